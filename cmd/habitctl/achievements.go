@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"gorm.io/gorm"
+
+	"habit-tracker/internal/events"
+	"habit-tracker/internal/models"
+	"habit-tracker/internal/repository"
+	"habit-tracker/internal/service"
+)
+
+func newAchievementService(gdb *gorm.DB) *service.AchievementService {
+	// 命令行没有事件订阅者，补发的成就不会触发 Webhook 与站内通知
+	return service.NewAchievementService(
+		repository.NewAchievementRepository(gdb),
+		repository.NewUserAchievementRepository(gdb),
+		repository.NewUserRepository(gdb),
+		events.NewBus(),
+	)
+}
+
+func syncAchievements(ctx context.Context, gdb *gorm.DB, args []string) error {
+	fs := flag.NewFlagSet("sync-achievements", flag.ExitOnError)
+	dryRun := fs.Bool("dry-run", false, "only report what would change")
+	fs.Parse(args)
+
+	report, err := newAchievementService(gdb).SyncCatalog(ctx, *dryRun)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
+
+// reevaluateAchievements unlocks what users already qualify for, e.g. after
+// new achievements were added to the catalog. It uses lifetime totals: the
+// longest streak of any habit, total_checkins and the points balance.
+func reevaluateAchievements(ctx context.Context, gdb *gorm.DB, args []string) error {
+	fs := flag.NewFlagSet("reevaluate-achievements", flag.ExitOnError)
+	username := fs.String("user", "", "only this user")
+	fs.Parse(args)
+
+	users := repository.NewUserRepository(gdb)
+	var list []models.User
+	if *username != "" {
+		user, err := users.GetByUsername(ctx, *username)
+		if err != nil {
+			return err
+		}
+		list = []models.User{*user}
+	} else {
+		var err error
+		if list, err = users.ListAll(ctx); err != nil {
+			return err
+		}
+	}
+
+	achievements := newAchievementService(gdb)
+	stats := service.NewUserStatsService(users, repository.NewHabitRepository(gdb), repository.NewCheckinRepository(gdb),
+		service.NewPointsService(users, repository.NewPointsRepository(gdb), events.NewBus()))
+	unlocked := 0
+	for _, u := range list {
+		st, err := stats.GetStats(ctx, u.ID)
+		if err != nil {
+			return err
+		}
+		newly, err := achievements.EvaluateAndUnlock(ctx, u.ID, service.AchievementMetrics{
+			CurrentStreakDays: st.LongestStreak,
+			TotalCheckins:     int(st.TotalCheckins),
+			TotalPoints:       u.Points,
+		})
+		if err != nil {
+			return err
+		}
+		for _, ua := range newly {
+			fmt.Printf("user %d %s: unlocked achievement %d\n", u.ID, u.Username, ua.AchievementID)
+		}
+		unlocked += len(newly)
+	}
+	fmt.Printf("checked %d users, unlocked %d achievements\n", len(list), unlocked)
+	return nil
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"os"
+
+	"gorm.io/gorm"
+
+	"habit-tracker/internal/events"
+	"habit-tracker/internal/repository"
+	"habit-tracker/internal/service"
+)
+
+func importData(ctx context.Context, gdb *gorm.DB, args []string) error {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	username := fs.String("user", "", "username to import into (required)")
+	file := fs.String("file", "", "Loop Habit Tracker zip/Checkmarks.csv or habit-tracker export zip (required)")
+	dryRun := fs.Bool("dry-run", false, "only report what would be imported")
+	awardPoints := fs.Bool("award-points", false, "award check-in points for imported completed days")
+	fs.Parse(args)
+	if *username == "" || *file == "" {
+		fs.Usage()
+		return errors.New("-user and -file are required")
+	}
+
+	users := repository.NewUserRepository(gdb)
+	user, err := users.GetByUsername(ctx, *username)
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(*file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	habits := repository.NewHabitRepository(gdb)
+	checkins := repository.NewCheckinRepository(gdb)
+	// 命令行没有事件订阅者，导入发放的积分不会触发 Webhook
+	points := service.NewPointsService(users, repository.NewPointsRepository(gdb), events.NewBus())
+	guard := service.NewStreakGuardService(
+		habits,
+		users,
+		checkins,
+		repository.NewStreakFreezeRepository(gdb),
+		repository.NewVacationRepository(gdb),
+		points,
+	)
+	// 导入只会创建习惯，不会删除照片，因此不需要 BlobStore
+	habitSvc := service.NewHabitService(habits, repository.NewCategoryRepository(gdb), repository.NewTagRepository(gdb), nil, guard)
+	imports := service.NewImportService(habitSvc, habits, users, checkins, points, guard)
+
+	report, err := imports.Import(ctx, user.ID, f, info.Size(), service.ImportOptions{
+		DryRun:      *dryRun,
+		AwardPoints: *awardPoints,
+	})
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
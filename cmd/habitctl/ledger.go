@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"gorm.io/gorm"
+
+	"habit-tracker/internal/repository"
+	"habit-tracker/internal/service"
+)
+
+func newLedgerService(gdb *gorm.DB) *service.LedgerService {
+	return service.NewLedgerService(
+		repository.NewUserRepository(gdb),
+		repository.NewPointsRepository(gdb),
+		repository.NewLedgerRepository(gdb),
+	)
+}
+
+// userIDArg resolves an optional -user flag; 0 means every user.
+func userIDArg(ctx context.Context, gdb *gorm.DB, username string) (uint64, error) {
+	if username == "" {
+		return 0, nil
+	}
+	user, err := repository.NewUserRepository(gdb).GetByUsername(ctx, username)
+	if err != nil {
+		return 0, err
+	}
+	return user.ID, nil
+}
+
+func reconcileCounters(ctx context.Context, gdb *gorm.DB, args []string) error {
+	fs := flag.NewFlagSet("reconcile-counters", flag.ExitOnError)
+	username := fs.String("user", "", "only this user")
+	fix := fs.Bool("fix", false, "correct drifted counters and record the changes in ledger_adjustments")
+	fs.Parse(args)
+
+	userID, err := userIDArg(ctx, gdb, *username)
+	if err != nil {
+		return err
+	}
+	report, err := newLedgerService(gdb).Reconcile(ctx, userID, *fix, service.LedgerSourceCLI)
+	if err != nil {
+		return err
+	}
+	for _, d := range report.Drifts {
+		status := "not fixed"
+		if d.Fixed {
+			status = "fixed"
+		} else if *fix {
+			status = "changed concurrently, run again"
+		}
+		fmt.Printf("user %d %s: points %d -> %d, total_checkins %d -> %d (%s)\n",
+			d.UserID, d.Username, d.Points, d.ExpectedPoints, d.TotalCheckins, d.ExpectedCheckins, status)
+	}
+	fmt.Printf("checked %d users, %d with drifted counters\n", report.Checked, len(report.Drifts))
+	return nil
+}
+
+func ledgerHistory(ctx context.Context, gdb *gorm.DB, args []string) error {
+	fs := flag.NewFlagSet("ledger-history", flag.ExitOnError)
+	username := fs.String("user", "", "only this user")
+	limit := fs.Int("limit", 50, "number of adjustments to print")
+	fs.Parse(args)
+
+	userID, err := userIDArg(ctx, gdb, *username)
+	if err != nil {
+		return err
+	}
+	items, err := newLedgerService(gdb).ListAdjustments(ctx, userID, *limit)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(items)
+}
@@ -0,0 +1,72 @@
+// 运维命令行工具
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+
+	"gorm.io/gorm"
+
+	"habit-tracker/internal/config"
+	"habit-tracker/internal/db"
+)
+
+type command struct {
+	name  string
+	usage string
+	run   func(ctx context.Context, gdb *gorm.DB, args []string) error
+}
+
+var commands = []command{
+	{name: "repair-streaks", usage: "rebuild persisted streak state from check-in history [-habit id]", run: repairStreaks},
+	{name: "import", usage: "import a Loop or habit-tracker export -user name -file path [-dry-run] [-award-points]", run: importData},
+	{name: "create-user", usage: "create an account -user name [-password pw] [-nickname n] [-timezone tz]", run: createUser},
+	{name: "reset-password", usage: "set a new password -user name [-password pw]", run: resetPassword},
+	{name: "user-stats", usage: "print profile, stats and achievements of -user name", run: userStats},
+	{name: "reconcile-counters", usage: "compare users.points and total_checkins with the points log [-user name] [-fix]", run: reconcileCounters},
+	{name: "ledger-history", usage: "print the counter corrections made by reconciliation [-user name] [-limit n]", run: ledgerHistory},
+	{name: "sync-achievements", usage: "write the built-in achievement catalog to the database [-dry-run]", run: syncAchievements},
+	{name: "reevaluate-achievements", usage: "unlock achievements users already qualify for [-user name]", run: reevaluateAchievements},
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	var cmd *command
+	for i := range commands {
+		if commands[i].name == os.Args[1] {
+			cmd = &commands[i]
+		}
+	}
+	if cmd == nil {
+		usage()
+		os.Exit(2)
+	}
+
+	driver, dsn, err := config.LoadDB()
+	if err != nil {
+		log.Fatalf("load config: %v", err)
+	}
+	gdb, err := db.Init(driver, dsn)
+	if err != nil {
+		log.Fatalf("init db: %v", err)
+	}
+	if err := db.Migrate(gdb); err != nil {
+		log.Fatalf("migrate db: %v", err)
+	}
+
+	if err := cmd.run(context.Background(), gdb, os.Args[2:]); err != nil {
+		log.Fatalf("%s: %v", cmd.name, err)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: habitctl <command> [flags]")
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "  %-24s %s\n", c.name, c.usage)
+	}
+}
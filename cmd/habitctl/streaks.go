@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"habit-tracker/internal/repository"
+	"habit-tracker/internal/service"
+)
+
+func repairStreaks(ctx context.Context, gdb *gorm.DB, args []string) error {
+	fs := flag.NewFlagSet("repair-streaks", flag.ExitOnError)
+	habitID := fs.Uint64("habit", 0, "only repair this habit")
+	fs.Parse(args)
+
+	habits := repository.NewHabitRepository(gdb)
+	// 重建连续天数不会购买冻结卡，因此不需要 PointsService
+	guard := service.NewStreakGuardService(
+		habits,
+		repository.NewUserRepository(gdb),
+		repository.NewCheckinRepository(gdb),
+		repository.NewStreakFreezeRepository(gdb),
+		repository.NewVacationRepository(gdb),
+		nil,
+	)
+
+	if *habitID != 0 {
+		habit, err := habits.GetByID(ctx, *habitID)
+		if err != nil {
+			return err
+		}
+		changed, err := guard.RebuildStreak(ctx, habit)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("habit %d: changed=%t current=%d longest=%d\n", habit.ID, changed, habit.CurrentStreak, habit.LongestStreak)
+		return nil
+	}
+
+	changed, err := guard.RebuildStreaks(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("repaired %d habits\n", changed)
+	return nil
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"habit-tracker/internal/events"
+	"habit-tracker/internal/models"
+	"habit-tracker/internal/repository"
+	"habit-tracker/internal/service"
+)
+
+func createUser(ctx context.Context, gdb *gorm.DB, args []string) error {
+	fs := flag.NewFlagSet("create-user", flag.ExitOnError)
+	username := fs.String("user", "", "username (required)")
+	password := fs.String("password", "", "password; read from stdin when empty")
+	nickname := fs.String("nickname", "", "nickname")
+	timezone := fs.String("timezone", "", "IANA time zone, default UTC")
+	fs.Parse(args)
+	if *username == "" {
+		fs.Usage()
+		return errors.New("-user is required")
+	}
+	pw, err := passwordArg(*password)
+	if err != nil {
+		return err
+	}
+
+	// 注册不签发令牌，不需要 JWT 密钥
+	auth := service.NewAuthService(repository.NewUserRepository(gdb), nil)
+	user, err := auth.Register(ctx, *username, pw, *nickname, *timezone)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("created user %d %s\n", user.ID, user.Username)
+	return nil
+}
+
+func resetPassword(ctx context.Context, gdb *gorm.DB, args []string) error {
+	fs := flag.NewFlagSet("reset-password", flag.ExitOnError)
+	username := fs.String("user", "", "username (required)")
+	password := fs.String("password", "", "new password; read from stdin when empty")
+	fs.Parse(args)
+	if *username == "" {
+		fs.Usage()
+		return errors.New("-user is required")
+	}
+	pw, err := passwordArg(*password)
+	if err != nil {
+		return err
+	}
+
+	auth := service.NewAuthService(repository.NewUserRepository(gdb), nil)
+	if err := auth.ResetPassword(ctx, *username, pw); err != nil {
+		return err
+	}
+	fmt.Printf("password of %s reset\n", *username)
+	return nil
+}
+
+// passwordArg returns the flag value, or the first line of stdin so the
+// password does not end up in the shell history.
+func passwordArg(flagValue string) (string, error) {
+	if flagValue != "" {
+		return flagValue, nil
+	}
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("read password from stdin: %w", err)
+	}
+	pw := strings.TrimRight(line, "\r\n")
+	if pw == "" {
+		return "", errors.New("empty password")
+	}
+	return pw, nil
+}
+
+type userReport struct {
+	User         *models.User             `json:"user"`
+	Stats        *service.UserStats       `json:"stats"`
+	Habits       int                      `json:"habits"`
+	Achievements []models.UserAchievement `json:"achievements"`
+}
+
+func userStats(ctx context.Context, gdb *gorm.DB, args []string) error {
+	fs := flag.NewFlagSet("user-stats", flag.ExitOnError)
+	username := fs.String("user", "", "username (required)")
+	fs.Parse(args)
+	if *username == "" {
+		fs.Usage()
+		return errors.New("-user is required")
+	}
+
+	users := repository.NewUserRepository(gdb)
+	user, err := users.GetByUsername(ctx, *username)
+	if err != nil {
+		return err
+	}
+	habits := repository.NewHabitRepository(gdb)
+	stats := service.NewUserStatsService(users, habits, repository.NewCheckinRepository(gdb),
+		service.NewPointsService(users, repository.NewPointsRepository(gdb), events.NewBus()))
+	report := userReport{User: user}
+	if report.Stats, err = stats.GetStats(ctx, user.ID); err != nil {
+		return err
+	}
+	list, err := habits.ListByUser(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	report.Habits = len(list)
+	if report.Achievements, err = repository.NewUserAchievementRepository(gdb).ListByUser(ctx, user.ID); err != nil {
+		return err
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
@@ -2,18 +2,12 @@
 package main
 
 import (
+	"context"
 	"log"
 
-	"github.com/gin-gonic/gin"
-
+	"habit-tracker/internal/app"
 	"habit-tracker/internal/config"
 	"habit-tracker/internal/db"
-	"habit-tracker/internal/handler"
-	"habit-tracker/internal/middleware"
-	"habit-tracker/internal/repository"
-	"habit-tracker/internal/router"
-	"habit-tracker/internal/service"
-	"habit-tracker/internal/utils"
 )
 
 func main() {
@@ -22,49 +16,25 @@ func main() {
 		log.Fatalf("load config: %v", err)
 	}
 
-	if _, err := db.Init(cfg.DBDSN); err != nil {
+	if _, err := db.Init(cfg.DBDriver, cfg.DBDSN); err != nil {
 		log.Fatalf("init db: %v", err)
 	}
+	if err := db.Migrate(db.DB); err != nil {
+		log.Fatalf("migrate db: %v", err)
+	}
 
-	userRepo := repository.NewUserRepository(db.DB)
-	habitRepo := repository.NewHabitRepository(db.DB)
-	checkinRepo := repository.NewCheckinRepository(db.DB)
-	pointsRepo := repository.NewPointsRepository(db.DB)
-	achRepo := repository.NewAchievementRepository(db.DB)
-	userAchRepo := repository.NewUserAchievementRepository(db.DB)
-
-	jwtManager := utils.NewJWTManager(cfg.JWTSecret, 0)
-	authSvc := service.NewAuthService(userRepo, jwtManager)
-	authHandler := handler.NewAuthHandler(authSvc)
-
-	pointsSvc := service.NewPointsService(userRepo, pointsRepo)
-	achSvc := service.NewAchievementService(achRepo, userAchRepo)
-	habitSvc := service.NewHabitService(habitRepo)
-	checkinSvc := service.NewCheckinService(habitRepo, userRepo, checkinRepo, pointsSvc, achSvc)
-	leaderboardSvc := service.NewLeaderboardService(userRepo, pointsRepo)
-	userStatsSvc := service.NewUserStatsService(userRepo, habitRepo, checkinRepo, pointsSvc)
-	habitHandler := handler.NewHabitHandler(habitSvc)
-	checkinHandler := handler.NewCheckinHandler(checkinSvc)
-	authMW := middleware.AuthMiddleware(jwtManager)
-	leaderboardHandler := handler.NewLeaderboardHandler(leaderboardSvc)
-	userHandler := handler.NewUserHandler(userStatsSvc)
-	achHandler := handler.NewAchievementHandler(achSvc)
-
-	r := gin.New()
-	r.Use(gin.Logger(), gin.Recovery())
-	router.Register(r, router.Deps{
-		AuthHandler:        authHandler,
-		HabitHandler:       habitHandler,
-		CheckinHandler:     checkinHandler,
-		LeaderboardHandler: leaderboardHandler,
-		UserHandler:        userHandler,
-		AchievementHandler: achHandler,
-		AuthMW:             authMW,
-	})
+	a, err := app.New(cfg, db.DB)
+	if err != nil {
+		log.Fatalf("init app: %v", err)
+	}
+	if err := a.RunStartup(context.Background()); err != nil {
+		log.Fatalf("startup: %v", err)
+	}
+	a.StartJobs(context.Background())
 
 	addr := ":" + cfg.Port
 	log.Printf("listening on %s", addr)
-	if err := r.Run(addr); err != nil {
+	if err := a.Router.Run(addr); err != nil {
 		log.Fatalf("run server: %v", err)
 	}
 }
@@ -0,0 +1,173 @@
+// 组装服务：仓储、业务服务、事件订阅、路由与后台任务
+package app
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"habit-tracker/internal/config"
+	"habit-tracker/internal/events"
+	"habit-tracker/internal/handler"
+	"habit-tracker/internal/jobs"
+	"habit-tracker/internal/middleware"
+	"habit-tracker/internal/repository"
+	"habit-tracker/internal/router"
+	"habit-tracker/internal/service"
+	"habit-tracker/internal/storage"
+	"habit-tracker/internal/utils"
+)
+
+// Job is a background task run every Interval. Startup jobs run once and
+// leave Interval zero.
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context) error
+}
+
+// App is the wired server. It is shared by cmd/server and the tests that
+// need the real router.
+type App struct {
+	Router *gin.Engine
+	Bus    *events.Bus
+	Jobs   []Job
+	// Startup runs once before serving, e.g. data backfills after a migration.
+	Startup []Job
+}
+
+// New wires every layer on top of an already migrated database.
+func New(cfg config.Config, gdb *gorm.DB) (*App, error) {
+	userRepo := repository.NewUserRepository(gdb)
+	habitRepo := repository.NewHabitRepository(gdb)
+	checkinRepo := repository.NewCheckinRepository(gdb)
+	pointsRepo := repository.NewPointsRepository(gdb)
+	achRepo := repository.NewAchievementRepository(gdb)
+	userAchRepo := repository.NewUserAchievementRepository(gdb)
+	categoryRepo := repository.NewCategoryRepository(gdb)
+	tagRepo := repository.NewTagRepository(gdb)
+	freezeRepo := repository.NewStreakFreezeRepository(gdb)
+	vacationRepo := repository.NewVacationRepository(gdb)
+	reminderRepo := repository.NewReminderRepository(gdb)
+	notificationRepo := repository.NewNotificationRepository(gdb)
+	webhookRepo := repository.NewWebhookRepository(gdb)
+	ledgerRepo := repository.NewLedgerRepository(gdb)
+
+	blobStore, err := storage.NewLocalStore(cfg.UploadDir)
+	if err != nil {
+		return nil, fmt.Errorf("init upload dir: %w", err)
+	}
+
+	jwtManager := utils.NewJWTManager(cfg.JWTSecret, 0)
+	authSvc := service.NewAuthService(userRepo, jwtManager)
+	authHandler := handler.NewAuthHandler(authSvc)
+
+	bus := events.NewBus()
+	webhookSvc := service.NewWebhookService(webhookRepo)
+	pointsSvc := service.NewPointsService(userRepo, pointsRepo, bus)
+	achSvc := service.NewAchievementService(achRepo, userAchRepo, userRepo, bus)
+	journalSvc := service.NewJournalService(checkinRepo, blobStore)
+	categorySvc := service.NewCategoryService(categoryRepo, tagRepo)
+	guardSvc := service.NewStreakGuardService(habitRepo, userRepo, checkinRepo, freezeRepo, vacationRepo, pointsSvc)
+	habitSvc := service.NewHabitService(habitRepo, categoryRepo, tagRepo, blobStore, guardSvc)
+	checkinSvc := service.NewCheckinService(habitRepo, userRepo, checkinRepo, guardSvc, bus)
+	leaderboardSvc := service.NewLeaderboardService(userRepo, pointsRepo)
+	streamSvc := service.NewStreamService(leaderboardSvc)
+	habitStatsSvc := service.NewHabitStatsService(habitRepo, checkinRepo, guardSvc)
+	userStatsSvc := service.NewUserStatsService(userRepo, habitRepo, checkinRepo, pointsSvc)
+	profileSvc := service.NewProfileService(userRepo)
+	analyticsSvc := service.NewAnalyticsService(profileSvc, pointsRepo, checkinRepo)
+	exportSvc := service.NewExportService(profileSvc, habitRepo, categoryRepo, checkinRepo, pointsRepo, achRepo, userAchRepo)
+	importSvc := service.NewImportService(habitSvc, habitRepo, userRepo, checkinRepo, pointsSvc, guardSvc)
+	calendarSvc := service.NewCalendarService(userRepo, habitRepo, checkinRepo)
+	notifier := service.MultiNotifier{service.NewInboxNotifier(notificationRepo), service.NewWebhookNotifier()}
+	reminderSvc := service.NewReminderService(reminderRepo, habitRepo, userRepo, checkinRepo, vacationRepo, notifier)
+	notificationSvc := service.NewNotificationService(notificationRepo, userRepo, pointsRepo)
+	ledgerSvc := service.NewLedgerService(userRepo, pointsRepo, ledgerRepo)
+
+	// 事件订阅：同步订阅者在发布事件的请求内按注册顺序执行，出错则请求失败
+	events.Subscribe(bus, pointsSvc.OnTargetReached)
+	events.Subscribe(bus, achSvc.OnTargetReached)
+	events.Subscribe(bus, achSvc.OnCheckinRecorded)
+	events.Subscribe(bus, webhookSvc.OnCheckinRecorded)
+	events.Subscribe(bus, webhookSvc.OnTargetReached)
+	events.Subscribe(bus, webhookSvc.OnPointsChanged)
+	events.Subscribe(bus, webhookSvc.OnAchievementUnlocked)
+	events.Subscribe(bus, streamSvc.OnCheckinRecorded)
+	events.Subscribe(bus, streamSvc.OnPointsChanged)
+	events.Subscribe(bus, streamSvc.OnAchievementUnlocked)
+	// 异步订阅者在请求结束后执行，失败只记日志
+	events.SubscribeAsync(bus, notificationSvc.OnAchievementUnlocked)
+	events.SubscribeAsync(bus, notificationSvc.OnPointsChanged)
+
+	r := gin.New()
+	// 事件流票据在 URL 中；旧客户端可能仍带 access_token，一并从访问日志中隐去
+	r.Use(middleware.Logger("ticket", "access_token"), gin.Recovery(), middleware.ErrorHandler())
+	router.Register(r, router.Deps{
+		AuthHandler:         authHandler,
+		HabitHandler:        handler.NewHabitHandler(habitSvc),
+		CheckinHandler:      handler.NewCheckinHandler(checkinSvc),
+		LeaderboardHandler:  handler.NewLeaderboardHandler(leaderboardSvc),
+		UserHandler:         handler.NewUserHandler(userStatsSvc, profileSvc),
+		AchievementHandler:  handler.NewAchievementHandler(achSvc),
+		CategoryHandler:     handler.NewCategoryHandler(categorySvc),
+		JournalHandler:      handler.NewJournalHandler(journalSvc),
+		StreakHandler:       handler.NewStreakHandler(guardSvc),
+		StatsHandler:        handler.NewStatsHandler(habitStatsSvc, analyticsSvc),
+		ExportHandler:       handler.NewExportHandler(exportSvc),
+		ImportHandler:       handler.NewImportHandler(importSvc),
+		CalendarHandler:     handler.NewCalendarHandler(calendarSvc),
+		ReminderHandler:     handler.NewReminderHandler(reminderSvc),
+		WebhookHandler:      handler.NewWebhookHandler(webhookSvc),
+		StreamHandler:       handler.NewStreamHandler(streamSvc),
+		NotificationHandler: handler.NewNotificationHandler(notificationSvc),
+		AuthMW:              middleware.AuthMiddleware(jwtManager),
+	})
+
+	return &App{
+		Router: r,
+		Bus:    bus,
+		Jobs: []Job{
+			{"purge-deleted-habits", time.Hour, habitSvc.PurgeExpired},
+			{"award-clean-days", time.Hour, checkinSvc.AwardCleanDays},
+			{"protect-streaks", time.Hour, guardSvc.ProtectStreaks},
+			{"send-reminders", time.Minute, reminderSvc.SendDue},
+			{"deliver-webhooks", 10 * time.Second, webhookSvc.Deliver},
+			{"purge-webhook-deliveries", time.Hour, webhookSvc.PurgeDeliveries},
+			{"push-leaderboard", 5 * time.Second, streamSvc.PushLeaderboard},
+			{"purge-stream", time.Minute, streamSvc.Purge},
+			{"purge-notifications", time.Hour, notificationSvc.Purge},
+			{"reconcile-counters", time.Hour, ledgerSvc.ReconcileJob},
+		},
+		Startup: []Job{
+			{Name: "backfill-streaks", Run: func(ctx context.Context) error {
+				n, err := guardSvc.BackfillStreaks(ctx)
+				if n > 0 {
+					log.Printf("已补齐 %d 个习惯的连续天数", n)
+				}
+				return err
+			}},
+		},
+	}, nil
+}
+
+// RunStartup runs the startup jobs in order and stops at the first error.
+func (a *App) RunStartup(ctx context.Context) error {
+	for _, j := range a.Startup {
+		if err := j.Run(ctx); err != nil {
+			return fmt.Errorf("%s: %w", j.Name, err)
+		}
+	}
+	return nil
+}
+
+// StartJobs runs every background job on its own goroutine until ctx is done.
+func (a *App) StartJobs(ctx context.Context) {
+	for _, j := range a.Jobs {
+		go jobs.Every(ctx, j.Name, j.Interval, j.Run)
+	}
+}
@@ -0,0 +1,372 @@
+package app_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"habit-tracker/internal/app/apptest"
+	"habit-tracker/internal/models"
+)
+
+// These tests run the full router on SQLite, the database of local
+// development, and check that the PostgreSQL specific queries degrade
+// correctly there.
+
+type apiClient struct {
+	t     *testing.T
+	base  string
+	token string
+}
+
+// call sends body as JSON and decodes the data of the response into out.
+func (c *apiClient) call(method, path string, body, out interface{}) int {
+	c.t.Helper()
+	var reader *bytes.Reader
+	if body != nil {
+		raw, err := json.Marshal(body)
+		if err != nil {
+			c.t.Fatal(err)
+		}
+		reader = bytes.NewReader(raw)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+	req, err := http.NewRequest(method, c.base+"/api/v1"+path, reader)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		c.t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+	var env struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&env); err != nil {
+		c.t.Fatalf("%s %s: decode response: %v", method, path, err)
+	}
+	if out != nil && resp.StatusCode == http.StatusOK {
+		if err := json.Unmarshal(env.Data, out); err != nil {
+			c.t.Fatalf("%s %s: decode data: %v", method, path, err)
+		}
+	}
+	return resp.StatusCode
+}
+
+// mustCall is call for requests that have to succeed.
+func (c *apiClient) mustCall(method, path string, body, out interface{}) {
+	c.t.Helper()
+	if status := c.call(method, path, body, out); status != http.StatusOK {
+		c.t.Fatalf("%s %s: status %d", method, path, status)
+	}
+}
+
+type profile struct {
+	ID            uint64 `json:"id"`
+	Points        int64  `json:"points"`
+	TotalCheckins int64  `json:"total_checkins"`
+}
+
+type checkinResult struct {
+	TodayCount    int
+	ReachedTarget bool
+	PointsAwarded int
+}
+
+type leaderboardEntry struct {
+	UserID uint64 `json:"user_id"`
+	Points int64  `json:"points"`
+	Rank   int    `json:"rank"`
+}
+
+// signUp registers and logs in username.
+func signUp(t *testing.T, srv *apptest.Server, username string) *apiClient {
+	t.Helper()
+	c := &apiClient{t: t, base: srv.URL}
+	credentials := map[string]string{"username": username, "password": "secret123"}
+	c.mustCall(http.MethodPost, "/auth/register", credentials, nil)
+	var session struct {
+		Token string `json:"token"`
+	}
+	c.mustCall(http.MethodPost, "/auth/login", credentials, &session)
+	c.token = session.Token
+	return c
+}
+
+func createHabit(c *apiClient, fields map[string]interface{}) uint64 {
+	c.t.Helper()
+	body := map[string]interface{}{
+		"name":        "Read",
+		"target_type": "daily",
+		"start_date":  time.Now().AddDate(0, 0, -30).Format("2006-01-02"),
+	}
+	for k, v := range fields {
+		body[k] = v
+	}
+	var habit struct {
+		ID uint64 `json:"id"`
+	}
+	c.mustCall(http.MethodPost, "/habits", body, &habit)
+	return habit.ID
+}
+
+func TestRegisterCheckinLeaderboard(t *testing.T) {
+	srv := apptest.New(t)
+	alice := signUp(t, srv, "alice")
+	bob := signUp(t, srv, "bob")
+
+	read := createHabit(alice, map[string]interface{}{"target_times": 1})
+	run := createHabit(alice, map[string]interface{}{"name": "Run", "target_times": 1})
+	walk := createHabit(bob, map[string]interface{}{"name": "Walk", "target_times": 1})
+
+	var res checkinResult
+	alice.mustCall(http.MethodPost, "/checkins", map[string]interface{}{"habit_id": read}, &res)
+	if !res.ReachedTarget || res.TodayCount != 1 || res.PointsAwarded <= 0 {
+		t.Fatalf("alice checkin = %+v", res)
+	}
+	alicePoints := int64(res.PointsAwarded)
+	alice.mustCall(http.MethodPost, "/checkins", map[string]interface{}{"habit_id": run}, &res)
+	alicePoints += int64(res.PointsAwarded)
+	bob.mustCall(http.MethodPost, "/checkins", map[string]interface{}{"habit_id": walk}, &res)
+	bobPoints := int64(res.PointsAwarded)
+
+	if status := bob.call(http.MethodPost, "/checkins", map[string]interface{}{"habit_id": read}, nil); status != http.StatusForbidden {
+		t.Fatalf("checkin on another user's habit: status %d", status)
+	}
+
+	var me profile
+	alice.mustCall(http.MethodGet, "/user/profile", nil, &me)
+	if me.Points != alicePoints || me.TotalCheckins != 2 {
+		t.Fatalf("alice profile = %+v, want %d points and 2 checkins", me, alicePoints)
+	}
+	var bobProfile profile
+	bob.mustCall(http.MethodGet, "/user/profile", nil, &bobProfile)
+
+	for _, period := range []string{"weekly", "monthly"} {
+		var board []leaderboardEntry
+		alice.mustCall(http.MethodGet, "/leaderboard/"+period, nil, &board)
+		if len(board) != 2 {
+			t.Fatalf("%s leaderboard = %+v", period, board)
+		}
+		if board[0].UserID != me.ID || board[0].Points != alicePoints || board[0].Rank != 1 {
+			t.Fatalf("%s leaderboard first = %+v, want alice with %d points", period, board[0], alicePoints)
+		}
+		if board[1].UserID != bobProfile.ID || board[1].Points != bobPoints || board[1].Rank != 2 {
+			t.Fatalf("%s leaderboard second = %+v, want bob with %d points", period, board[1], bobPoints)
+		}
+	}
+}
+
+// SQLite ignores SELECT ... FOR UPDATE; concurrent check-ins on one habit
+// must still be serialised.
+func TestConcurrentCheckins(t *testing.T) {
+	srv := apptest.New(t)
+	c := signUp(t, srv, "alice")
+	habit := createHabit(c, map[string]interface{}{"target_times": 5})
+
+	const n = 8
+	var wg sync.WaitGroup
+	statuses := make([]int, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			statuses[i] = c.call(http.MethodPost, "/checkins", map[string]interface{}{"habit_id": habit}, nil)
+		}(i)
+	}
+	wg.Wait()
+	for i, status := range statuses {
+		if status != http.StatusOK {
+			t.Fatalf("checkin %d: status %d", i, status)
+		}
+	}
+
+	var checkins []struct {
+		Count int `json:"count"`
+	}
+	c.mustCall(http.MethodGet, fmt.Sprintf("/habits/%d/checkins", habit), nil, &checkins)
+	if len(checkins) != 1 || checkins[0].Count != n {
+		t.Fatalf("checkins = %+v, want one day with count %d", checkins, n)
+	}
+	// the target is reached once, so only one check-in is counted
+	var me profile
+	c.mustCall(http.MethodGet, "/user/profile", nil, &me)
+	if me.TotalCheckins != 1 {
+		t.Fatalf("total_checkins = %d, want 1", me.TotalCheckins)
+	}
+}
+
+func TestStatsAndSeries(t *testing.T) {
+	srv := apptest.New(t)
+	c := signUp(t, srv, "alice")
+	// points and check-ins both count the user's days, in a zone far from UTC
+	// so that the local day often differs from the UTC day
+	const tz = "Pacific/Kiritimati"
+	c.mustCall(http.MethodPut, "/user/timezone", map[string]string{"timezone": tz}, nil)
+	habit := createHabit(c, map[string]interface{}{"target_times": 1})
+
+	var res checkinResult
+	c.mustCall(http.MethodPost, "/checkins", map[string]interface{}{"habit_id": habit}, &res)
+	// relapses of quit habits are not check-ins in any of the statistics
+	quit := createHabit(c, map[string]interface{}{"name": "No sugar", "polarity": "quit"})
+	c.mustCall(http.MethodPost, fmt.Sprintf("/habits/%d/relapses", quit), nil, nil)
+
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	today := time.Now().In(loc)
+	var checkins []struct {
+		CheckinDate time.Time `json:"checkin_date"`
+	}
+	c.mustCall(http.MethodGet, fmt.Sprintf("/habits/%d/checkins", habit), nil, &checkins)
+	if len(checkins) != 1 {
+		t.Fatalf("checkins = %+v", checkins)
+	}
+	checkinDay := checkins[0].CheckinDate.Format("2006-01-02")
+	if want := today.Format("2006-01-02"); checkinDay != want {
+		t.Fatalf("check-in stored on %s, want the user's day %s", checkinDay, want)
+	}
+	weekday := int(checkins[0].CheckinDate.Weekday())
+
+	var stats struct {
+		CompletedDays int  `json:"completed_days"`
+		BestWeekday   *int `json:"best_weekday"`
+		Weekdays      []struct {
+			Weekday   int `json:"weekday"`
+			Completed int `json:"completed"`
+		} `json:"weekdays"`
+	}
+	c.mustCall(http.MethodGet, fmt.Sprintf("/habits/%d/stats", habit), nil, &stats)
+	if stats.CompletedDays != 1 || stats.BestWeekday == nil || *stats.BestWeekday != weekday {
+		t.Fatalf("habit stats = %+v, want one completed day on weekday %d", stats, weekday)
+	}
+	for _, d := range stats.Weekdays {
+		if want := map[bool]int{true: 1}[d.Weekday == weekday]; d.Completed != want {
+			t.Fatalf("weekday %d completed = %d, want %d", d.Weekday, d.Completed, want)
+		}
+	}
+
+	var userStats struct {
+		WeeklyCheckins  int64 `json:"weekly_checkins"`
+		MonthlyCheckins int64 `json:"monthly_checkins"`
+	}
+	c.mustCall(http.MethodGet, "/user/stats", nil, &userStats)
+	if userStats.WeeklyCheckins != 1 || userStats.MonthlyCheckins != 1 {
+		t.Fatalf("user stats = %+v, want the one check-in this week and month", userStats)
+	}
+
+	var series struct {
+		Buckets          []string `json:"buckets"`
+		Points           []int64  `json:"points"`
+		CumulativePoints []int64  `json:"cumulative_points"`
+		Checkins         []int64  `json:"checkins"`
+	}
+	points := int64(res.PointsAwarded)
+	for _, granularity := range []string{"day", "week", "month"} {
+		c.mustCall(http.MethodGet, "/user/series?granularity="+granularity, nil, &series)
+		last := len(series.Buckets) - 1
+		if last < 0 {
+			t.Fatalf("%s series has no buckets", granularity)
+		}
+		var sum, checkins int64
+		for i := range series.Buckets {
+			sum += series.Points[i]
+			checkins += series.Checkins[i]
+		}
+		if series.Points[last] != points || sum != points || series.CumulativePoints[last] != points {
+			t.Fatalf("%s series points = %v, cumulative %v, want %d in the last bucket", granularity, series.Points, series.CumulativePoints, points)
+		}
+		if checkins != 1 {
+			t.Fatalf("%s series checkins = %v, want 1", granularity, series.Checkins)
+		}
+	}
+	c.mustCall(http.MethodGet, "/user/series?granularity=day", nil, &series)
+	if got, want := series.Buckets[len(series.Buckets)-1], today.Format("2006-01-02"); got != want {
+		t.Fatalf("last day bucket = %s, want %s", got, want)
+	}
+	for i, bucket := range series.Buckets {
+		if want := map[bool]int64{true: 1}[bucket == checkinDay]; series.Checkins[i] != want {
+			t.Fatalf("checkins on %s = %d, want %d", bucket, series.Checkins[i], want)
+		}
+	}
+
+	// the balance before the range comes from SumBefore
+	from := today.AddDate(0, 0, 1).Format("2006-01-02")
+	c.mustCall(http.MethodGet, "/user/series?granularity=day&start_date="+from+"&end_date="+from, nil, &series)
+	if len(series.CumulativePoints) != 1 || series.CumulativePoints[0] != points || series.Points[0] != 0 {
+		t.Fatalf("series after today = %+v, want balance %d", series, points)
+	}
+}
+
+func TestStartupBackfillsStreaks(t *testing.T) {
+	srv := apptest.New(t)
+	c := signUp(t, srv, "alice")
+	habit := createHabit(c, map[string]interface{}{"target_times": 1})
+	c.mustCall(http.MethodPost, "/checkins", map[string]interface{}{"habit_id": habit}, nil)
+
+	// a habit migrated from before the streak columns existed
+	if err := srv.DB.Model(&models.Habit{}).Where("id = ?", habit).
+		Updates(map[string]interface{}{"current_streak": 0, "longest_streak": 0, "last_completed_date": nil}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.RunStartup(context.Background()); err != nil {
+		t.Fatalf("run startup: %v", err)
+	}
+	var got models.Habit
+	if err := srv.DB.First(&got, habit).Error; err != nil {
+		t.Fatal(err)
+	}
+	if got.CurrentStreak != 1 || got.LongestStreak != 1 || got.LastCompletedDate == nil {
+		t.Fatalf("streak state = %d/%d/%v, want 1/1/today", got.CurrentStreak, got.LongestStreak, got.LastCompletedDate)
+	}
+}
+
+func TestConcurrentCleanDayAwards(t *testing.T) {
+	srv := apptest.New(t)
+	c := signUp(t, srv, "alice")
+	createHabit(c, map[string]interface{}{
+		"name":       "No sugar",
+		"polarity":   "quit",
+		"start_date": time.Now().UTC().AddDate(0, 0, -3).Format("2006-01-02"),
+	})
+	var award func(ctx context.Context) error
+	for _, j := range srv.Jobs {
+		if j.Name == "award-clean-days" {
+			award = j.Run
+		}
+	}
+
+	// two instances running the job at once pay each clean day once
+	var wg sync.WaitGroup
+	errs := make([]error, 2)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = award(context.Background())
+		}(i)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			t.Fatalf("award clean days: %v", err)
+		}
+	}
+	var me profile
+	c.mustCall(http.MethodGet, "/user/profile", nil, &me)
+	if me.TotalCheckins != 3 || me.Points != 3 {
+		t.Fatalf("profile = %+v, want 3 clean days counted and paid once", me)
+	}
+}
@@ -0,0 +1,56 @@
+// Package apptest runs the real server on a private in-memory SQLite
+// database, for tests that exercise the HTTP API end to end.
+package apptest
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"habit-tracker/internal/app"
+	"habit-tracker/internal/config"
+	"habit-tracker/internal/db"
+)
+
+var dbSeq atomic.Int64
+
+// Server is a started test server; URL is its base address.
+type Server struct {
+	*app.App
+	DB  *gorm.DB
+	URL string
+}
+
+// New starts a server with an empty, migrated database. Background jobs are
+// not started; tests run them through Jobs when they need them.
+func New(t testing.TB) *Server {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	dsn := fmt.Sprintf("file:apptest%d?mode=memory&cache=shared", dbSeq.Add(1))
+	gdb, err := db.Open(db.DriverSQLite, dsn, &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	if err := db.Migrate(gdb); err != nil {
+		t.Fatalf("migrate db: %v", err)
+	}
+	a, err := app.New(config.Config{JWTSecret: "apptest", UploadDir: t.TempDir()}, gdb)
+	if err != nil {
+		t.Fatalf("init app: %v", err)
+	}
+	srv := httptest.NewServer(a.Router)
+	t.Cleanup(func() {
+		srv.Close()
+		a.Bus.Wait()
+		if sqlDB, err := gdb.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return &Server{App: a, DB: gdb, URL: srv.URL}
+}
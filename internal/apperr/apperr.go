@@ -0,0 +1,106 @@
+// 统一业务错误模型
+package apperr
+
+import (
+	"errors"
+	"net/http"
+
+	"gorm.io/gorm"
+)
+
+// Kind classifies an error and decides the HTTP status it maps to.
+type Kind int
+
+const (
+	KindInternal Kind = iota
+	KindInvalid
+	KindUnauthorized
+	KindForbidden
+	KindNotFound
+	KindConflict
+)
+
+// HTTPStatus maps the error kind to an HTTP status code.
+func (k Kind) HTTPStatus() int {
+	switch k {
+	case KindInvalid:
+		return http.StatusBadRequest
+	case KindUnauthorized:
+		return http.StatusUnauthorized
+	case KindForbidden:
+		return http.StatusForbidden
+	case KindNotFound:
+		return http.StatusNotFound
+	case KindConflict:
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// Error is a business error with a stable machine-readable code.
+// Message is safe to show to clients; Err keeps the underlying cause for logs.
+type Error struct {
+	Kind    Kind
+	Code    string
+	Message string
+	Err     error
+}
+
+func New(kind Kind, code, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// Is matches errors by code so wrapped copies still match their sentinel.
+// Errors built by Invalid share one code and are told apart by message.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	if !ok || t.Code != e.Code {
+		return false
+	}
+	return e.Code != codeInvalid || t.Message == e.Message
+}
+
+// Wrap returns a copy of e carrying err as its cause.
+func (e *Error) Wrap(err error) *Error {
+	cp := *e
+	cp.Err = err
+	return &cp
+}
+
+// Common errors shared across layers.
+var (
+	ErrInternal     = New(KindInternal, "internal_error", "internal server error")
+	ErrUnauthorized = New(KindUnauthorized, "unauthorized", "unauthorized")
+	ErrNotFound     = New(KindNotFound, "not_found", "resource not found")
+)
+
+const codeInvalid = "invalid_argument"
+
+// Invalid builds a validation error with the given client-facing message.
+// Errors that callers need to tell apart get their own code through New.
+func Invalid(message string) *Error {
+	return New(KindInvalid, codeInvalid, message)
+}
+
+// From converts any error into an *Error. Unknown errors become internal
+// errors so raw driver messages never reach clients.
+func From(err error) *Error {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotFound.Wrap(err)
+	}
+	return ErrInternal.Wrap(err)
+}
@@ -0,0 +1,25 @@
+package apperr
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestIsTellsInvalidErrorsApart(t *testing.T) {
+	tooLarge := Invalid("photo is too large")
+	unsupported := Invalid("photo must be an image")
+	timezone := New(KindInvalid, "invalid_timezone", "invalid timezone")
+
+	if !errors.Is(tooLarge.Wrap(errors.New("cause")), tooLarge) {
+		t.Error("a wrapped copy should match its sentinel")
+	}
+	if errors.Is(tooLarge, unsupported) {
+		t.Error("invalid errors with different messages should not match")
+	}
+	if errors.Is(timezone, tooLarge) || errors.Is(tooLarge, timezone) {
+		t.Error("errors with different codes should not match")
+	}
+	if !errors.Is(New(KindInvalid, "invalid_timezone", "unknown zone"), timezone) {
+		t.Error("errors with the same specific code should match")
+	}
+}
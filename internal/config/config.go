@@ -8,18 +8,21 @@ import (
 )
 
 type Config struct {
+	DBDriver  string // "postgres" (openGauss) or "sqlite"
 	DBDSN     string
 	Port      string
 	JWTSecret string
+	UploadDir string
 }
 
 func Load() (Config, error) {
 	var cfg Config
 
-	cfg.DBDSN = strings.TrimSpace(os.Getenv("DB_DSN"))
-	if cfg.DBDSN == "" {
-		return Config{}, fmt.Errorf("missing env DB_DSN")
+	driver, dsn, err := LoadDB()
+	if err != nil {
+		return Config{}, err
 	}
+	cfg.DBDriver, cfg.DBDSN = driver, dsn
 
 	cfg.Port = strings.TrimSpace(os.Getenv("PORT"))
 	if cfg.Port == "" {
@@ -31,5 +34,34 @@ func Load() (Config, error) {
 		return Config{}, fmt.Errorf("missing env JWT_SECRET")
 	}
 
+	cfg.UploadDir = strings.TrimSpace(os.Getenv("UPLOAD_DIR"))
+	if cfg.UploadDir == "" {
+		cfg.UploadDir = "./data/uploads"
+	}
+
 	return cfg, nil
 }
+
+// LoadDB reads only the database driver and DSN, for command line tools
+// that do not serve HTTP. DB_DRIVER defaults to postgres; the sqlite driver
+// defaults to a database file under ./data.
+func LoadDB() (driver, dsn string, err error) {
+	driver = strings.ToLower(strings.TrimSpace(os.Getenv("DB_DRIVER")))
+	if driver == "" {
+		driver = "postgres"
+	}
+	dsn = strings.TrimSpace(os.Getenv("DB_DSN"))
+	switch driver {
+	case "postgres":
+		if dsn == "" {
+			return "", "", fmt.Errorf("missing env DB_DSN")
+		}
+	case "sqlite":
+		if dsn == "" {
+			dsn = "./data/habit-tracker.db"
+		}
+	default:
+		return "", "", fmt.Errorf("unsupported DB_DRIVER %q, want postgres or sqlite", driver)
+	}
+	return driver, dsn, nil
+}
@@ -1,33 +1,40 @@
-// 初始化 GORM + openGauss
+// 初始化 GORM，支持 openGauss（postgres 驱动）与 SQLite
 package db
 
 import (
 	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
+	"github.com/glebarez/sqlite"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
+// Drivers accepted by Open.
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+)
+
 var DB *gorm.DB
 
-func Init(dsn string) (*gorm.DB, error) {
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+func Init(driver, dsn string) (*gorm.DB, error) {
+	db, err := Open(driver, dsn, &gorm.Config{})
 	if err != nil {
 		return nil, err
 	}
 
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
 	sqlDB, err := db.DB()
 	if err != nil {
 		return nil, err
 	}
-
-	sqlDB.SetMaxOpenConns(25)
-	sqlDB.SetMaxIdleConns(10)
-	sqlDB.SetConnMaxLifetime(30 * time.Minute)
-
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
 	if err := sqlDB.PingContext(ctx); err != nil {
 		return nil, err
 	}
@@ -35,3 +42,68 @@ func Init(dsn string) (*gorm.DB, error) {
 	DB = db
 	return db, nil
 }
+
+// Open connects to the database and sizes the connection pool for driver.
+func Open(driver, dsn string, cfg *gorm.Config) (*gorm.DB, error) {
+	var dialector gorm.Dialector
+	switch driver {
+	case DriverPostgres:
+		dialector = postgres.Open(dsn)
+	case DriverSQLite:
+		var err error
+		if dsn, err = sqliteDSN(dsn); err != nil {
+			return nil, err
+		}
+		dialector = sqlite.Open(dsn)
+	default:
+		return nil, fmt.Errorf("unsupported database driver %q", driver)
+	}
+
+	db, err := gorm.Open(dialector, cfg)
+	if err != nil {
+		return nil, err
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	if driver == DriverSQLite {
+		// SQLite 没有行锁，clause.Locking 会被忽略；单连接让本进程内的事务串行执行，
+		// BEGIN IMMEDIATE 与 busy_timeout 处理其他进程（如 habitctl）的并发写入
+		sqlDB.SetMaxOpenConns(1)
+		return db, nil
+	}
+	sqlDB.SetMaxOpenConns(25)
+	sqlDB.SetMaxIdleConns(10)
+	sqlDB.SetConnMaxLifetime(30 * time.Minute)
+	return db, nil
+}
+
+// sqliteDSN adds the connection settings the repositories rely on and
+// creates the directory of a database file.
+func sqliteDSN(dsn string) (string, error) {
+	path, rawQuery, _ := strings.Cut(dsn, "?")
+	q, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return "", fmt.Errorf("parse sqlite dsn: %w", err)
+	}
+	inMemory := strings.Contains(path, ":memory:") || q.Get("mode") == "memory"
+	if q.Get("_txlock") == "" {
+		q.Set("_txlock", "immediate")
+	}
+	pragmas := strings.Join(q["_pragma"], ",")
+	if !strings.Contains(pragmas, "busy_timeout") {
+		q.Add("_pragma", "busy_timeout(5000)")
+	}
+	if !inMemory && !strings.Contains(pragmas, "journal_mode") {
+		q.Add("_pragma", "journal_mode(WAL)")
+	}
+	if !inMemory {
+		if dir := filepath.Dir(strings.TrimPrefix(path, "file:")); dir != "." {
+			if err := os.MkdirAll(dir, 0o755); err != nil {
+				return "", err
+			}
+		}
+	}
+	return path + "?" + q.Encode(), nil
+}
@@ -0,0 +1,30 @@
+package db
+
+import (
+	"gorm.io/gorm"
+
+	"habit-tracker/internal/models"
+)
+
+// Migrate creates or updates tables for all models.
+func Migrate(db *gorm.DB) error {
+	return db.AutoMigrate(
+		&models.User{},
+		&models.HabitCategory{},
+		&models.HabitTag{},
+		&models.HabitTagLink{},
+		&models.Habit{},
+		&models.HabitCheckin{},
+		&models.UserPointsLog{},
+		&models.Achievement{},
+		&models.UserAchievement{},
+		&models.StreakFreeze{},
+		&models.Vacation{},
+		&models.HabitReminder{},
+		&models.Notification{},
+		&models.WebhookEndpoint{},
+		&models.WebhookDelivery{},
+		&models.WebhookAttempt{},
+		&models.LedgerAdjustment{},
+	)
+}
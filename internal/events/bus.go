@@ -0,0 +1,144 @@
+// 进程内领域事件总线
+package events
+
+import (
+	"context"
+	"log"
+	"sync"
+)
+
+// Event is a domain event; Name identifies its type on the bus.
+type Event interface {
+	Name() string
+}
+
+type handler func(ctx context.Context, e Event) error
+
+// Bus dispatches events to subscribers registered at startup.
+//
+// Guarantees:
+//   - Sync subscribers run inside Publish, in registration order, on the
+//     publisher's goroutine and context (so within its request). The first
+//     error stops dispatch and is returned to the publisher, which fails the
+//     request the same way a direct call would.
+//   - Async subscribers run after all sync subscribers succeeded, on their own
+//     goroutine with a context that is not cancelled with the request. Their
+//     errors and panics are logged and never reach the publisher. They are not
+//     persisted: events still queued when the process exits are lost, and there
+//     is no ordering between separately published events.
+//   - Subscribers may publish further events; those are dispatched the same
+//     way before the outer Publish returns (sync) or independently (async).
+type Bus struct {
+	mu    sync.RWMutex
+	sync  map[string][]handler
+	async map[string][]handler
+	wg    sync.WaitGroup
+}
+
+func NewBus() *Bus {
+	return &Bus{sync: map[string][]handler{}, async: map[string][]handler{}}
+}
+
+// Subscribe registers a sync subscriber for events of type E.
+func Subscribe[E Event](b *Bus, fn func(ctx context.Context, e E) error) {
+	var zero E
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.sync[zero.Name()] = append(b.sync[zero.Name()], wrap(fn))
+}
+
+// SubscribeAsync registers an async subscriber for events of type E.
+func SubscribeAsync[E Event](b *Bus, fn func(ctx context.Context, e E) error) {
+	var zero E
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.async[zero.Name()] = append(b.async[zero.Name()], wrap(fn))
+}
+
+func wrap[E Event](fn func(ctx context.Context, e E) error) handler {
+	return func(ctx context.Context, e Event) error {
+		return fn(ctx, e.(E))
+	}
+}
+
+// Publish dispatches e; see Bus for what runs when.
+func (b *Bus) Publish(ctx context.Context, e Event) error {
+	if rec, ok := ctx.Value(recorderKey{}).(*Recorder); ok && rec != nil {
+		rec.add(e)
+	}
+	b.mu.RLock()
+	syncHandlers := b.sync[e.Name()]
+	asyncHandlers := b.async[e.Name()]
+	b.mu.RUnlock()
+
+	for _, h := range syncHandlers {
+		if err := h(ctx, e); err != nil {
+			return err
+		}
+	}
+	if len(asyncHandlers) == 0 {
+		return nil
+	}
+
+	// 异步订阅者不随请求取消，也不再记录到请求的 Recorder 中
+	actx := context.WithValue(context.WithoutCancel(ctx), recorderKey{}, (*Recorder)(nil))
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+		for _, h := range asyncHandlers {
+			runAsync(actx, e, h)
+		}
+	}()
+	return nil
+}
+
+func runAsync(ctx context.Context, e Event, h handler) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("event %s: async subscriber panic: %v", e.Name(), r)
+		}
+	}()
+	if err := h(ctx, e); err != nil {
+		log.Printf("event %s: async subscriber: %v", e.Name(), err)
+	}
+}
+
+// Wait blocks until all async subscribers started so far have finished.
+func (b *Bus) Wait() {
+	b.wg.Wait()
+}
+
+type recorderKey struct{}
+
+// Recorder collects the events published under a context, including those
+// published by sync subscribers, so the publisher can report what its
+// subscribers did.
+type Recorder struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+// WithRecorder returns a context whose published events are recorded.
+func WithRecorder(ctx context.Context) (context.Context, *Recorder) {
+	rec := &Recorder{}
+	return context.WithValue(ctx, recorderKey{}, rec), rec
+}
+
+func (r *Recorder) add(e Event) {
+	r.mu.Lock()
+	r.events = append(r.events, e)
+	r.mu.Unlock()
+}
+
+// Recorded returns the recorded events of type E in publish order.
+func Recorded[E Event](r *Recorder) []E {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []E
+	for _, e := range r.events {
+		if v, ok := e.(E); ok {
+			out = append(out, v)
+		}
+	}
+	return out
+}
@@ -0,0 +1,61 @@
+package events
+
+import "time"
+
+// CheckinRecorded is published after every check-in of a build habit, once
+// the streak and the points of a reached target have been updated.
+type CheckinRecorded struct {
+	UserID        uint64
+	HabitID       uint64
+	HabitName     string
+	CheckinID     uint64
+	Date          time.Time
+	CountInc      int
+	QuantityInc   float64
+	TodayCount    int
+	TodayQuantity float64
+	ReachedTarget bool
+	StreakDays    int
+	TotalCheckins int // sum of the habit's check-in counts
+}
+
+func (CheckinRecorded) Name() string { return "checkin.recorded" }
+
+// TargetReached is published once per habit and day when the day's target
+// is met: by the check-in that crossed it for build habits, and by the
+// clean-day job for each day without a relapse for quit habits.
+type TargetReached struct {
+	UserID        uint64
+	HabitID       uint64
+	HabitName     string
+	Polarity      string
+	Date          time.Time
+	StreakDays    int
+	TotalCheckins int // check-in counts for build habits, clean days for quit habits
+}
+
+func (TargetReached) Name() string { return "habit.target_reached" }
+
+// PointsChanged is published after a points change has been applied and logged.
+type PointsChanged struct {
+	UserID  uint64
+	Delta   int64
+	Balance int64
+	Reason  string
+	HabitID *uint64
+	At      time.Time // created_at of the points log entry
+}
+
+func (PointsChanged) Name() string { return "points.changed" }
+
+// AchievementUnlocked is published for each newly unlocked achievement.
+type AchievementUnlocked struct {
+	UserID            uint64
+	UserAchievementID uint64
+	AchievementID     uint64
+	Code              string
+	Title             string
+	UnlockedAt        time.Time
+}
+
+func (AchievementUnlocked) Name() string { return "achievement.unlocked" }
@@ -1,11 +1,8 @@
 package handler
 
 import (
-	"net/http"
-
 	"github.com/gin-gonic/gin"
 
-	"habit-tracker/internal/middleware"
 	"habit-tracker/internal/service"
 )
 
@@ -25,23 +22,22 @@ func (h *AchievementHandler) RegisterRoutes(r *gin.RouterGroup) {
 func (h *AchievementHandler) ListAll(c *gin.Context) {
 	list, err := h.svc.ListAll(c.Request.Context())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		writeError(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"data": list})
+	writeOK(c, list)
 }
 
 func (h *AchievementHandler) ListUserAchievements(c *gin.Context) {
-	uid, ok := c.Get(middleware.ContextUserIDKey)
+	userID, ok := currentUserID(c)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
 		return
 	}
 
-	list, err := h.svc.ListByUser(c.Request.Context(), uid.(uint64))
+	list, err := h.svc.ListByUser(c.Request.Context(), userID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		writeError(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"data": list})
+	writeOK(c, list)
 }
@@ -1,9 +1,6 @@
 package handler
 
 import (
-	"errors"
-	"net/http"
-
 	"github.com/gin-gonic/gin"
 
 	"habit-tracker/internal/service"
@@ -17,24 +14,11 @@ func NewAuthHandler(authService *service.AuthService) *AuthHandler {
 	return &AuthHandler{authService: authService}
 }
 
-type response struct {
-	Code    int         `json:"code"`
-	Message string      `json:"message"`
-	Data    interface{} `json:"data,omitempty"`
-}
-
-func writeOK(c *gin.Context, data interface{}) {
-	c.JSON(http.StatusOK, response{Code: 0, Message: "ok", Data: data})
-}
-
-func writeError(c *gin.Context, status int, msg string) {
-	c.JSON(status, response{Code: 1, Message: msg})
-}
-
 type registerRequest struct {
 	Username string `json:"username" binding:"required"`
 	Password string `json:"password" binding:"required"`
 	Nickname string `json:"nickname"`
+	Timezone string `json:"timezone"`
 }
 
 type loginRequest struct {
@@ -50,18 +34,13 @@ func (h *AuthHandler) RegisterRoutes(rg *gin.RouterGroup) {
 func (h *AuthHandler) Register(c *gin.Context) {
 	var req registerRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		writeError(c, http.StatusBadRequest, "invalid request")
+		writeError(c, errInvalidRequest)
 		return
 	}
 
-	user, err := h.authService.Register(c.Request.Context(), req.Username, req.Password, req.Nickname)
+	user, err := h.authService.Register(c.Request.Context(), req.Username, req.Password, req.Nickname, req.Timezone)
 	if err != nil {
-		switch {
-		case errors.Is(err, service.ErrUserExists):
-			writeError(c, http.StatusConflict, "username already exists")
-		default:
-			writeError(c, http.StatusInternalServerError, err.Error())
-		}
+		writeError(c, err)
 		return
 	}
 
@@ -69,24 +48,20 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		"id":       user.ID,
 		"username": user.Username,
 		"nickname": user.Nickname,
+		"timezone": user.Timezone,
 	})
 }
 
 func (h *AuthHandler) Login(c *gin.Context) {
 	var req loginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		writeError(c, http.StatusBadRequest, "invalid request")
+		writeError(c, errInvalidRequest)
 		return
 	}
 
 	token, user, err := h.authService.Login(c.Request.Context(), req.Username, req.Password)
 	if err != nil {
-		switch {
-		case errors.Is(err, service.ErrInvalidCredentials):
-			writeError(c, http.StatusUnauthorized, "invalid username or password")
-		default:
-			writeError(c, http.StatusInternalServerError, err.Error())
-		}
+		writeError(c, err)
 		return
 	}
 
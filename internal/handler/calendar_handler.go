@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"habit-tracker/internal/service"
+)
+
+type CalendarHandler struct {
+	calendar *service.CalendarService
+}
+
+func NewCalendarHandler(calendar *service.CalendarService) *CalendarHandler {
+	return &CalendarHandler{calendar: calendar}
+}
+
+type calendarFeedResponse struct {
+	*service.CalendarFeed
+	URL string `json:"url,omitempty"`
+}
+
+// RegisterRoutes adds the feed management routes under the authenticated /user group.
+func (h *CalendarHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.GET("/calendar-feed", h.Status)
+	rg.POST("/calendar-feed", h.Rotate)
+	rg.DELETE("/calendar-feed", h.Disable)
+}
+
+// RegisterFeed adds the public feed route; the token in the path is the only credential.
+func (h *CalendarHandler) RegisterFeed(rg *gin.RouterGroup) {
+	rg.GET("/:file", h.Feed)
+}
+
+func (h *CalendarHandler) Status(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	feed, err := h.calendar.Status(c.Request.Context(), userID)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	writeOK(c, calendarFeedResponse{CalendarFeed: feed})
+}
+
+// Rotate creates a new secret URL; the previous one stops working immediately.
+func (h *CalendarHandler) Rotate(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	feed, err := h.calendar.Rotate(c.Request.Context(), userID)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	writeOK(c, calendarFeedResponse{CalendarFeed: feed, URL: feedURL(c, feed.Token)})
+}
+
+func (h *CalendarHandler) Disable(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	if err := h.calendar.Disable(c.Request.Context(), userID); err != nil {
+		writeError(c, err)
+		return
+	}
+	writeOK(c, calendarFeedResponse{CalendarFeed: &service.CalendarFeed{}})
+}
+
+func (h *CalendarHandler) Feed(c *gin.Context) {
+	token := strings.TrimSuffix(c.Param("file"), ".ics")
+	data, err := h.calendar.Feed(c.Request.Context(), token)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.Header("Cache-Control", "private, max-age=900")
+	c.Data(200, "text/calendar; charset=utf-8", data)
+}
+
+func feedURL(c *gin.Context, token string) string {
+	scheme := "http"
+	if c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + c.Request.Host + "/api/v1/calendar/" + token + ".ics"
+}
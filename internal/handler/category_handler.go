@@ -0,0 +1,140 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"habit-tracker/internal/service"
+	"habit-tracker/internal/utils"
+)
+
+type CategoryHandler struct {
+	svc *service.CategoryService
+}
+
+func NewCategoryHandler(svc *service.CategoryService) *CategoryHandler {
+	return &CategoryHandler{svc: svc}
+}
+
+type categoryRequest struct {
+	Name      string `json:"name" binding:"required"`
+	Color     string `json:"color"`
+	SortOrder int    `json:"sort_order"`
+}
+
+func (h *CategoryHandler) RegisterRoutes(api *gin.RouterGroup) {
+	api.GET("/categories", h.ListCategories)
+	api.POST("/categories", h.CreateCategory)
+	api.PUT("/categories/:id", h.UpdateCategory)
+	api.DELETE("/categories/:id", h.DeleteCategory)
+	api.GET("/tags", h.ListTags)
+	api.DELETE("/tags/:id", h.DeleteTag)
+}
+
+func (h *CategoryHandler) ListCategories(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	list, err := h.svc.List(c.Request.Context(), userID)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	writeOK(c, list)
+}
+
+func (h *CategoryHandler) CreateCategory(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	var req categoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, errInvalidRequest)
+		return
+	}
+	category, err := h.svc.Create(c.Request.Context(), userID, service.CategoryInput{
+		Name:      req.Name,
+		Color:     req.Color,
+		SortOrder: req.SortOrder,
+	})
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	writeOK(c, category)
+}
+
+func (h *CategoryHandler) UpdateCategory(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	categoryID, err := utils.ParseIDParam(c.Param("id"))
+	if err != nil {
+		writeError(c, errInvalidID)
+		return
+	}
+	var req categoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, errInvalidRequest)
+		return
+	}
+	category, err := h.svc.Update(c.Request.Context(), userID, categoryID, service.CategoryInput{
+		Name:      req.Name,
+		Color:     req.Color,
+		SortOrder: req.SortOrder,
+	})
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	writeOK(c, category)
+}
+
+func (h *CategoryHandler) DeleteCategory(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	categoryID, err := utils.ParseIDParam(c.Param("id"))
+	if err != nil {
+		writeError(c, errInvalidID)
+		return
+	}
+	if err := h.svc.Delete(c.Request.Context(), userID, categoryID); err != nil {
+		writeError(c, err)
+		return
+	}
+	writeOK(c, gin.H{"id": categoryID})
+}
+
+func (h *CategoryHandler) ListTags(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	list, err := h.svc.ListTags(c.Request.Context(), userID)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	writeOK(c, list)
+}
+
+func (h *CategoryHandler) DeleteTag(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	tagID, err := utils.ParseIDParam(c.Param("id"))
+	if err != nil {
+		writeError(c, errInvalidID)
+		return
+	}
+	if err := h.svc.DeleteTag(c.Request.Context(), userID, tagID); err != nil {
+		writeError(c, err)
+		return
+	}
+	writeOK(c, gin.H{"id": tagID})
+}
@@ -1,13 +1,11 @@
 package handler
 
 import (
-	"errors"
-	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
 
-	"habit-tracker/internal/middleware"
+	"habit-tracker/internal/apperr"
 	"habit-tracker/internal/service"
 	"habit-tracker/internal/utils"
 )
@@ -20,23 +18,12 @@ func NewCheckinHandler(checkinSvc *service.CheckinService) *CheckinHandler {
 	return &CheckinHandler{checkinSvc: checkinSvc}
 }
 
-type checkinResponse struct {
-	Code    int         `json:"code"`
-	Message string      `json:"message"`
-	Data    interface{} `json:"data,omitempty"`
-}
-
-func writeCheckinOK(c *gin.Context, data interface{}) {
-	c.JSON(http.StatusOK, checkinResponse{Code: 0, Message: "ok", Data: data})
-}
-
-func writeCheckinError(c *gin.Context, status int, msg string) {
-	c.JSON(status, checkinResponse{Code: 1, Message: msg})
-}
-
 type checkinRequest struct {
-	HabitID  uint64 `json:"habit_id" binding:"required"`
-	CountInc int    `json:"count_inc"`
+	HabitID  uint64  `json:"habit_id" binding:"required"`
+	CountInc int     `json:"count_inc"`
+	Quantity float64 `json:"quantity"`
+	Note     string  `json:"note"`
+	Mood     *int    `json:"mood"`
 }
 
 type historyQuery struct {
@@ -47,57 +34,59 @@ type historyQuery struct {
 func (h *CheckinHandler) RegisterRoutes(api *gin.RouterGroup) {
 	api.POST("/checkins", h.Checkin)
 	api.GET("/habits/:id/checkins", h.ListCheckins)
+	api.POST("/habits/:id/relapses", h.Relapse)
 }
 
 func (h *CheckinHandler) Checkin(c *gin.Context) {
-	uidVal, ok := c.Get(middleware.ContextUserIDKey)
+	userID, ok := currentUserID(c)
 	if !ok {
-		writeCheckinError(c, http.StatusUnauthorized, "unauthorized")
 		return
 	}
-	userID := uidVal.(uint64)
 	var req checkinRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		writeCheckinError(c, http.StatusBadRequest, "invalid request")
+		writeError(c, errInvalidRequest)
 		return
 	}
 	if req.CountInc == 0 {
 		req.CountInc = 1
 	}
-	res, err := h.checkinSvc.Checkin(c.Request.Context(), userID, req.HabitID, req.CountInc)
+	res, err := h.checkinSvc.Checkin(c.Request.Context(), userID, req.HabitID, service.CheckinInput{
+		CountInc: req.CountInc,
+		Quantity: req.Quantity,
+		Note:     req.Note,
+		Mood:     req.Mood,
+	})
 	if err != nil {
-		writeCheckinError(c, statusFromCheckinError(err), err.Error())
+		writeError(c, err)
 		return
 	}
-	writeCheckinOK(c, res)
+	writeOK(c, res)
 }
 
 func (h *CheckinHandler) ListCheckins(c *gin.Context) {
-	uidVal, ok := c.Get(middleware.ContextUserIDKey)
+	userID, ok := currentUserID(c)
 	if !ok {
-		writeCheckinError(c, http.StatusUnauthorized, "unauthorized")
 		return
 	}
-	userID := uidVal.(uint64)
 
 	habitID, err := utils.ParseIDParam(c.Param("id"))
 	if err != nil {
-		writeCheckinError(c, http.StatusBadRequest, "invalid id")
+		writeError(c, errInvalidID)
 		return
 	}
 
 	var q historyQuery
 	if err := c.ShouldBindQuery(&q); err != nil {
-		writeCheckinError(c, http.StatusBadRequest, "invalid query")
+		writeError(c, errInvalidQuery)
 		return
 	}
-	end := time.Now().In(time.Local).Truncate(24 * time.Hour)
-	start := end.AddDate(0, 0, -30)
+	// 未指定的范围由服务按用户时区补齐
+	var start, end time.Time
 	if q.StartDate != "" {
 		if parsed, err := time.Parse("2006-01-02", q.StartDate); err == nil {
 			start = parsed
 		} else {
-			writeCheckinError(c, http.StatusBadRequest, "invalid start_date")
+			writeError(c, apperr.Invalid("invalid start_date"))
 			return
 		}
 	}
@@ -105,26 +94,33 @@ func (h *CheckinHandler) ListCheckins(c *gin.Context) {
 		if parsed, err := time.Parse("2006-01-02", q.EndDate); err == nil {
 			end = parsed
 		} else {
-			writeCheckinError(c, http.StatusBadRequest, "invalid end_date")
+			writeError(c, apperr.Invalid("invalid end_date"))
 			return
 		}
 	}
 
 	records, err := h.checkinSvc.ListHistory(c.Request.Context(), userID, habitID, start, end)
 	if err != nil {
-		writeCheckinError(c, statusFromCheckinError(err), err.Error())
+		writeError(c, err)
 		return
 	}
-	writeCheckinOK(c, records)
+	writeOK(c, records)
 }
 
-func statusFromCheckinError(err error) int {
-	switch {
-	case errors.Is(err, service.ErrCheckinForbidden):
-		return http.StatusForbidden
-	case errors.Is(err, service.ErrHabitMissing):
-		return http.StatusNotFound
-	default:
-		return http.StatusBadRequest
+func (h *CheckinHandler) Relapse(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	habitID, err := utils.ParseIDParam(c.Param("id"))
+	if err != nil {
+		writeError(c, errInvalidID)
+		return
+	}
+	res, err := h.checkinSvc.Relapse(c.Request.Context(), userID, habitID)
+	if err != nil {
+		writeError(c, err)
+		return
 	}
+	writeOK(c, res)
 }
@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/gin-gonic/gin"
+
+	"habit-tracker/internal/service"
+)
+
+type ExportHandler struct {
+	export *service.ExportService
+}
+
+func NewExportHandler(export *service.ExportService) *ExportHandler {
+	return &ExportHandler{export: export}
+}
+
+func (h *ExportHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.GET("/export", h.Export)
+}
+
+// Export streams the user's data as a zip archive; see service.ExportManifest.
+func (h *ExportHandler) Export(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	export, err := h.export.Prepare(c.Request.Context(), userID)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", export.Filename))
+	c.Status(200)
+	if err := export.Stream(c.Request.Context(), c.Writer); err != nil {
+		// 响应已经开始，只能中断连接，客户端会得到不完整的压缩包
+		log.Printf("用户 %d 数据导出失败: %v", userID, err)
+		c.Abort()
+	}
+}
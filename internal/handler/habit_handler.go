@@ -1,14 +1,15 @@
 package handler
 
 import (
-	"errors"
-	"net/http"
+	"context"
 	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
 
-	"habit-tracker/internal/middleware"
+	"habit-tracker/internal/apperr"
+	"habit-tracker/internal/models"
+	"habit-tracker/internal/repository"
 	"habit-tracker/internal/service"
 	"habit-tracker/internal/utils"
 )
@@ -21,35 +22,43 @@ func NewHabitHandler(habitSvc *service.HabitService) *HabitHandler {
 	return &HabitHandler{habitSvc: habitSvc}
 }
 
-type habitResponse struct {
-	Code    int         `json:"code"`
-	Message string      `json:"message"`
-	Data    interface{} `json:"data,omitempty"`
-}
-
-func writeHabitOK(c *gin.Context, data interface{}) {
-	c.JSON(http.StatusOK, habitResponse{Code: 0, Message: "ok", Data: data})
-}
-
-func writeHabitError(c *gin.Context, status int, msg string) {
-	c.JSON(status, habitResponse{Code: 1, Message: msg})
-}
-
 type createHabitRequest struct {
-	Name        string `json:"name" binding:"required"`
-	Description string `json:"description"`
-	TargetType  string `json:"target_type" binding:"required"`
-	TargetTimes int    `json:"target_times" binding:"required"`
-	StartDate   string `json:"start_date" binding:"required"`
+	Name        string            `json:"name" binding:"required"`
+	Description string            `json:"description"`
+	TargetType  string            `json:"target_type" binding:"required"`
+	TargetTimes int               `json:"target_times"`
+	Schedule    models.WeekdaySet `json:"schedule_days"`
+	Kind        string            `json:"kind"`
+	Polarity    string            `json:"polarity"`
+	Unit        string            `json:"unit"`
+	Quantity    float64           `json:"target_quantity"`
+	StartDate   string            `json:"start_date" binding:"required"`
+	CategoryID  *uint64           `json:"category_id"`
+	Color       *string           `json:"color"`
+	Icon        *string           `json:"icon"`
+	Tags        []string          `json:"tags"`
 }
 
 type updateHabitRequest struct {
-	Name        string `json:"name" binding:"required"`
-	Description string `json:"description"`
-	TargetType  string `json:"target_type" binding:"required"`
-	TargetTimes int    `json:"target_times" binding:"required"`
-	StartDate   string `json:"start_date" binding:"required"`
-	IsActive    *bool  `json:"is_active"`
+	Name        string            `json:"name" binding:"required"`
+	Description string            `json:"description"`
+	TargetType  string            `json:"target_type" binding:"required"`
+	TargetTimes int               `json:"target_times"`
+	Schedule    models.WeekdaySet `json:"schedule_days"`
+	Kind        string            `json:"kind"`
+	Polarity    string            `json:"polarity"`
+	Unit        string            `json:"unit"`
+	Quantity    float64           `json:"target_quantity"`
+	StartDate   string            `json:"start_date" binding:"required"`
+	IsActive    *bool             `json:"is_active"`
+	CategoryID  *uint64           `json:"category_id"`
+	Color       *string           `json:"color"`
+	Icon        *string           `json:"icon"`
+	Tags        []string          `json:"tags"`
+}
+
+type reorderHabitsRequest struct {
+	HabitIDs []uint64 `json:"habit_ids" binding:"required"`
 }
 
 type toggleHabitStatusRequest struct {
@@ -62,149 +71,280 @@ func (h *HabitHandler) RegisterRoutes(rg *gin.RouterGroup) {
 	rg.GET(":id", h.GetHabit)
 	rg.PUT(":id", h.UpdateHabit)
 	rg.PATCH(":id/status", h.ToggleHabitStatus)
+	rg.DELETE(":id", h.DeleteHabit)
+	rg.GET("/trash", h.ListDeletedHabits)
+	rg.PUT("/order", h.ReorderHabits)
+	rg.POST(":id/archive", h.ArchiveHabit)
+	rg.POST(":id/unarchive", h.UnarchiveHabit)
+	rg.POST(":id/restore", h.RestoreHabit)
 }
 
 func (h *HabitHandler) CreateHabit(c *gin.Context) {
-	uid, ok := c.Get(middleware.ContextUserIDKey)
+	userID, ok := currentUserID(c)
 	if !ok {
-		writeHabitError(c, http.StatusUnauthorized, "unauthorized")
 		return
 	}
 	var req createHabitRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		writeHabitError(c, http.StatusBadRequest, "invalid request")
+		writeError(c, errInvalidRequest)
 		return
 	}
 	startDate, err := time.Parse("2006-01-02", req.StartDate)
 	if err != nil {
-		writeHabitError(c, http.StatusBadRequest, "invalid start_date")
+		writeError(c, apperr.Invalid("invalid start_date"))
 		return
 	}
 
-	habit, err := h.habitSvc.Create(c.Request.Context(), uid.(uint64), service.HabitInput{
-		Name:        req.Name,
-		Description: req.Description,
-		TargetType:  req.TargetType,
-		TargetTimes: req.TargetTimes,
-		StartDate:   startDate,
+	habit, err := h.habitSvc.Create(c.Request.Context(), userID, service.HabitInput{
+		Name:         req.Name,
+		Description:  req.Description,
+		TargetType:   req.TargetType,
+		TargetTimes:  req.TargetTimes,
+		ScheduleDays: req.Schedule,
+		Kind:         req.Kind,
+		Polarity:     req.Polarity,
+		Unit:         req.Unit,
+		Quantity:     req.Quantity,
+		StartDate:    startDate,
+		CategoryID:   req.CategoryID,
+		Color:        req.Color,
+		Icon:         req.Icon,
+		Tags:         req.Tags,
 	})
 	if err != nil {
-		writeHabitError(c, http.StatusBadRequest, err.Error())
+		writeError(c, err)
 		return
 	}
-	writeHabitOK(c, habit)
+	writeOK(c, habit)
 }
 
 func (h *HabitHandler) UpdateHabit(c *gin.Context) {
-	uid, ok := c.Get(middleware.ContextUserIDKey)
+	userID, ok := currentUserID(c)
 	if !ok {
-		writeHabitError(c, http.StatusUnauthorized, "unauthorized")
 		return
 	}
 	habitID, err := utils.ParseIDParam(c.Param("id"))
 	if err != nil {
-		writeHabitError(c, http.StatusBadRequest, "invalid id")
+		writeError(c, errInvalidID)
 		return
 	}
 
 	var req updateHabitRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		writeHabitError(c, http.StatusBadRequest, "invalid request")
+		writeError(c, errInvalidRequest)
 		return
 	}
 	startDate, err := time.Parse("2006-01-02", req.StartDate)
 	if err != nil {
-		writeHabitError(c, http.StatusBadRequest, "invalid start_date")
+		writeError(c, apperr.Invalid("invalid start_date"))
 		return
 	}
 
-	habit, err := h.habitSvc.Update(c.Request.Context(), uid.(uint64), habitID, service.HabitInput{
-		Name:        req.Name,
-		Description: req.Description,
-		TargetType:  req.TargetType,
-		TargetTimes: req.TargetTimes,
-		StartDate:   startDate,
-		IsActive:    req.IsActive,
+	habit, err := h.habitSvc.Update(c.Request.Context(), userID, habitID, service.HabitInput{
+		Name:         req.Name,
+		Description:  req.Description,
+		TargetType:   req.TargetType,
+		TargetTimes:  req.TargetTimes,
+		ScheduleDays: req.Schedule,
+		Kind:         req.Kind,
+		Polarity:     req.Polarity,
+		Unit:         req.Unit,
+		Quantity:     req.Quantity,
+		StartDate:    startDate,
+		IsActive:     req.IsActive,
+		CategoryID:   req.CategoryID,
+		Color:        req.Color,
+		Icon:         req.Icon,
+		Tags:         req.Tags,
 	})
 	if err != nil {
-		writeHabitError(c, statusFromHabitError(err), err.Error())
+		writeError(c, err)
 		return
 	}
-	writeHabitOK(c, habit)
+	writeOK(c, habit)
 }
 
 func (h *HabitHandler) ListHabits(c *gin.Context) {
-	uid, ok := c.Get(middleware.ContextUserIDKey)
+	userID, ok := currentUserID(c)
 	if !ok {
-		writeHabitError(c, http.StatusUnauthorized, "unauthorized")
 		return
 	}
-	var activePtr *bool
-	if v := c.Query("is_active"); v != "" {
-		parsed, err := strconv.ParseBool(v)
+	activePtr, err := parseBoolQuery(c, "is_active")
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	archivedPtr, err := parseBoolQuery(c, "archived")
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	var categoryPtr *uint64
+	if v := c.Query("category_id"); v != "" {
+		parsed, err := utils.ParseIDParam(v)
 		if err != nil {
-			writeHabitError(c, http.StatusBadRequest, "invalid is_active")
+			writeError(c, apperr.Invalid("invalid category_id"))
 			return
 		}
-		activePtr = &parsed
+		categoryPtr = &parsed
 	}
-	habits, err := h.habitSvc.List(c.Request.Context(), uid.(uint64), activePtr)
+	habits, err := h.habitSvc.List(c.Request.Context(), userID, repository.HabitFilter{
+		IsActive:   activePtr,
+		Archived:   archivedPtr,
+		CategoryID: categoryPtr,
+		Tag:        c.Query("tag"),
+		Sort:       c.Query("sort"),
+	})
 	if err != nil {
-		writeHabitError(c, http.StatusInternalServerError, err.Error())
+		writeError(c, err)
 		return
 	}
-	writeHabitOK(c, habits)
+	writeOK(c, habits)
 }
 
 func (h *HabitHandler) GetHabit(c *gin.Context) {
-	uid, ok := c.Get(middleware.ContextUserIDKey)
+	userID, ok := currentUserID(c)
 	if !ok {
-		writeHabitError(c, http.StatusUnauthorized, "unauthorized")
 		return
 	}
 	habitID, err := utils.ParseIDParam(c.Param("id"))
 	if err != nil {
-		writeHabitError(c, http.StatusBadRequest, "invalid id")
+		writeError(c, errInvalidID)
 		return
 	}
-	habit, err := h.habitSvc.Get(c.Request.Context(), uid.(uint64), habitID)
+	habit, err := h.habitSvc.Get(c.Request.Context(), userID, habitID)
 	if err != nil {
-		writeHabitError(c, statusFromHabitError(err), err.Error())
+		writeError(c, err)
 		return
 	}
-	writeHabitOK(c, habit)
+	writeOK(c, habit)
 }
 
 func (h *HabitHandler) ToggleHabitStatus(c *gin.Context) {
-	uid, ok := c.Get(middleware.ContextUserIDKey)
+	userID, ok := currentUserID(c)
 	if !ok {
-		writeHabitError(c, http.StatusUnauthorized, "unauthorized")
 		return
 	}
 	habitID, err := utils.ParseIDParam(c.Param("id"))
 	if err != nil {
-		writeHabitError(c, http.StatusBadRequest, "invalid id")
+		writeError(c, errInvalidID)
 		return
 	}
 	var req toggleHabitStatusRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		writeHabitError(c, http.StatusBadRequest, "invalid request")
+		writeError(c, errInvalidRequest)
 		return
 	}
-	if err := h.habitSvc.SetActive(c.Request.Context(), uid.(uint64), habitID, req.IsActive); err != nil {
-		writeHabitError(c, statusFromHabitError(err), err.Error())
+	if err := h.habitSvc.SetActive(c.Request.Context(), userID, habitID, req.IsActive); err != nil {
+		writeError(c, err)
 		return
 	}
-	writeHabitOK(c, gin.H{"is_active": req.IsActive})
+	writeOK(c, gin.H{"is_active": req.IsActive})
 }
 
-func statusFromHabitError(err error) int {
-	switch {
-	case errors.Is(err, service.ErrHabitForbidden):
-		return http.StatusForbidden
-	case errors.Is(err, service.ErrHabitNotFound):
-		return http.StatusNotFound
-	default:
-		return http.StatusBadRequest
+// DeleteHabit soft-deletes by default; ?hard=true removes the habit and its
+// check-ins permanently, with points_policy=keep|revoke for its points log.
+func (h *HabitHandler) DeleteHabit(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	habitID, err := utils.ParseIDParam(c.Param("id"))
+	if err != nil {
+		writeError(c, errInvalidID)
+		return
+	}
+	hard, err := parseBoolQuery(c, "hard")
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	if hard != nil && *hard {
+		policy := service.PointsPolicy(c.Query("points_policy"))
+		if err := h.habitSvc.HardDelete(c.Request.Context(), userID, habitID, policy); err != nil {
+			writeError(c, err)
+			return
+		}
+		writeOK(c, gin.H{"id": habitID, "deleted": "hard"})
+		return
+	}
+
+	if err := h.habitSvc.Delete(c.Request.Context(), userID, habitID); err != nil {
+		writeError(c, err)
+		return
+	}
+	writeOK(c, gin.H{"id": habitID, "deleted": "soft"})
+}
+
+func (h *HabitHandler) ReorderHabits(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	var req reorderHabitsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, errInvalidRequest)
+		return
+	}
+	if err := h.habitSvc.Reorder(c.Request.Context(), userID, req.HabitIDs); err != nil {
+		writeError(c, err)
+		return
+	}
+	writeOK(c, gin.H{"habit_ids": req.HabitIDs})
+}
+
+func (h *HabitHandler) ListDeletedHabits(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	habits, err := h.habitSvc.ListDeleted(c.Request.Context(), userID)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	writeOK(c, habits)
+}
+
+func (h *HabitHandler) ArchiveHabit(c *gin.Context) {
+	h.applyHabitAction(c, h.habitSvc.Archive)
+}
+
+func (h *HabitHandler) UnarchiveHabit(c *gin.Context) {
+	h.applyHabitAction(c, h.habitSvc.Unarchive)
+}
+
+func (h *HabitHandler) RestoreHabit(c *gin.Context) {
+	h.applyHabitAction(c, h.habitSvc.Restore)
+}
+
+func (h *HabitHandler) applyHabitAction(c *gin.Context, action func(ctx context.Context, userID, habitID uint64) (*models.Habit, error)) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	habitID, err := utils.ParseIDParam(c.Param("id"))
+	if err != nil {
+		writeError(c, errInvalidID)
+		return
+	}
+	habit, err := action(c.Request.Context(), userID, habitID)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	writeOK(c, habit)
+}
+
+func parseBoolQuery(c *gin.Context, name string) (*bool, error) {
+	v := c.Query(name)
+	if v == "" {
+		return nil, nil
+	}
+	parsed, err := strconv.ParseBool(v)
+	if err != nil {
+		return nil, apperr.Invalid("invalid " + name)
 	}
+	return &parsed, nil
 }
@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"habit-tracker/internal/apperr"
+	"habit-tracker/internal/service"
+)
+
+type ImportHandler struct {
+	imports *service.ImportService
+}
+
+func NewImportHandler(imports *service.ImportService) *ImportHandler {
+	return &ImportHandler{imports: imports}
+}
+
+type importQuery struct {
+	DryRun      bool `form:"dry_run"`
+	AwardPoints bool `form:"award_points"`
+}
+
+func (h *ImportHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.POST("/import", h.Import)
+}
+
+// Import expects a multipart form with the export in the "file" field:
+// a Loop Habit Tracker zip or Checkmarks.csv, or an archive from GET /user/export.
+func (h *ImportHandler) Import(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	var q importQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		writeError(c, errInvalidQuery)
+		return
+	}
+	fh, err := c.FormFile("file")
+	if err != nil {
+		writeError(c, apperr.Invalid("import file is required"))
+		return
+	}
+	if fh.Size > service.MaxImportSize {
+		writeError(c, service.ErrImportTooLarge)
+		return
+	}
+	f, err := fh.Open()
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	defer f.Close()
+
+	report, err := h.imports.Import(c.Request.Context(), userID, f, fh.Size, service.ImportOptions{
+		DryRun:      q.DryRun,
+		AwardPoints: q.AwardPoints,
+	})
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	writeOK(c, report)
+}
@@ -0,0 +1,150 @@
+package handler
+
+import (
+	"io"
+
+	"github.com/gin-gonic/gin"
+
+	"habit-tracker/internal/apperr"
+	"habit-tracker/internal/service"
+	"habit-tracker/internal/utils"
+)
+
+type JournalHandler struct {
+	svc *service.JournalService
+}
+
+func NewJournalHandler(svc *service.JournalService) *JournalHandler {
+	return &JournalHandler{svc: svc}
+}
+
+type updateJournalRequest struct {
+	Note *string `json:"note"`
+	Mood *int    `json:"mood"`
+}
+
+func (h *JournalHandler) RegisterRoutes(api *gin.RouterGroup) {
+	api.GET("/checkins/search", h.SearchNotes)
+	api.PATCH("/checkins/:id", h.UpdateJournal)
+	api.POST("/checkins/:id/photo", h.UploadPhoto)
+	api.GET("/checkins/:id/photo", h.GetPhoto)
+	api.DELETE("/checkins/:id/photo", h.DeletePhoto)
+}
+
+func (h *JournalHandler) UpdateJournal(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	checkinID, err := utils.ParseIDParam(c.Param("id"))
+	if err != nil {
+		writeError(c, errInvalidID)
+		return
+	}
+	var req updateJournalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, errInvalidRequest)
+		return
+	}
+	rec, err := h.svc.UpdateJournal(c.Request.Context(), userID, checkinID, req.Note, req.Mood)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	writeOK(c, rec)
+}
+
+// UploadPhoto expects a multipart form with the image in the "photo" field.
+func (h *JournalHandler) UploadPhoto(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	checkinID, err := utils.ParseIDParam(c.Param("id"))
+	if err != nil {
+		writeError(c, errInvalidID)
+		return
+	}
+	fh, err := c.FormFile("photo")
+	if err != nil {
+		writeError(c, apperr.Invalid("photo file is required"))
+		return
+	}
+	if fh.Size > service.MaxPhotoSize {
+		writeError(c, service.ErrPhotoTooLarge)
+		return
+	}
+	f, err := fh.Open()
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	defer f.Close()
+
+	rec, err := h.svc.UploadPhoto(c.Request.Context(), userID, checkinID, io.LimitReader(f, service.MaxPhotoSize+1))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	writeOK(c, rec)
+}
+
+func (h *JournalHandler) GetPhoto(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	checkinID, err := utils.ParseIDParam(c.Param("id"))
+	if err != nil {
+		writeError(c, errInvalidID)
+		return
+	}
+	photo, err := h.svc.OpenPhoto(c.Request.Context(), userID, checkinID)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	defer photo.Body.Close()
+	c.Header("Cache-Control", "private, max-age=3600")
+	c.DataFromReader(200, -1, photo.ContentType, photo.Body, nil)
+}
+
+func (h *JournalHandler) DeletePhoto(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	checkinID, err := utils.ParseIDParam(c.Param("id"))
+	if err != nil {
+		writeError(c, errInvalidID)
+		return
+	}
+	if err := h.svc.DeletePhoto(c.Request.Context(), userID, checkinID); err != nil {
+		writeError(c, err)
+		return
+	}
+	writeOK(c, gin.H{"id": checkinID})
+}
+
+// SearchNotes searches check-in notes with ?q=, optionally limited to ?habit_id=.
+func (h *JournalHandler) SearchNotes(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	var habitPtr *uint64
+	if v := c.Query("habit_id"); v != "" {
+		parsed, err := utils.ParseIDParam(v)
+		if err != nil {
+			writeError(c, apperr.Invalid("invalid habit_id"))
+			return
+		}
+		habitPtr = &parsed
+	}
+	records, err := h.svc.SearchNotes(c.Request.Context(), userID, habitPtr, c.Query("q"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	writeOK(c, records)
+}
@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"habit-tracker/internal/service"
+	"habit-tracker/internal/utils"
+)
+
+type NotificationHandler struct {
+	notifications *service.NotificationService
+}
+
+func NewNotificationHandler(notifications *service.NotificationService) *NotificationHandler {
+	return &NotificationHandler{notifications: notifications}
+}
+
+type notificationsQuery struct {
+	Unread   bool   `form:"unread"`
+	BeforeID uint64 `form:"before_id"`
+	Limit    int    `form:"limit"`
+}
+
+func (h *NotificationHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.GET("", h.List)
+	rg.GET("/unread-count", h.UnreadCount)
+	rg.POST("/read-all", h.MarkAllRead)
+	rg.POST("/:id/read", h.MarkRead)
+}
+
+// List supports ?unread=true, ?limit= and ?before_id= for the next page.
+func (h *NotificationHandler) List(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	var q notificationsQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		writeError(c, errInvalidQuery)
+		return
+	}
+	res, err := h.notifications.List(c.Request.Context(), userID, q.Unread, q.BeforeID, q.Limit)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	writeOK(c, res)
+}
+
+func (h *NotificationHandler) UnreadCount(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	n, err := h.notifications.UnreadCount(c.Request.Context(), userID)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	writeOK(c, gin.H{"unread": n})
+}
+
+func (h *NotificationHandler) MarkRead(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	id, err := utils.ParseIDParam(c.Param("id"))
+	if err != nil {
+		writeError(c, errInvalidID)
+		return
+	}
+	if err := h.notifications.MarkRead(c.Request.Context(), userID, id); err != nil {
+		writeError(c, err)
+		return
+	}
+	writeOK(c, gin.H{"id": id})
+}
+
+func (h *NotificationHandler) MarkAllRead(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	n, err := h.notifications.MarkAllRead(c.Request.Context(), userID)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	writeOK(c, gin.H{"marked": n})
+}
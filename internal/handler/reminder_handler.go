@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"habit-tracker/internal/service"
+	"habit-tracker/internal/utils"
+)
+
+type ReminderHandler struct {
+	reminders *service.ReminderService
+}
+
+func NewReminderHandler(reminders *service.ReminderService) *ReminderHandler {
+	return &ReminderHandler{reminders: reminders}
+}
+
+type remindersRequest struct {
+	Times []string `json:"times"`
+}
+
+type reminderWebhookRequest struct {
+	URL string `json:"url"`
+}
+
+func (h *ReminderHandler) RegisterRoutes(api *gin.RouterGroup) {
+	api.GET("/habits/:id/reminders", h.List)
+	api.PUT("/habits/:id/reminders", h.Set)
+	api.PUT("/user/reminder-webhook", h.SetWebhook)
+}
+
+func (h *ReminderHandler) List(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	habitID, err := utils.ParseIDParam(c.Param("id"))
+	if err != nil {
+		writeError(c, errInvalidID)
+		return
+	}
+	res, err := h.reminders.List(c.Request.Context(), userID, habitID)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	writeOK(c, res)
+}
+
+// Set replaces all reminder times of the habit, e.g. {"times":["08:00","21:30"]}.
+func (h *ReminderHandler) Set(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	habitID, err := utils.ParseIDParam(c.Param("id"))
+	if err != nil {
+		writeError(c, errInvalidID)
+		return
+	}
+	var req remindersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, errInvalidRequest)
+		return
+	}
+	res, err := h.reminders.Set(c.Request.Context(), userID, habitID, req.Times)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	writeOK(c, res)
+}
+
+func (h *ReminderHandler) SetWebhook(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	var req reminderWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, errInvalidRequest)
+		return
+	}
+	if err := h.reminders.SetWebhook(c.Request.Context(), userID, req.URL); err != nil {
+		writeError(c, err)
+		return
+	}
+	writeOK(c, req)
+}
@@ -0,0 +1,36 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"habit-tracker/internal/apperr"
+	"habit-tracker/internal/middleware"
+)
+
+var (
+	errInvalidRequest = apperr.Invalid("invalid request")
+	errInvalidID      = apperr.Invalid("invalid id")
+	errInvalidQuery   = apperr.Invalid("invalid query")
+)
+
+func writeOK(c *gin.Context, data interface{}) {
+	c.JSON(http.StatusOK, middleware.Response{Code: 0, Message: "ok", Data: data})
+}
+
+// writeError hands err to middleware.ErrorHandler, which picks the status and envelope.
+func writeError(c *gin.Context, err error) {
+	_ = c.Error(err)
+	c.Abort()
+}
+
+// currentUserID reads the authenticated user id, writing 401 when it is absent.
+func currentUserID(c *gin.Context) (uint64, bool) {
+	uid, ok := c.Get(middleware.ContextUserIDKey)
+	if !ok {
+		writeError(c, apperr.ErrUnauthorized)
+		return 0, false
+	}
+	return uid.(uint64), true
+}
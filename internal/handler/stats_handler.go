@@ -0,0 +1,166 @@
+package handler
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"habit-tracker/internal/apperr"
+	"habit-tracker/internal/service"
+	"habit-tracker/internal/utils"
+)
+
+type StatsHandler struct {
+	habitStats *service.HabitStatsService
+	analytics  *service.AnalyticsService
+}
+
+func NewStatsHandler(habitStats *service.HabitStatsService, analytics *service.AnalyticsService) *StatsHandler {
+	return &StatsHandler{habitStats: habitStats, analytics: analytics}
+}
+
+func (h *StatsHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.GET("/habits/:id/stats", h.HabitStats)
+	rg.GET("/habits/:id/heatmap", h.HabitHeatmap)
+	rg.GET("/user/heatmap", h.UserHeatmap)
+	rg.GET("/user/series", h.UserSeries)
+}
+
+func (h *StatsHandler) HabitStats(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	habitID, err := utils.ParseIDParam(c.Param("id"))
+	if err != nil {
+		writeError(c, errInvalidID)
+		return
+	}
+	stats, err := h.habitStats.Stats(c.Request.Context(), userID, habitID)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	writeOK(c, stats)
+}
+
+type heatmapQuery struct {
+	StartDate string `form:"start_date"`
+	EndDate   string `form:"end_date"`
+}
+
+type seriesQuery struct {
+	heatmapQuery
+	Granularity string `form:"granularity"`
+	Timezone    string `form:"tz"`
+}
+
+// HabitHeatmap returns one habit's day values; see service.Heatmap.
+func (h *StatsHandler) HabitHeatmap(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	habitID, err := utils.ParseIDParam(c.Param("id"))
+	if err != nil {
+		writeError(c, errInvalidID)
+		return
+	}
+	from, to, ok := h.parseHeatmapRange(c, userID)
+	if !ok {
+		return
+	}
+	heatmap, err := h.habitStats.HabitHeatmap(c.Request.Context(), userID, habitID, from, to)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	writeOK(c, heatmap)
+}
+
+// UserHeatmap averages the day values over all build habits of the user.
+func (h *StatsHandler) UserHeatmap(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	from, to, ok := h.parseHeatmapRange(c, userID)
+	if !ok {
+		return
+	}
+	heatmap, err := h.habitStats.UserHeatmap(c.Request.Context(), userID, from, to)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	writeOK(c, heatmap)
+}
+
+// UserSeries returns points and check-in counts per day, week or month,
+// bucketed in the user's time zone (overridable with tz).
+func (h *StatsHandler) UserSeries(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	var q seriesQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		writeError(c, errInvalidQuery)
+		return
+	}
+	start, end, ok := parseDateBounds(c, q.heatmapQuery)
+	if !ok {
+		return
+	}
+	series, err := h.analytics.Series(c.Request.Context(), userID, service.SeriesQuery{
+		Granularity: q.Granularity,
+		StartDate:   start,
+		EndDate:     end,
+		Timezone:    q.Timezone,
+	})
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	writeOK(c, series)
+}
+
+func (h *StatsHandler) parseHeatmapRange(c *gin.Context, userID uint64) (time.Time, time.Time, bool) {
+	var q heatmapQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		writeError(c, errInvalidQuery)
+		return time.Time{}, time.Time{}, false
+	}
+	start, end, ok := parseDateBounds(c, q)
+	if !ok {
+		return time.Time{}, time.Time{}, false
+	}
+	from, to, err := h.habitStats.HeatmapRange(c.Request.Context(), userID, start, end)
+	if err != nil {
+		writeError(c, err)
+		return time.Time{}, time.Time{}, false
+	}
+	return from, to, true
+}
+
+// parseDateBounds parses the optional start_date/end_date pair.
+func parseDateBounds(c *gin.Context, q heatmapQuery) (*time.Time, *time.Time, bool) {
+	var start, end *time.Time
+	if q.StartDate != "" {
+		parsed, err := time.Parse("2006-01-02", q.StartDate)
+		if err != nil {
+			writeError(c, apperr.Invalid("invalid start_date"))
+			return nil, nil, false
+		}
+		start = &parsed
+	}
+	if q.EndDate != "" {
+		parsed, err := time.Parse("2006-01-02", q.EndDate)
+		if err != nil {
+			writeError(c, apperr.Invalid("invalid end_date"))
+			return nil, nil, false
+		}
+		end = &parsed
+	}
+	return start, end, true
+}
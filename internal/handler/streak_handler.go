@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"habit-tracker/internal/apperr"
+	"habit-tracker/internal/service"
+	"habit-tracker/internal/utils"
+)
+
+type StreakHandler struct {
+	guard *service.StreakGuardService
+}
+
+func NewStreakHandler(guard *service.StreakGuardService) *StreakHandler {
+	return &StreakHandler{guard: guard}
+}
+
+type createVacationRequest struct {
+	StartDate string `json:"start_date" binding:"required"`
+	EndDate   string `json:"end_date" binding:"required"`
+}
+
+func (h *StreakHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.GET("/streak-freezes", h.GetFreezes)
+	rg.POST("/streak-freezes/purchase", h.PurchaseFreeze)
+	rg.GET("/vacations", h.ListVacations)
+	rg.POST("/vacations", h.CreateVacation)
+	rg.DELETE("/vacations/:id", h.CancelVacation)
+}
+
+func (h *StreakHandler) GetFreezes(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	summary, err := h.guard.Summary(c.Request.Context(), userID)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	writeOK(c, summary)
+}
+
+func (h *StreakHandler) PurchaseFreeze(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	summary, err := h.guard.Purchase(c.Request.Context(), userID)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	writeOK(c, summary)
+}
+
+func (h *StreakHandler) ListVacations(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	items, err := h.guard.ListVacations(c.Request.Context(), userID)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	writeOK(c, items)
+}
+
+func (h *StreakHandler) CreateVacation(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	var req createVacationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, errInvalidRequest)
+		return
+	}
+	start, err := time.Parse("2006-01-02", req.StartDate)
+	if err != nil {
+		writeError(c, apperr.Invalid("invalid start_date"))
+		return
+	}
+	end, err := time.Parse("2006-01-02", req.EndDate)
+	if err != nil {
+		writeError(c, apperr.Invalid("invalid end_date"))
+		return
+	}
+	item, err := h.guard.CreateVacation(c.Request.Context(), userID, start, end)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	writeOK(c, item)
+}
+
+// CancelVacation deletes an upcoming vacation or ends a running one early.
+func (h *StreakHandler) CancelVacation(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	vacationID, err := utils.ParseIDParam(c.Param("id"))
+	if err != nil {
+		writeError(c, errInvalidID)
+		return
+	}
+	if err := h.guard.CancelVacation(c.Request.Context(), userID, vacationID); err != nil {
+		writeError(c, err)
+		return
+	}
+	writeOK(c, gin.H{"id": vacationID})
+}
@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+
+	"habit-tracker/internal/middleware"
+	"habit-tracker/internal/service"
+)
+
+const (
+	streamHeartbeat = 25 * time.Second
+	streamRetryMs   = 3000
+)
+
+type StreamHandler struct {
+	stream *service.StreamService
+}
+
+func NewStreamHandler(stream *service.StreamService) *StreamHandler {
+	return &StreamHandler{stream: stream}
+}
+
+func (h *StreamHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.POST("/ticket", h.Ticket)
+	rg.GET("/stream", h.Stream)
+}
+
+// TicketAuth authenticates GET requests carrying ?ticket= by redeeming it
+// and everything else with auth.
+func (h *StreamHandler) TicketAuth(auth gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ticket := c.Query("ticket")
+		if ticket == "" || c.Request.Method != http.MethodGet {
+			auth(c)
+			return
+		}
+		userID, err := h.stream.RedeemTicket(ticket)
+		if err != nil {
+			writeError(c, err)
+			return
+		}
+		c.Set(middleware.ContextUserIDKey, userID)
+		c.Next()
+	}
+}
+
+// Ticket issues a single-use ticket for opening the stream without the
+// Authorization header.
+func (h *StreamHandler) Ticket(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	ticket, expires := h.stream.IssueTicket(userID)
+	writeOK(c, gin.H{"ticket": ticket, "expires_at": expires})
+}
+
+// Stream serves the user's live events as text/event-stream. Reconnecting
+// clients send Last-Event-ID (or ?last_event_id=) to replay what they missed.
+func (h *StreamHandler) Stream(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	lastID := c.GetHeader("Last-Event-ID")
+	if lastID == "" {
+		lastID = c.Query("last_event_id")
+	}
+	last, _ := strconv.ParseUint(lastID, 10, 64) // 无法解析时按新连接处理
+
+	sub, replay, reset := h.stream.Subscribe(userID, last)
+	defer h.stream.Unsubscribe(sub)
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // 关闭 nginx 缓冲
+	c.Render(-1, sse.Event{Event: "ready", Retry: streamRetryMs, Data: gin.H{"user_id": userID}})
+	if reset {
+		c.Render(-1, sse.Event{Event: service.StreamReset, Data: gin.H{"last_event_id": last}})
+	}
+	for _, e := range replay {
+		renderStreamEvent(c, e)
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case e, ok := <-sub.C:
+			if !ok {
+				return false // 消费过慢被断开，客户端会带 Last-Event-ID 重连
+			}
+			renderStreamEvent(c, e)
+			return true
+		case <-heartbeat.C:
+			_, _ = io.WriteString(w, ": ping\n\n")
+			return true
+		case <-c.Request.Context().Done():
+			return false
+		}
+	})
+}
+
+func renderStreamEvent(c *gin.Context, e service.StreamEvent) {
+	c.Render(-1, sse.Event{Id: strconv.FormatUint(e.ID, 10), Event: e.Type, Data: e.Data})
+}
@@ -1,50 +1,71 @@
 package handler
 
 import (
-	"net/http"
-
 	"github.com/gin-gonic/gin"
 
-	"habit-tracker/internal/middleware"
 	"habit-tracker/internal/service"
 )
 
 type UserHandler struct {
-	stats *service.UserStatsService
+	stats   *service.UserStatsService
+	profile *service.ProfileService
 }
 
-func NewUserHandler(stats *service.UserStatsService) *UserHandler {
-	return &UserHandler{stats: stats}
+func NewUserHandler(stats *service.UserStatsService, profile *service.ProfileService) *UserHandler {
+	return &UserHandler{stats: stats, profile: profile}
 }
 
-type userResponse struct {
-	Code    int         `json:"code"`
-	Message string      `json:"message"`
-	Data    interface{} `json:"data,omitempty"`
+type timezoneRequest struct {
+	Timezone string `json:"timezone"`
 }
 
-func writeUserOK(c *gin.Context, data interface{}) {
-	c.JSON(http.StatusOK, userResponse{Code: 0, Message: "ok", Data: data})
+func (h *UserHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.GET("/stats", h.Stats)
+	rg.GET("/profile", h.Profile)
+	rg.PUT("/timezone", h.SetTimezone)
 }
 
-func writeUserError(c *gin.Context, status int, msg string) {
-	c.JSON(status, userResponse{Code: 1, Message: msg})
+func (h *UserHandler) Stats(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	stats, err := h.stats.GetStats(c.Request.Context(), userID)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	writeOK(c, stats)
 }
 
-func (h *UserHandler) RegisterRoutes(rg *gin.RouterGroup) {
-	rg.GET("/stats", h.Stats)
+func (h *UserHandler) Profile(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	user, err := h.profile.Get(c.Request.Context(), userID)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	writeOK(c, user)
 }
 
-func (h *UserHandler) Stats(c *gin.Context) {
-	uid, ok := c.Get(middleware.ContextUserIDKey)
+// SetTimezone stores the IANA time zone used for series; an empty value means UTC.
+func (h *UserHandler) SetTimezone(c *gin.Context) {
+	userID, ok := currentUserID(c)
 	if !ok {
-		writeUserError(c, http.StatusUnauthorized, "unauthorized")
 		return
 	}
-	stats, err := h.stats.GetStats(c.Request.Context(), uid.(uint64))
+	var req timezoneRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, errInvalidRequest)
+		return
+	}
+	user, err := h.profile.SetTimezone(c.Request.Context(), userID, req.Timezone)
 	if err != nil {
-		writeUserError(c, http.StatusInternalServerError, "unable to load stats")
+		writeError(c, err)
 		return
 	}
-	writeUserOK(c, stats)
+	writeOK(c, user)
 }
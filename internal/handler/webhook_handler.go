@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"habit-tracker/internal/service"
+	"habit-tracker/internal/utils"
+)
+
+type WebhookHandler struct {
+	webhooks *service.WebhookService
+}
+
+func NewWebhookHandler(webhooks *service.WebhookService) *WebhookHandler {
+	return &WebhookHandler{webhooks: webhooks}
+}
+
+type webhookEndpointRequest struct {
+	URL    string   `json:"url" binding:"required"`
+	Events []string `json:"events"`
+}
+
+type deliveriesQuery struct {
+	Limit int `form:"limit"`
+}
+
+// RegisterRoutes adds the webhook endpoint routes under the authenticated /user group.
+func (h *WebhookHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.GET("/webhooks", h.List)
+	rg.POST("/webhooks", h.Create)
+	rg.DELETE("/webhooks/:id", h.Delete)
+	rg.GET("/webhooks/:id/deliveries", h.Deliveries)
+}
+
+func (h *WebhookHandler) List(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	items, err := h.webhooks.ListEndpoints(c.Request.Context(), userID)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	writeOK(c, items)
+}
+
+// Create registers an endpoint; the signing secret is only returned here.
+func (h *WebhookHandler) Create(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	var req webhookEndpointRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, errInvalidRequest)
+		return
+	}
+	ep, err := h.webhooks.CreateEndpoint(c.Request.Context(), userID, req.URL, req.Events)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	writeOK(c, ep)
+}
+
+func (h *WebhookHandler) Delete(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	id, err := utils.ParseIDParam(c.Param("id"))
+	if err != nil {
+		writeError(c, errInvalidID)
+		return
+	}
+	if err := h.webhooks.DeleteEndpoint(c.Request.Context(), userID, id); err != nil {
+		writeError(c, err)
+		return
+	}
+	writeOK(c, gin.H{"id": id})
+}
+
+func (h *WebhookHandler) Deliveries(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	id, err := utils.ParseIDParam(c.Param("id"))
+	if err != nil {
+		writeError(c, errInvalidID)
+		return
+	}
+	var q deliveriesQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		writeError(c, errInvalidQuery)
+		return
+	}
+	items, err := h.webhooks.ListDeliveries(c.Request.Context(), userID, id, q.Limit)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	writeOK(c, items)
+}
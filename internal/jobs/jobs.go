@@ -0,0 +1,30 @@
+// 后台定时任务
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// Every runs fn once immediately and then on every tick until ctx is done.
+// Errors are logged and never stop the loop.
+func Every(ctx context.Context, name string, interval time.Duration, fn func(context.Context) error) {
+	run := func() {
+		if err := fn(ctx); err != nil {
+			log.Printf("job %s: %v", name, err)
+		}
+	}
+
+	run()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			run()
+		}
+	}
+}
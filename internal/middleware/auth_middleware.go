@@ -1,34 +1,40 @@
 package middleware
 
 import (
-	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 
+	"habit-tracker/internal/apperr"
 	"habit-tracker/internal/utils"
 )
 
 const ContextUserIDKey = "user_id"
 
+var (
+	errMissingAuthHeader = apperr.New(apperr.KindUnauthorized, "auth_header_missing", "missing Authorization header")
+	errInvalidAuthHeader = apperr.New(apperr.KindUnauthorized, "auth_header_invalid", "invalid Authorization header")
+	errInvalidToken      = apperr.New(apperr.KindUnauthorized, "token_invalid", "invalid or expired token")
+)
+
 // AuthMiddleware validates Bearer token and injects user_id into context.
 func AuthMiddleware(jwtManager *utils.JWTManager) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing Authorization header"})
+			abortWithError(c, errMissingAuthHeader)
 			return
 		}
 
 		parts := strings.SplitN(authHeader, " ", 2)
 		if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid Authorization header"})
+			abortWithError(c, errInvalidAuthHeader)
 			return
 		}
 
 		userID, err := jwtManager.ParseToken(strings.TrimSpace(parts[1]))
 		if err != nil {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired token"})
+			abortWithError(c, errInvalidToken)
 			return
 		}
 
@@ -36,3 +42,8 @@ func AuthMiddleware(jwtManager *utils.JWTManager) gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+func abortWithError(c *gin.Context, err error) {
+	_ = c.Error(err)
+	c.Abort()
+}
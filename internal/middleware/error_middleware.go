@@ -1,3 +1,39 @@
 package middleware
 
-// TODO: 统一错误处理中间件
+import (
+	"log"
+
+	"github.com/gin-gonic/gin"
+
+	"habit-tracker/internal/apperr"
+)
+
+// Response is the envelope shared by every API response.
+// Code is 0 on success; on failure Error carries the machine-readable code.
+type Response struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Error   string      `json:"error,omitempty"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+// ErrorHandler renders the last error attached via c.Error using the unified envelope.
+func ErrorHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+		err := c.Errors.Last().Err
+		appErr := apperr.From(err)
+		if appErr.Kind == apperr.KindInternal {
+			log.Printf("%s %s: %v", c.Request.Method, c.Request.URL.Path, err)
+		}
+		c.JSON(appErr.Kind.HTTPStatus(), Response{
+			Code:    1,
+			Message: appErr.Message,
+			Error:   appErr.Code,
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Logger is gin.Logger with the values of the given query parameters masked,
+// so credentials passed in URLs do not end up in the access log.
+func Logger(secretParams ...string) gin.HandlerFunc {
+	return gin.LoggerWithConfig(gin.LoggerConfig{
+		Formatter: func(p gin.LogFormatterParams) string {
+			p.Path = redactQuery(p.Path, secretParams)
+			return formatLog(p)
+		},
+	})
+}
+
+// formatLog is gin's default log line.
+func formatLog(p gin.LogFormatterParams) string {
+	var statusColor, methodColor, resetColor string
+	if p.IsOutputColor() {
+		statusColor, methodColor, resetColor = p.StatusCodeColor(), p.MethodColor(), p.ResetColor()
+	}
+	if p.Latency > time.Minute {
+		p.Latency = p.Latency.Truncate(time.Second)
+	}
+	return fmt.Sprintf("[GIN] %v |%s %3d %s| %13v | %15s |%s %-7s %s %#v\n%s",
+		p.TimeStamp.Format("2006/01/02 - 15:04:05"),
+		statusColor, p.StatusCode, resetColor,
+		p.Latency,
+		p.ClientIP,
+		methodColor, p.Method, resetColor,
+		p.Path,
+		p.ErrorMessage,
+	)
+}
+
+// redactQuery replaces the values of params in the query of path, keeping
+// everything else as sent.
+func redactQuery(path string, params []string) string {
+	base, query, ok := strings.Cut(path, "?")
+	if !ok || len(params) == 0 {
+		return path
+	}
+	pairs := strings.Split(query, "&")
+	for i, pair := range pairs {
+		key, _, _ := strings.Cut(pair, "=")
+		if name, err := url.QueryUnescape(key); err == nil {
+			key = name
+		}
+		for _, param := range params {
+			if key == param {
+				pairs[i] = param + "=REDACTED"
+				break
+			}
+		}
+	}
+	return base + "?" + strings.Join(pairs, "&")
+}
@@ -8,6 +8,10 @@ type HabitCheckin struct {
 	UserID      uint64    `gorm:"column:user_id;not null;index" json:"user_id"`
 	CheckinDate time.Time `gorm:"column:checkin_date;type:date;not null;index;uniqueIndex:uq_habit_date" json:"checkin_date"`
 	Count       int       `gorm:"column:count;not null;default:0" json:"count"`
+	Quantity    float64   `gorm:"column:quantity;type:decimal(12,3);not null;default:0" json:"quantity"`
+	Note        string    `gorm:"column:note;type:text" json:"note"`
+	Mood        *int      `gorm:"column:mood;type:smallint" json:"mood"`
+	PhotoKey    string    `gorm:"column:photo_key;type:varchar(255)" json:"photo_key"`
 	CreatedAt   time.Time `gorm:"column:created_at;not null" json:"created_at"`
 }
 
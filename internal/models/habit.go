@@ -1,16 +1,52 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Habit kinds: count habits are a daily tally reaching TargetTimes, measurable
+// habits accumulate a decimal quantity in Unit reaching TargetQuantity.
+const (
+	HabitKindCount      = "count"
+	HabitKindMeasurable = "measurable"
+)
+
+// Habit polarities: build habits are done N times a day, quit habits succeed
+// on every day without a logged relapse (relapses are stored as check-ins).
+const (
+	HabitPolarityBuild = "build"
+	HabitPolarityQuit  = "quit"
+)
 
 type Habit struct {
-	ID          uint64    `gorm:"primaryKey;autoIncrement" json:"id"`
-	UserID      uint64    `gorm:"column:user_id;not null;index" json:"user_id"`
-	Name        string    `gorm:"column:name;type:varchar(128);not null" json:"name"`
-	Description string    `gorm:"column:description;type:text" json:"description"`
-	TargetType  string    `gorm:"column:target_type;type:varchar(16);not null" json:"target_type"`
-	TargetTimes int       `gorm:"column:target_times;not null;default:1" json:"target_times"`
-	StartDate   time.Time `gorm:"column:start_date;type:date;not null" json:"start_date"`
-	IsActive    bool      `gorm:"column:is_active;not null;default:true;index" json:"is_active"`
+	ID             uint64         `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID         uint64         `gorm:"column:user_id;not null;index" json:"user_id"`
+	Name           string         `gorm:"column:name;type:varchar(128);not null" json:"name"`
+	Description    string         `gorm:"column:description;type:text" json:"description"`
+	TargetType     string         `gorm:"column:target_type;type:varchar(16);not null" json:"target_type"`
+	TargetTimes    int            `gorm:"column:target_times;not null;default:1" json:"target_times"`
+	ScheduleDays   WeekdaySet     `gorm:"column:schedule_days;not null;default:0" json:"schedule_days"`
+	Kind           string         `gorm:"column:kind;type:varchar(16);not null;default:'count'" json:"kind"`
+	Unit           string         `gorm:"column:unit;type:varchar(32)" json:"unit"`
+	TargetQuantity float64        `gorm:"column:target_quantity;type:decimal(12,3);not null;default:0" json:"target_quantity"`
+	Polarity       string         `gorm:"column:polarity;type:varchar(16);not null;default:'build'" json:"polarity"`
+	LastCleanDate  *time.Time     `gorm:"column:last_clean_date;type:date" json:"last_clean_date"`
+	StartDate      time.Time      `gorm:"column:start_date;type:date;not null" json:"start_date"`
+	IsActive       bool           `gorm:"column:is_active;not null;default:true;index" json:"is_active"`
+	CategoryID     *uint64        `gorm:"column:category_id;index" json:"category_id"`
+	Color          string         `gorm:"column:color;type:varchar(16)" json:"color"`
+	Icon           string         `gorm:"column:icon;type:varchar(32)" json:"icon"`
+	SortOrder      int            `gorm:"column:sort_order;not null;default:0;index" json:"sort_order"`
+	Tags           []HabitTag     `gorm:"many2many:habit_tag_links;joinForeignKey:HabitID;joinReferences:TagID" json:"tags"`
+	ArchivedAt     *time.Time     `gorm:"column:archived_at;index" json:"archived_at"`
+	DeletedAt      gorm.DeletedAt `gorm:"column:deleted_at;index" json:"deleted_at"`
+
+	// Streak state maintained on check-in; rebuild with `habitctl repair-streaks`.
+	CurrentStreak     int        `gorm:"column:current_streak;not null;default:0" json:"current_streak"`
+	LongestStreak     int        `gorm:"column:longest_streak;not null;default:0" json:"longest_streak"`
+	LastCompletedDate *time.Time `gorm:"column:last_completed_date;type:date" json:"last_completed_date"`
 }
 
 func (Habit) TableName() string { return "habits" }
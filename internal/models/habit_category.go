@@ -0,0 +1,11 @@
+package models
+
+type HabitCategory struct {
+	ID        uint64 `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID    uint64 `gorm:"column:user_id;not null;index;uniqueIndex:uq_user_category" json:"user_id"`
+	Name      string `gorm:"column:name;type:varchar(64);not null;uniqueIndex:uq_user_category" json:"name"`
+	Color     string `gorm:"column:color;type:varchar(16)" json:"color"`
+	SortOrder int    `gorm:"column:sort_order;not null;default:0" json:"sort_order"`
+}
+
+func (HabitCategory) TableName() string { return "habit_categories" }
@@ -0,0 +1,16 @@
+package models
+
+type HabitTag struct {
+	ID     uint64 `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID uint64 `gorm:"column:user_id;not null;index;uniqueIndex:uq_user_tag" json:"user_id"`
+	Name   string `gorm:"column:name;type:varchar(64);not null;uniqueIndex:uq_user_tag" json:"name"`
+}
+
+func (HabitTag) TableName() string { return "habit_tags" }
+
+type HabitTagLink struct {
+	HabitID uint64 `gorm:"column:habit_id;primaryKey" json:"habit_id"`
+	TagID   uint64 `gorm:"column:tag_id;primaryKey;index" json:"tag_id"`
+}
+
+func (HabitTagLink) TableName() string { return "habit_tag_links" }
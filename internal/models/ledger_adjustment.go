@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// Counters corrected by the ledger reconciliation.
+const (
+	LedgerCounterPoints   = "points"
+	LedgerCounterCheckins = "total_checkins"
+)
+
+// LedgerAdjustment is the audit record of one correction of a denormalized
+// user counter to the value derived from the points log.
+type LedgerAdjustment struct {
+	ID        uint64    `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID    uint64    `gorm:"column:user_id;not null;index" json:"user_id"`
+	Counter   string    `gorm:"column:counter;type:varchar(32);not null" json:"counter"`
+	OldValue  int64     `gorm:"column:old_value;not null" json:"old_value"`
+	NewValue  int64     `gorm:"column:new_value;not null" json:"new_value"`
+	Source    string    `gorm:"column:source;type:varchar(16);not null" json:"source"` // "job" or "cli"
+	CreatedAt time.Time `gorm:"column:created_at;not null;index" json:"created_at"`
+}
+
+func (LedgerAdjustment) TableName() string { return "ledger_adjustments" }
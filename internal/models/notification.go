@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// Notification kinds.
+const (
+	NotificationReminder    = "reminder"
+	NotificationAchievement = "achievement"
+	NotificationPoints      = "points"
+	NotificationLeaderboard = "leaderboard"
+)
+
+// Notification is an entry of a user's in-app inbox; it is unread while ReadAt is nil.
+type Notification struct {
+	ID        uint64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID    uint64     `gorm:"column:user_id;not null;index:idx_notification_user" json:"user_id"`
+	Kind      string     `gorm:"column:kind;type:varchar(32);not null" json:"kind"`
+	Title     string     `gorm:"column:title;type:varchar(255);not null" json:"title"`
+	Body      string     `gorm:"column:body;type:text" json:"body"`
+	HabitID   *uint64    `gorm:"column:habit_id" json:"habit_id"`
+	ReadAt    *time.Time `gorm:"column:read_at" json:"read_at"`
+	CreatedAt time.Time  `gorm:"column:created_at;not null;index" json:"created_at"`
+}
+
+func (Notification) TableName() string { return "notifications" }
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// HabitReminder fires once a day at MinuteOfDay (0-1439) in the owner's time
+// zone. LastFiredOn is the owner's local date it last fired or was skipped.
+type HabitReminder struct {
+	ID          uint64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID      uint64     `gorm:"column:user_id;not null;index" json:"user_id"`
+	HabitID     uint64     `gorm:"column:habit_id;not null;uniqueIndex:uq_habit_reminder" json:"habit_id"`
+	MinuteOfDay int        `gorm:"column:minute_of_day;not null;uniqueIndex:uq_habit_reminder" json:"minute_of_day"`
+	LastFiredOn *time.Time `gorm:"column:last_fired_on;type:date" json:"last_fired_on"`
+	CreatedAt   time.Time  `gorm:"column:created_at;not null" json:"created_at"`
+}
+
+func (HabitReminder) TableName() string { return "habit_reminders" }
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// WeekdaySet is a bit set of weekdays, bit 0 = Sunday. It is stored as an
+// integer and encoded in JSON as a sorted list of weekday numbers (0-6).
+type WeekdaySet uint8
+
+// AllWeekdays contains every day of the week.
+const AllWeekdays WeekdaySet = 1<<7 - 1
+
+func WeekdaySetOf(days ...time.Weekday) WeekdaySet {
+	var s WeekdaySet
+	for _, d := range days {
+		s |= 1 << uint(d)
+	}
+	return s
+}
+
+func (s WeekdaySet) Has(d time.Weekday) bool {
+	return s&(1<<uint(d)) != 0
+}
+
+// Days lists the weekdays in the set from Sunday to Saturday.
+func (s WeekdaySet) Days() []time.Weekday {
+	days := make([]time.Weekday, 0, 7)
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if s.Has(d) {
+			days = append(days, d)
+		}
+	}
+	return days
+}
+
+func (s WeekdaySet) MarshalJSON() ([]byte, error) {
+	days := s.Days()
+	nums := make([]int, len(days))
+	for i, d := range days {
+		nums[i] = int(d)
+	}
+	return json.Marshal(nums)
+}
+
+func (s *WeekdaySet) UnmarshalJSON(data []byte) error {
+	var nums []int
+	if err := json.Unmarshal(data, &nums); err != nil {
+		return err
+	}
+	var set WeekdaySet
+	for _, n := range nums {
+		if n < 0 || n > 6 {
+			return fmt.Errorf("invalid weekday %d", n)
+		}
+		set |= 1 << uint(n)
+	}
+	*s = set
+	return nil
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+const (
+	StreakFreezeEarned    = "earned"
+	StreakFreezePurchased = "purchased"
+)
+
+// StreakFreeze is one freeze in a user's inventory. It is unused while UsedOn is
+// nil; once consumed it covers the missed day UsedOn of habit HabitID.
+type StreakFreeze struct {
+	ID        uint64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID    uint64     `gorm:"column:user_id;not null;index" json:"user_id"`
+	Source    string     `gorm:"column:source;type:varchar(16);not null" json:"source"`
+	HabitID   *uint64    `gorm:"column:habit_id;uniqueIndex:uq_freeze_habit_day" json:"habit_id"`
+	UsedOn    *time.Time `gorm:"column:used_on;type:date;uniqueIndex:uq_freeze_habit_day" json:"used_on"`
+	CreatedAt time.Time  `gorm:"column:created_at;not null" json:"created_at"`
+}
+
+func (StreakFreeze) TableName() string { return "streak_freezes" }
@@ -9,7 +9,13 @@ type User struct {
 	Nickname      string    `gorm:"column:nickname;type:varchar(64)" json:"nickname"`
 	Points        int64     `gorm:"column:points;not null;default:0" json:"points"`
 	TotalCheckins int64     `gorm:"column:total_checkins;not null;default:0" json:"total_checkins"`
+	Timezone      string    `gorm:"column:timezone;type:varchar(64);not null;default:''" json:"timezone"` // IANA name, empty = UTC
 	CreatedAt     time.Time `gorm:"column:created_at;not null" json:"created_at"`
+
+	// SHA-256 of the secret in the calendar feed URL; nil when the feed is off.
+	CalendarTokenHash *string `gorm:"column:calendar_token_hash;type:varchar(64);uniqueIndex" json:"-"`
+	// Reminders are also POSTed here when set.
+	ReminderWebhookURL string `gorm:"column:reminder_webhook_url;type:varchar(512);not null;default:''" json:"reminder_webhook_url"`
 }
 
 func (User) TableName() string { return "users" }
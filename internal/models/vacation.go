@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// Vacation pauses all of a user's habits from StartDate to EndDate inclusive.
+type Vacation struct {
+	ID        uint64    `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID    uint64    `gorm:"column:user_id;not null;index" json:"user_id"`
+	StartDate time.Time `gorm:"column:start_date;type:date;not null" json:"start_date"`
+	EndDate   time.Time `gorm:"column:end_date;type:date;not null" json:"end_date"`
+	CreatedAt time.Time `gorm:"column:created_at;not null" json:"created_at"`
+}
+
+func (Vacation) TableName() string { return "vacations" }
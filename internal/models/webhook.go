@@ -0,0 +1,60 @@
+package models
+
+import "time"
+
+// Outgoing webhook event types.
+const (
+	WebhookCheckinCreated      = "checkin.created"
+	WebhookTargetReached       = "habit.target_reached"
+	WebhookAchievementUnlocked = "achievement.unlocked"
+	WebhookPointsChanged       = "points.changed"
+)
+
+// Webhook delivery states; pending deliveries are retried until they succeed
+// or run out of attempts.
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliveryDelivered = "delivered"
+	WebhookDeliveryFailed    = "failed"
+)
+
+// WebhookEndpoint is a user-registered URL that receives signed events.
+// Events is a comma separated list of event types; empty means all.
+type WebhookEndpoint struct {
+	ID        uint64    `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID    uint64    `gorm:"column:user_id;not null;index" json:"user_id"`
+	URL       string    `gorm:"column:url;type:varchar(512);not null" json:"url"`
+	Secret    string    `gorm:"column:secret;type:varchar(64);not null" json:"-"`
+	Events    string    `gorm:"column:events;type:varchar(255);not null;default:''" json:"events"`
+	CreatedAt time.Time `gorm:"column:created_at;not null" json:"created_at"`
+}
+
+func (WebhookEndpoint) TableName() string { return "webhook_endpoints" }
+
+// WebhookDelivery is one event queued for one endpoint (the outbox row).
+type WebhookDelivery struct {
+	ID            uint64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	EndpointID    uint64     `gorm:"column:endpoint_id;not null;index" json:"endpoint_id"`
+	EventID       string     `gorm:"column:event_id;type:varchar(64);not null" json:"event_id"`
+	Event         string     `gorm:"column:event;type:varchar(64);not null" json:"event"`
+	Payload       string     `gorm:"column:payload;type:text;not null" json:"payload"`
+	Status        string     `gorm:"column:status;type:varchar(16);not null;index:idx_webhook_due" json:"status"`
+	Attempts      int        `gorm:"column:attempts;not null;default:0" json:"attempts"`
+	NextAttemptAt time.Time  `gorm:"column:next_attempt_at;not null;index:idx_webhook_due" json:"next_attempt_at"`
+	DeliveredAt   *time.Time `gorm:"column:delivered_at" json:"delivered_at"`
+	CreatedAt     time.Time  `gorm:"column:created_at;not null;index" json:"created_at"`
+}
+
+func (WebhookDelivery) TableName() string { return "webhook_deliveries" }
+
+// WebhookAttempt logs one HTTP attempt of a delivery.
+type WebhookAttempt struct {
+	ID         uint64    `gorm:"primaryKey;autoIncrement" json:"id"`
+	DeliveryID uint64    `gorm:"column:delivery_id;not null;index" json:"delivery_id"`
+	StatusCode int       `gorm:"column:status_code;not null;default:0" json:"status_code"` // 0 = no response
+	Error      string    `gorm:"column:error;type:varchar(512);not null;default:''" json:"error"`
+	DurationMs int64     `gorm:"column:duration_ms;not null" json:"duration_ms"`
+	CreatedAt  time.Time `gorm:"column:created_at;not null" json:"created_at"`
+}
+
+func (WebhookAttempt) TableName() string { return "webhook_attempts" }
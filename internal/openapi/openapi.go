@@ -0,0 +1,11 @@
+// Package openapi embeds the OpenAPI 3 description of the REST API.
+//
+// openapi.json is maintained by hand next to the handlers; the contract
+// tests in this package fail when a route or a request/response shape no
+// longer matches it.
+package openapi
+
+import _ "embed"
+
+//go:embed openapi.json
+var Spec []byte
@@ -0,0 +1,152 @@
+package openapi_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// document is the parsed spec with just enough of JSON Schema to check the
+// shapes the API produces: types, nullable, enum, required, properties,
+// additionalProperties, items, allOf, formats and bounds.
+type document struct {
+	root map[string]interface{}
+}
+
+func (d *document) resolve(s map[string]interface{}) map[string]interface{} {
+	for {
+		ref, ok := s["$ref"].(string)
+		if !ok {
+			return s
+		}
+		var node interface{} = d.root
+		for _, part := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+			node = node.(map[string]interface{})[part]
+		}
+		s = node.(map[string]interface{})
+	}
+}
+
+// validate returns every mismatch between v, decoded with UseNumber, and
+// schema s; path locates v in messages.
+func (d *document) validate(s map[string]interface{}, v interface{}, path string) []string {
+	s = d.resolve(s)
+	if v == nil {
+		if s["nullable"] == true || len(s) == 0 {
+			return nil
+		}
+		return []string{path + ": null is not allowed"}
+	}
+	var errs []string
+	if all, ok := s["allOf"].([]interface{}); ok {
+		for _, sub := range all {
+			errs = append(errs, d.validate(sub.(map[string]interface{}), v, path)...)
+		}
+	}
+	if enum, ok := s["enum"].([]interface{}); ok && !inEnum(enum, v) {
+		errs = append(errs, fmt.Sprintf("%s: %v is not one of %v", path, v, enum))
+	}
+	typ, _ := s["type"].(string)
+	switch typ {
+	case "":
+	case "object":
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return append(errs, fmt.Sprintf("%s: want object, got %T", path, v))
+		}
+		props, _ := s["properties"].(map[string]interface{})
+		if req, ok := s["required"].([]interface{}); ok {
+			for _, name := range req {
+				if _, ok := obj[name.(string)]; !ok {
+					errs = append(errs, fmt.Sprintf("%s: missing required property %q", path, name))
+				}
+			}
+		}
+		keys := make([]string, 0, len(obj))
+		for k := range obj {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if ps, ok := props[k]; ok {
+				errs = append(errs, d.validate(ps.(map[string]interface{}), obj[k], path+"."+k)...)
+				continue
+			}
+			switch ap := s["additionalProperties"].(type) {
+			case bool:
+				if !ap {
+					errs = append(errs, fmt.Sprintf("%s: unexpected property %q", path, k))
+				}
+			case map[string]interface{}:
+				errs = append(errs, d.validate(ap, obj[k], path+"."+k)...)
+			}
+		}
+	case "array":
+		items, ok := v.([]interface{})
+		if !ok {
+			return append(errs, fmt.Sprintf("%s: want array, got %T", path, v))
+		}
+		if is, ok := s["items"].(map[string]interface{}); ok {
+			for i, item := range items {
+				errs = append(errs, d.validate(is, item, fmt.Sprintf("%s[%d]", path, i))...)
+			}
+		}
+	case "string":
+		str, ok := v.(string)
+		if !ok {
+			return append(errs, fmt.Sprintf("%s: want string, got %T", path, v))
+		}
+		if err := checkFormat(s["format"], str); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", path, err))
+		}
+		if p, ok := s["pattern"].(string); ok && !regexp.MustCompile(p).MatchString(str) {
+			errs = append(errs, fmt.Sprintf("%s: %q does not match %s", path, str, p))
+		}
+	case "integer", "number":
+		n, ok := v.(json.Number)
+		if !ok {
+			return append(errs, fmt.Sprintf("%s: want %s, got %T", path, typ, v))
+		}
+		f, err := n.Float64()
+		if err != nil || (typ == "integer" && strings.ContainsAny(n.String(), ".eE")) {
+			return append(errs, fmt.Sprintf("%s: %s is not an %s", path, n, typ))
+		}
+		if min, ok := s["minimum"].(float64); ok && f < min {
+			errs = append(errs, fmt.Sprintf("%s: %s is below %v", path, n, min))
+		}
+		if max, ok := s["maximum"].(float64); ok && f > max {
+			errs = append(errs, fmt.Sprintf("%s: %s is above %v", path, n, max))
+		}
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			errs = append(errs, fmt.Sprintf("%s: want boolean, got %T", path, v))
+		}
+	default:
+		errs = append(errs, fmt.Sprintf("%s: unsupported schema type %q", path, typ))
+	}
+	return errs
+}
+
+func inEnum(enum []interface{}, v interface{}) bool {
+	for _, e := range enum {
+		if fmt.Sprint(e) == fmt.Sprint(v) {
+			return true
+		}
+	}
+	return false
+}
+
+func checkFormat(format interface{}, s string) error {
+	switch format {
+	case "date":
+		_, err := time.Parse("2006-01-02", s)
+		return err
+	case "date-time":
+		_, err := time.Parse(time.RFC3339Nano, s)
+		return err
+	}
+	return nil
+}
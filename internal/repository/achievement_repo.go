@@ -30,3 +30,12 @@ func (r *AchievementRepository) ListByConditionType(ctx context.Context, conditi
 		Find(&items).Error
 	return items, err
 }
+
+func (r *AchievementRepository) Create(ctx context.Context, a *models.Achievement) error {
+	return r.db.WithContext(ctx).Create(a).Error
+}
+
+// Update saves the definition of an existing achievement.
+func (r *AchievementRepository) Update(ctx context.Context, a *models.Achievement) error {
+	return r.db.WithContext(ctx).Save(a).Error
+}
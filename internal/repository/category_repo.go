@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+
+	"habit-tracker/internal/models"
+)
+
+type CategoryRepository struct {
+	db *gorm.DB
+}
+
+func NewCategoryRepository(db *gorm.DB) *CategoryRepository {
+	return &CategoryRepository{db: db}
+}
+
+func (r *CategoryRepository) ListByUser(ctx context.Context, userID uint64) ([]models.HabitCategory, error) {
+	var items []models.HabitCategory
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("sort_order asc, id asc").
+		Find(&items).Error
+	return items, err
+}
+
+func (r *CategoryRepository) GetByID(ctx context.Context, id uint64) (*models.HabitCategory, error) {
+	var item models.HabitCategory
+	if err := r.db.WithContext(ctx).First(&item, id).Error; err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+func (r *CategoryRepository) Create(ctx context.Context, category *models.HabitCategory) error {
+	return r.db.WithContext(ctx).Create(category).Error
+}
+
+func (r *CategoryRepository) Update(ctx context.Context, category *models.HabitCategory) error {
+	return r.db.WithContext(ctx).Save(category).Error
+}
+
+// Delete removes the category and detaches its habits.
+func (r *CategoryRepository) Delete(ctx context.Context, id uint64) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Model(&models.Habit{}).
+			Where("category_id = ?", id).
+			Update("category_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.HabitCategory{}, id).Error
+	})
+}
@@ -2,8 +2,9 @@ package repository
 
 import (
 	"context"
-	"time"
 	"errors"
+	"strings"
+	"time"
 
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
@@ -19,30 +20,46 @@ func NewCheckinRepository(db *gorm.DB) *CheckinRepository {
 	return &CheckinRepository{db: db}
 }
 
-// Upsert by (habit_id, checkin_date) to avoid duplicate daily records.
-func (r *CheckinRepository) Upsert(ctx context.Context, checkin *models.HabitCheckin) error {
-    return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-        var existing models.HabitCheckin
+// Upsert by (habit_id, checkin_date) to avoid duplicate daily records. It
+// returns the day's totals from before this check-in and leaves the stored
+// row in checkin, both read under the row lock.
+func (r *CheckinRepository) Upsert(ctx context.Context, checkin *models.HabitCheckin) (CheckinTotals, error) {
+	var before CheckinTotals
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var existing models.HabitCheckin
 
-        // 加锁查询，避免并发问题
-        err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
-            Where("habit_id = ? AND checkin_date = ?", checkin.HabitID, checkin.CheckinDate).
-            First(&existing).Error
+		// 加锁查询，避免并发问题（SQLite 忽略 FOR UPDATE，由单连接串行化，见 db.Open）
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("habit_id = ? AND checkin_date = ?", checkin.HabitID, checkin.CheckinDate).
+			First(&existing).Error
 
-        if errors.Is(err, gorm.ErrRecordNotFound) {
-            // 不存在就插入
-            return tx.Create(checkin).Error
-        }
-        if err != nil {
-            return err
-        }
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// 不存在就插入
+			return tx.Create(checkin).Error
+		}
+		if err != nil {
+			return err
+		}
+		before = CheckinTotals{Count: int64(existing.Count), Quantity: existing.Quantity}
 
-        // 已存在就累加 count 并更新 user_id
-        return tx.Model(&existing).Updates(map[string]interface{}{
-            "count":   gorm.Expr("count + ?", checkin.Count),
-            "user_id": checkin.UserID,
-        }).Error
-    })
+		// 已存在就累加 count/quantity 并更新 user_id；备注和心情只在本次提供时覆盖
+		updates := map[string]interface{}{
+			"count":    gorm.Expr("count + ?", checkin.Count),
+			"quantity": gorm.Expr("quantity + ?", checkin.Quantity),
+			"user_id":  checkin.UserID,
+		}
+		if checkin.Note != "" {
+			updates["note"] = checkin.Note
+		}
+		if checkin.Mood != nil {
+			updates["mood"] = *checkin.Mood
+		}
+		if err := tx.Model(&existing).Updates(updates).Error; err != nil {
+			return err
+		}
+		return tx.First(checkin, existing.ID).Error
+	})
+	return before, err
 }
 
 func (r *CheckinRepository) GetByHabitAndDate(ctx context.Context, habitID uint64, date time.Time) (*models.HabitCheckin, error) {
@@ -64,6 +81,16 @@ func (r *CheckinRepository) ListByHabitAndDateRange(ctx context.Context, habitID
 	return records, err
 }
 
+// ListByUserAndDateRange returns the user's check-ins between start and end, oldest first.
+func (r *CheckinRepository) ListByUserAndDateRange(ctx context.Context, userID uint64, start, end time.Time) ([]models.HabitCheckin, error) {
+	var records []models.HabitCheckin
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND checkin_date BETWEEN ? AND ?", userID, start, end).
+		Order("checkin_date asc, id asc").
+		Find(&records).Error
+	return records, err
+}
+
 func (r *CheckinRepository) SumCountByHabit(ctx context.Context, habitID uint64) (int64, error) {
 	var total int64
 	err := r.db.WithContext(ctx).
@@ -84,12 +111,17 @@ func (r *CheckinRepository) SumCountByUser(ctx context.Context, userID uint64) (
 	return total, err
 }
 
+// SumCountByUserAndRange sums the check-in counts of the user's build habits
+// between start and end. Relapses of quit habits are excluded, as in SumCountByBucket.
 func (r *CheckinRepository) SumCountByUserAndRange(ctx context.Context, userID uint64, start, end time.Time) (int64, error) {
 	var total int64
 	err := r.db.WithContext(ctx).
 		Model(&models.HabitCheckin{}).
 		Select("COALESCE(SUM(count),0)").
 		Where("user_id = ? AND checkin_date BETWEEN ? AND ?", userID, start, end).
+		Where("habit_id NOT IN (?)", r.db.Unscoped().Model(&models.Habit{}).
+			Select("id").
+			Where("polarity = ?", models.HabitPolarityQuit)).
 		Scan(&total).Error
 	return total, err
 }
@@ -102,3 +134,89 @@ func (r *CheckinRepository) ListByHabitDesc(ctx context.Context, habitID uint64)
 		Find(&records).Error
 	return records, err
 }
+
+func (r *CheckinRepository) GetByID(ctx context.Context, id uint64) (*models.HabitCheckin, error) {
+	var rec models.HabitCheckin
+	if err := r.db.WithContext(ctx).First(&rec, id).Error; err != nil {
+		return nil, err
+	}
+	return &rec, nil
+}
+
+// UpdateJournal sets the note and mood of a check-in; nil arguments are left unchanged.
+func (r *CheckinRepository) UpdateJournal(ctx context.Context, id uint64, note *string, mood *int) error {
+	updates := map[string]interface{}{}
+	if note != nil {
+		updates["note"] = *note
+	}
+	if mood != nil {
+		updates["mood"] = *mood
+	}
+	if len(updates) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).
+		Model(&models.HabitCheckin{}).
+		Where("id = ?", id).
+		Updates(updates).Error
+}
+
+func (r *CheckinRepository) SetPhotoKey(ctx context.Context, id uint64, key string) error {
+	return r.db.WithContext(ctx).
+		Model(&models.HabitCheckin{}).
+		Where("id = ?", id).
+		Update("photo_key", key).Error
+}
+
+// SearchNotes finds the user's check-ins whose note contains query, case-insensitively.
+func (r *CheckinRepository) SearchNotes(ctx context.Context, userID uint64, habitID *uint64, query string, limit int) ([]models.HabitCheckin, error) {
+	pattern := "%" + escapeLike(strings.ToLower(query)) + "%"
+	q := r.db.WithContext(ctx).
+		Where("user_id = ? AND LOWER(note) LIKE ? ESCAPE '\\'", userID, pattern)
+	if habitID != nil {
+		q = q.Where("habit_id = ?", *habitID)
+	}
+	var records []models.HabitCheckin
+	err := q.Order("checkin_date desc").Limit(limit).Find(&records).Error
+	return records, err
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer("\\", "\\\\", "%", "\\%", "_", "\\_").Replace(s)
+}
+
+// EachByUser feeds every check-in of the user to fn in id order, batchSize
+// rows at a time, so large histories are never loaded at once.
+func (r *CheckinRepository) EachByUser(ctx context.Context, userID uint64, batchSize int, fn func([]models.HabitCheckin) error) error {
+	var batch []models.HabitCheckin
+	return r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		FindInBatches(&batch, batchSize, func(tx *gorm.DB, _ int) error {
+			return fn(batch)
+		}).Error
+}
+
+// ListDaysByHabit returns the dates that already have a check-in for the habit.
+func (r *CheckinRepository) ListDaysByHabit(ctx context.Context, habitID uint64) ([]time.Time, error) {
+	var days []time.Time
+	err := r.db.WithContext(ctx).
+		Model(&models.HabitCheckin{}).
+		Where("habit_id = ?", habitID).
+		Pluck("checkin_date", &days).Error
+	return days, err
+}
+
+// InsertMissing inserts records whose (habit_id, checkin_date) is not taken
+// yet and leaves existing days untouched. It returns the number inserted.
+func (r *CheckinRepository) InsertMissing(ctx context.Context, records []models.HabitCheckin) (int64, error) {
+	if len(records) == 0 {
+		return 0, nil
+	}
+	res := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "habit_id"}, {Name: "checkin_date"}},
+			DoNothing: true,
+		}).
+		CreateInBatches(records, 500)
+	return res.RowsAffected, res.Error
+}
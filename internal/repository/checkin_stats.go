@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"habit-tracker/internal/models"
+)
+
+// DayThreshold selects the check-in days of a habit whose count or quantity
+// reaches a minimum. Build it with CountAtLeast or QuantityAtLeast.
+type DayThreshold struct {
+	column   string
+	min      float64
+	weekdays []int
+}
+
+// OnWeekdays additionally limits the days to the given weekdays (0 = Sunday).
+func (t DayThreshold) OnWeekdays(weekdays []int) DayThreshold {
+	t.weekdays = weekdays
+	return t
+}
+
+func (t DayThreshold) apply(db *gorm.DB) *gorm.DB {
+	db = db.Where(t.column+" >= ?", t.min)
+	if t.weekdays != nil {
+		db = db.Where(weekdayExpr(db, "checkin_date")+" IN ?", t.weekdays)
+	}
+	return db
+}
+
+// Matches reports whether rec is one of the days t selects. It is the Go
+// form of the SQL condition, for code that filters records in memory.
+func (t DayThreshold) Matches(rec models.HabitCheckin) bool {
+	value := float64(rec.Count)
+	if t.column == "quantity" {
+		value = rec.Quantity
+	}
+	if value < t.min {
+		return false
+	}
+	if t.weekdays == nil {
+		return true
+	}
+	for _, d := range t.weekdays {
+		if int(rec.CheckinDate.Weekday()) == d {
+			return true
+		}
+	}
+	return false
+}
+
+func CountAtLeast(n int) DayThreshold {
+	return DayThreshold{column: "count", min: float64(n)}
+}
+
+func QuantityAtLeast(q float64) DayThreshold {
+	return DayThreshold{column: "quantity", min: q}
+}
+
+type CheckinTotals struct {
+	Count    int64   `gorm:"column:count"`
+	Quantity float64 `gorm:"column:quantity"`
+}
+
+func (r *CheckinRepository) TotalsByHabit(ctx context.Context, habitID uint64) (CheckinTotals, error) {
+	var totals CheckinTotals
+	err := r.db.WithContext(ctx).
+		Model(&models.HabitCheckin{}).
+		Select("COALESCE(SUM(count),0) AS count, COALESCE(SUM(quantity),0) AS quantity").
+		Where("habit_id = ?", habitID).
+		Scan(&totals).Error
+	return totals, err
+}
+
+// CountDaysByWeekday counts the matching days in [from, to] per weekday, index 0 = Sunday.
+func (r *CheckinRepository) CountDaysByWeekday(ctx context.Context, habitID uint64, t DayThreshold, from, to time.Time) ([7]int64, error) {
+	var rows []struct {
+		Weekday int
+		Days    int64
+	}
+	var out [7]int64
+	query := r.db.WithContext(ctx).
+		Model(&models.HabitCheckin{}).
+		Select(weekdayExpr(r.db, "checkin_date")+" AS weekday, COUNT(*) AS days").
+		Where("habit_id = ? AND checkin_date >= ? AND checkin_date <= ?", habitID, from, to)
+	err := t.apply(query).
+		Group("weekday").
+		Scan(&rows).Error
+	if err != nil {
+		return out, err
+	}
+	for _, row := range rows {
+		if row.Weekday >= 0 && row.Weekday < 7 {
+			out[row.Weekday] = row.Days
+		}
+	}
+	return out, nil
+}
+
+// CountDaysByRanges counts the matching days in each [bounds[i], bounds[i+1])
+// with a single query, so the result has len(bounds)-1 buckets.
+func (r *CheckinRepository) CountDaysByRanges(ctx context.Context, habitID uint64, t DayThreshold, bounds []time.Time) ([]int64, error) {
+	if len(bounds) < 2 {
+		return nil, nil
+	}
+	cols := make([]string, 0, len(bounds)-1)
+	args := make([]interface{}, 0, 2*(len(bounds)-1))
+	for i := 0; i+1 < len(bounds); i++ {
+		cols = append(cols, fmt.Sprintf("COALESCE(SUM(CASE WHEN checkin_date >= ? AND checkin_date < ? THEN 1 ELSE 0 END),0) AS b%d", i))
+		args = append(args, bounds[i], bounds[i+1])
+	}
+
+	query := r.db.WithContext(ctx).
+		Model(&models.HabitCheckin{}).
+		Select(strings.Join(cols, ", "), args...).
+		Where("habit_id = ? AND checkin_date >= ? AND checkin_date < ?", habitID, bounds[0], bounds[len(bounds)-1])
+	rows, err := t.apply(query).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]int64, len(bounds)-1)
+	dest := make([]interface{}, len(out))
+	for i := range out {
+		dest[i] = &out[i]
+	}
+	if rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+	}
+	return out, rows.Err()
+}
+
+// DayScore is the summed completion ratio of build habits on one day.
+type DayScore struct {
+	Day   time.Time `gorm:"column:day"`
+	Score float64   `gorm:"column:score"`
+}
+
+// dayRatioExpr is one check-in's completion ratio, capped at 1.
+const dayRatioExpr = `CASE WHEN habits.kind = 'measurable'
+	THEN (CASE WHEN habits.target_quantity > 0 AND habit_checkins.quantity < habits.target_quantity
+		THEN habit_checkins.quantity / habits.target_quantity ELSE 1.0 END)
+	ELSE (CASE WHEN habits.target_times > 0 AND habit_checkins.count < habits.target_times
+		THEN habit_checkins.count * 1.0 / habits.target_times ELSE 1.0 END)
+	END`
+
+// SumRatiosByDay sums the completion ratios of the user's build habits per day
+// in [from, to], optionally for a single habit. Days without check-ins are omitted.
+func (r *CheckinRepository) SumRatiosByDay(ctx context.Context, userID uint64, habitID *uint64, from, to time.Time) ([]DayScore, error) {
+	query := r.db.WithContext(ctx).
+		Table("habit_checkins").
+		Select("habit_checkins.checkin_date AS day, SUM("+dayRatioExpr+") AS score").
+		Joins("JOIN habits ON habits.id = habit_checkins.habit_id").
+		Where("habit_checkins.user_id = ? AND habit_checkins.checkin_date >= ? AND habit_checkins.checkin_date <= ?", userID, from, to).
+		Where("habits.polarity = ? AND habits.deleted_at IS NULL", models.HabitPolarityBuild)
+	if habitID != nil {
+		query = query.Where("habit_checkins.habit_id = ?", *habitID)
+	}
+	var scores []DayScore
+	err := query.Group("habit_checkins.checkin_date").
+		Order("habit_checkins.checkin_date asc").
+		Scan(&scores).Error
+	return scores, err
+}
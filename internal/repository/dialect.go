@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// The queries target PostgreSQL/openGauss. SQLite, used for local development
+// and tests, lacks EXTRACT, date_trunc and time zones, so the helpers below
+// build its equivalents. The SQLite driver stores times as text with the
+// writer's wall clock first, e.g. "2024-05-01 00:00:00+08:00", so the first
+// ten characters are the calendar date as written.
+
+func isSQLite(db *gorm.DB) bool {
+	return db.Dialector.Name() == "sqlite"
+}
+
+// weekdayExpr extracts the weekday of a date column, 0 = Sunday.
+func weekdayExpr(db *gorm.DB, column string) string {
+	if isSQLite(db) {
+		return fmt.Sprintf("CAST(strftime('%%w', substr(%s, 1, 10)) AS INTEGER)", column)
+	}
+	return fmt.Sprintf("CAST(EXTRACT(DOW FROM %s) AS INTEGER)", column)
+}
+
+// dateTruncExpr truncates a date column to the first day of its bucket.
+func dateTruncExpr(db *gorm.DB, unit, column string) (string, error) {
+	if !isSQLite(db) {
+		return truncExpr(unit, "CAST("+column+" AS timestamp)")
+	}
+	date := "substr(" + column + ", 1, 10)"
+	switch unit {
+	case BucketDay:
+		return "date(" + date + ")", nil
+	case BucketWeek:
+		// the Monday on or before the date
+		return "date(" + date + ", '-6 days', 'weekday 1')", nil
+	case BucketMonth:
+		return "date(" + date + ", 'start of month')", nil
+	}
+	return "", fmt.Errorf("unsupported bucket unit %q", unit)
+}
+
+// truncateWall is the Go equivalent of date_trunc on the wall clock of t.
+func truncateWall(t time.Time, unit string) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	switch unit {
+	case BucketWeek:
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	case BucketMonth:
+		return day.AddDate(0, 0, 1-day.Day())
+	}
+	return day
+}
+
+// sqliteTimeSlack covers the largest gap between two UTC offsets. Times
+// written in different offsets compare as text on SQLite, so range queries
+// on timestamps widen their bounds by it and filter exactly in Go.
+const sqliteTimeSlack = 27 * time.Hour
+
+// bucketStart scans a bucket column, which SQLite returns as a date string.
+type bucketStart struct{ time.Time }
+
+func (b *bucketStart) Scan(v interface{}) error {
+	switch v := v.(type) {
+	case time.Time:
+		b.Time = v
+		return nil
+	case string:
+		return b.parse(v)
+	case []byte:
+		return b.parse(string(v))
+	}
+	return fmt.Errorf("unsupported bucket value %T", v)
+}
+
+func (b *bucketStart) parse(s string) error {
+	if len(s) < 10 {
+		return fmt.Errorf("invalid bucket value %q", s)
+	}
+	t, err := time.Parse("2006-01-02", s[:10])
+	b.Time = t
+	return err
+}
+
+// Value is only there for gorm, which accepts struct fields that are both
+// Scanner and Valuer.
+func (b bucketStart) Value() (driver.Value, error) {
+	return b.Time, nil
+}
+
+var (
+	_ sql.Scanner   = (*bucketStart)(nil)
+	_ driver.Valuer = bucketStart{}
+)
+
+// scanBuckets runs a query selecting bucket and total columns.
+func scanBuckets(query *gorm.DB) ([]BucketSum, error) {
+	var rows []struct {
+		Bucket bucketStart
+		Total  int64
+	}
+	if err := query.Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	out := make([]BucketSum, len(rows))
+	for i, row := range rows {
+		out[i] = BucketSum{Start: row.Bucket.Time, Total: row.Total}
+	}
+	return out, nil
+}
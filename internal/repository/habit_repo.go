@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"gorm.io/gorm"
 
@@ -16,6 +17,37 @@ func NewHabitRepository(db *gorm.DB) *HabitRepository {
 	return &HabitRepository{db: db}
 }
 
+// Sort keys accepted by HabitFilter.Sort.
+const (
+	HabitSortManual    = "manual"
+	HabitSortName      = "name"
+	HabitSortStartDate = "start_date"
+	HabitSortCreated   = "created"
+)
+
+var habitSortOrders = map[string]string{
+	HabitSortManual:    "sort_order asc, start_date asc, id asc",
+	HabitSortName:      "name asc, id asc",
+	HabitSortStartDate: "start_date asc, id asc",
+	HabitSortCreated:   "id asc",
+}
+
+// ValidHabitSort reports whether key is a supported sort key.
+func ValidHabitSort(key string) bool {
+	_, ok := habitSortOrders[key]
+	return ok
+}
+
+// HabitFilter narrows ListFiltered; nil/empty fields are not applied.
+type HabitFilter struct {
+	IsActive   *bool
+	Archived   *bool
+	CategoryID *uint64
+	Tag        string
+	Sort       string // defaults to HabitSortManual
+}
+
+// ListByUser returns every non-deleted habit, archived ones included.
 func (r *HabitRepository) ListByUser(ctx context.Context, userID uint64) ([]models.Habit, error) {
 	var habits []models.Habit
 	err := r.db.WithContext(ctx).
@@ -25,14 +57,105 @@ func (r *HabitRepository) ListByUser(ctx context.Context, userID uint64) ([]mode
 	return habits, err
 }
 
-func (r *HabitRepository) ListByUserWithActive(ctx context.Context, userID uint64, isActive *bool) ([]models.Habit, error) {
+func (r *HabitRepository) ListFiltered(ctx context.Context, userID uint64, f HabitFilter) ([]models.Habit, error) {
 	query := r.db.WithContext(ctx).
 		Where("user_id = ?", userID)
-	if isActive != nil {
-		query = query.Where("is_active = ?", *isActive)
+	if f.IsActive != nil {
+		query = query.Where("is_active = ?", *f.IsActive)
+	}
+	if f.Archived != nil {
+		if *f.Archived {
+			query = query.Where("archived_at IS NOT NULL")
+		} else {
+			query = query.Where("archived_at IS NULL")
+		}
+	}
+	if f.CategoryID != nil {
+		query = query.Where("category_id = ?", *f.CategoryID)
+	}
+	if f.Tag != "" {
+		query = query.Where("id IN (?)", r.db.
+			Table("habit_tag_links").
+			Select("habit_tag_links.habit_id").
+			Joins("JOIN habit_tags ON habit_tags.id = habit_tag_links.tag_id").
+			Where("habit_tags.user_id = ? AND habit_tags.name = ?", userID, f.Tag))
+	}
+	order, ok := habitSortOrders[f.Sort]
+	if !ok {
+		order = habitSortOrders[HabitSortManual]
 	}
 	var habits []models.Habit
-	err := query.Order("start_date asc").Find(&habits).Error
+	err := query.Preload("Tags").Order(order).Find(&habits).Error
+	return habits, err
+}
+
+// ListTrackedByPolarity returns active, unarchived habits of every user with the given polarity.
+func (r *HabitRepository) ListTrackedByPolarity(ctx context.Context, polarity string) ([]models.Habit, error) {
+	var habits []models.Habit
+	err := r.db.WithContext(ctx).
+		Where("polarity = ? AND is_active = ? AND archived_at IS NULL", polarity, true).
+		Order("id asc").
+		Find(&habits).Error
+	return habits, err
+}
+
+// ListAll returns every habit that is not soft-deleted.
+func (r *HabitRepository) ListAll(ctx context.Context) ([]models.Habit, error) {
+	var habits []models.Habit
+	err := r.db.WithContext(ctx).Order("id asc").Find(&habits).Error
+	return habits, err
+}
+
+// ListMissingStreakState returns habits that have history but no persisted
+// streak state, i.e. rows that predate the streak columns.
+func (r *HabitRepository) ListMissingStreakState(ctx context.Context) ([]models.Habit, error) {
+	var habits []models.Habit
+	err := r.db.WithContext(ctx).
+		Where("last_completed_date IS NULL").
+		Where("last_clean_date IS NOT NULL OR EXISTS (SELECT 1 FROM habit_checkins WHERE habit_checkins.habit_id = habits.id)").
+		Order("id asc").
+		Find(&habits).Error
+	return habits, err
+}
+
+// ListTrackedByUser is ListTrackedByPolarity limited to one user.
+func (r *HabitRepository) ListTrackedByUser(ctx context.Context, userID uint64, polarity string) ([]models.Habit, error) {
+	var habits []models.Habit
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND polarity = ? AND is_active = ? AND archived_at IS NULL", userID, polarity, true).
+		Order("id asc").
+		Find(&habits).Error
+	return habits, err
+}
+
+// ListByUserWithDeleted returns every habit of the user with its tags,
+// soft-deleted ones included, in id order.
+func (r *HabitRepository) ListByUserWithDeleted(ctx context.Context, userID uint64) ([]models.Habit, error) {
+	var habits []models.Habit
+	err := r.db.WithContext(ctx).Unscoped().
+		Where("user_id = ?", userID).
+		Preload("Tags").
+		Order("id asc").
+		Find(&habits).Error
+	return habits, err
+}
+
+// ListDeletedByUser returns soft-deleted habits, most recently deleted first.
+func (r *HabitRepository) ListDeletedByUser(ctx context.Context, userID uint64) ([]models.Habit, error) {
+	var habits []models.Habit
+	err := r.db.WithContext(ctx).Unscoped().
+		Where("user_id = ? AND deleted_at IS NOT NULL", userID).
+		Order("deleted_at desc").
+		Find(&habits).Error
+	return habits, err
+}
+
+// ListDeletedBefore returns soft-deleted habits whose deletion is older than cutoff.
+func (r *HabitRepository) ListDeletedBefore(ctx context.Context, cutoff time.Time) ([]models.Habit, error) {
+	var habits []models.Habit
+	err := r.db.WithContext(ctx).Unscoped().
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).
+		Find(&habits).Error
 	return habits, err
 }
 
@@ -44,12 +167,68 @@ func (r *HabitRepository) GetByID(ctx context.Context, id uint64) (*models.Habit
 	return &habit, nil
 }
 
+func (r *HabitRepository) GetByIDWithTags(ctx context.Context, id uint64) (*models.Habit, error) {
+	var habit models.Habit
+	if err := r.db.WithContext(ctx).Preload("Tags").First(&habit, id).Error; err != nil {
+		return nil, err
+	}
+	return &habit, nil
+}
+
+// GetByIDUnscoped also finds soft-deleted habits.
+func (r *HabitRepository) GetByIDUnscoped(ctx context.Context, id uint64) (*models.Habit, error) {
+	var habit models.Habit
+	if err := r.db.WithContext(ctx).Unscoped().First(&habit, id).Error; err != nil {
+		return nil, err
+	}
+	return &habit, nil
+}
+
+// Create inserts the habit at the end of the user's manual order.
+// Tags are managed separately through TagRepository.ReplaceForHabit.
 func (r *HabitRepository) Create(ctx context.Context, habit *models.Habit) error {
-	return r.db.WithContext(ctx).Create(habit).Error
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var maxOrder int
+		if err := tx.Unscoped().Model(&models.Habit{}).
+			Select("COALESCE(MAX(sort_order),0)").
+			Where("user_id = ?", habit.UserID).
+			Scan(&maxOrder).Error; err != nil {
+			return err
+		}
+		habit.SortOrder = maxOrder + 1
+		return tx.Omit("Tags").Create(habit).Error
+	})
 }
 
+// Update saves the editable columns; streak state is only written by UpdateStreakState.
 func (r *HabitRepository) Update(ctx context.Context, habit *models.Habit) error {
-	return r.db.WithContext(ctx).Save(habit).Error
+	return r.db.WithContext(ctx).
+		Omit("Tags", "current_streak", "longest_streak", "last_completed_date").
+		Save(habit).Error
+}
+
+// Reorder assigns sort_order following the position of each id in habitIDs.
+// It fails with gorm.ErrRecordNotFound if any id is not a habit of the user.
+func (r *HabitRepository) Reorder(ctx context.Context, userID uint64, habitIDs []uint64) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var owned int64
+		if err := tx.Model(&models.Habit{}).
+			Where("user_id = ? AND id IN ?", userID, habitIDs).
+			Count(&owned).Error; err != nil {
+			return err
+		}
+		if owned != int64(len(habitIDs)) {
+			return gorm.ErrRecordNotFound
+		}
+		for i, id := range habitIDs {
+			if err := tx.Model(&models.Habit{}).
+				Where("id = ?", id).
+				Update("sort_order", i+1).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
 
 func (r *HabitRepository) UpdateStatus(ctx context.Context, habitID uint64, isActive bool) error {
@@ -58,3 +237,128 @@ func (r *HabitRepository) UpdateStatus(ctx context.Context, habitID uint64, isAc
 		Where("id = ?", habitID).
 		Update("is_active", isActive).Error
 }
+
+// SetArchivedAt archives the habit at the given time, or unarchives it when at is nil.
+func (r *HabitRepository) SetArchivedAt(ctx context.Context, habitID uint64, at *time.Time) error {
+	return r.db.WithContext(ctx).
+		Model(&models.Habit{}).
+		Where("id = ?", habitID).
+		Update("archived_at", at).Error
+}
+
+// UpdateLastCleanDate moves the habit's last clean day forward to day; it
+// never moves it back.
+func (r *HabitRepository) UpdateLastCleanDate(ctx context.Context, habitID uint64, day time.Time) error {
+	return r.db.WithContext(ctx).
+		Model(&models.Habit{}).
+		Where("id = ? AND (last_clean_date IS NULL OR last_clean_date < ?)", habitID, day).
+		Update("last_clean_date", day).Error
+}
+
+// ClaimCleanDay records day as the habit's last clean day and counts it as a
+// check-in of the user, in one transaction. It reports false without changes
+// when the day is already claimed, e.g. by a concurrent run.
+func (r *HabitRepository) ClaimCleanDay(ctx context.Context, habitID, userID uint64, day time.Time) (bool, error) {
+	claimed := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&models.Habit{}).
+			Where("id = ? AND (last_clean_date IS NULL OR last_clean_date < ?)", habitID, day).
+			Update("last_clean_date", day)
+		if res.Error != nil || res.RowsAffected != 1 {
+			return res.Error
+		}
+		if err := tx.Model(&models.User{}).
+			Where("id = ?", userID).
+			UpdateColumn("total_checkins", gorm.Expr("total_checkins + ?", 1)).Error; err != nil {
+			return err
+		}
+		claimed = true
+		return nil
+	})
+	return claimed, err
+}
+
+// UpdateStreakState stores the habit's persisted streak state.
+func (r *HabitRepository) UpdateStreakState(ctx context.Context, habitID uint64, current, longest int, lastCompleted *time.Time) error {
+	return r.db.WithContext(ctx).
+		Model(&models.Habit{}).
+		Where("id = ?", habitID).
+		Updates(map[string]interface{}{
+			"current_streak":      current,
+			"longest_streak":      longest,
+			"last_completed_date": lastCompleted,
+		}).Error
+}
+
+// SoftDelete marks the habit deleted; its check-ins and points log stay untouched.
+func (r *HabitRepository) SoftDelete(ctx context.Context, habitID uint64) error {
+	return r.db.WithContext(ctx).Delete(&models.Habit{}, habitID).Error
+}
+
+func (r *HabitRepository) Restore(ctx context.Context, habitID uint64) error {
+	return r.db.WithContext(ctx).Unscoped().
+		Model(&models.Habit{}).
+		Where("id = ?", habitID).
+		Update("deleted_at", nil).Error
+}
+
+// HardDelete removes the habit with its check-ins, tag links and reminders in
+// one transaction and returns the photo keys of the removed check-ins for blob cleanup.
+// With revokePoints the related points log rows are deleted and the user's
+// points and total_checkins are reduced accordingly; otherwise the log rows
+// are kept and only detached from the habit.
+func (r *HabitRepository) HardDelete(ctx context.Context, habit *models.Habit, revokePoints bool) ([]string, error) {
+	var photoKeys []string
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.HabitCheckin{}).
+			Where("habit_id = ? AND photo_key <> ''", habit.ID).
+			Pluck("photo_key", &photoKeys).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("habit_id = ?", habit.ID).Delete(&models.HabitCheckin{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("habit_id = ?", habit.ID).Delete(&models.HabitTagLink{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("habit_id = ?", habit.ID).Delete(&models.HabitReminder{}).Error; err != nil {
+			return err
+		}
+
+		if revokePoints {
+			var agg struct {
+				Points   int64
+				Checkins int64
+			}
+			if err := tx.Model(&models.UserPointsLog{}).
+				Select("COALESCE(SUM(change_amount),0) AS points, COALESCE(SUM("+checkinsFromLog+"),0) AS checkins").
+				Where("related_habit_id = ?", habit.ID).
+				Scan(&agg).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("related_habit_id = ?", habit.ID).Delete(&models.UserPointsLog{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&models.User{}).
+				Where("id = ?", habit.UserID).
+				UpdateColumns(map[string]interface{}{
+					"points":         gorm.Expr("points - ?", agg.Points),
+					"total_checkins": gorm.Expr("total_checkins - ?", agg.Checkins),
+				}).Error; err != nil {
+				return err
+			}
+		} else {
+			if err := tx.Model(&models.UserPointsLog{}).
+				Where("related_habit_id = ?", habit.ID).
+				Update("related_habit_id", nil).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.Unscoped().Delete(&models.Habit{}, habit.ID).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return photoKeys, nil
+}
@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"habit-tracker/internal/models"
+)
+
+type LedgerRepository struct {
+	db *gorm.DB
+}
+
+func NewLedgerRepository(db *gorm.DB) *LedgerRepository {
+	return &LedgerRepository{db: db}
+}
+
+// CounterCorrection moves a user's counters from the Old to the New values.
+type CounterCorrection struct {
+	UserID                   uint64
+	OldPoints, NewPoints     int64
+	OldCheckins, NewCheckins int64
+}
+
+// Correct applies c and records a LedgerAdjustment for every changed counter
+// in one transaction. Nothing is written and false is returned when the
+// counters no longer hold the Old values.
+func (r *LedgerRepository) Correct(ctx context.Context, c CounterCorrection, source string, at time.Time) (bool, error) {
+	applied := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&models.User{}).
+			Where("id = ? AND points = ? AND total_checkins = ?", c.UserID, c.OldPoints, c.OldCheckins).
+			UpdateColumns(map[string]interface{}{"points": c.NewPoints, "total_checkins": c.NewCheckins})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return nil
+		}
+		var audit []models.LedgerAdjustment
+		if c.OldPoints != c.NewPoints {
+			audit = append(audit, models.LedgerAdjustment{
+				UserID: c.UserID, Counter: models.LedgerCounterPoints,
+				OldValue: c.OldPoints, NewValue: c.NewPoints, Source: source, CreatedAt: at,
+			})
+		}
+		if c.OldCheckins != c.NewCheckins {
+			audit = append(audit, models.LedgerAdjustment{
+				UserID: c.UserID, Counter: models.LedgerCounterCheckins,
+				OldValue: c.OldCheckins, NewValue: c.NewCheckins, Source: source, CreatedAt: at,
+			})
+		}
+		if len(audit) > 0 {
+			if err := tx.Create(&audit).Error; err != nil {
+				return err
+			}
+		}
+		applied = true
+		return nil
+	})
+	return applied, err
+}
+
+// ListAdjustments returns the newest adjustments first; userID 0 means all users.
+func (r *LedgerRepository) ListAdjustments(ctx context.Context, userID uint64, limit int) ([]models.LedgerAdjustment, error) {
+	var items []models.LedgerAdjustment
+	q := r.db.WithContext(ctx).Order("id desc").Limit(limit)
+	if userID != 0 {
+		q = q.Where("user_id = ?", userID)
+	}
+	err := q.Find(&items).Error
+	return items, err
+}
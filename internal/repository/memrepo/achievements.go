@@ -0,0 +1,93 @@
+package memrepo
+
+import (
+	"context"
+	"sort"
+
+	"gorm.io/gorm"
+
+	"habit-tracker/internal/models"
+	"habit-tracker/internal/repository"
+)
+
+type AchievementRepository struct {
+	db *DB
+}
+
+func NewAchievementRepository(db *DB) *AchievementRepository {
+	return &AchievementRepository{db: db}
+}
+
+var _ repository.AchievementStore = (*AchievementRepository)(nil)
+
+func (r *AchievementRepository) ListAll(ctx context.Context) ([]models.Achievement, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	return rows(r.db.achievements, nil), nil
+}
+
+func (r *AchievementRepository) ListByConditionType(ctx context.Context, conditionType string) ([]models.Achievement, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	return rows(r.db.achievements, func(a models.Achievement) bool { return a.ConditionType == conditionType }), nil
+}
+
+func (r *AchievementRepository) Create(ctx context.Context, a *models.Achievement) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	for _, existing := range r.db.achievements {
+		if existing.Code == a.Code {
+			return gorm.ErrDuplicatedKey
+		}
+	}
+	a.ID = r.db.nextID("achievements")
+	r.db.achievements[a.ID] = *a
+	return nil
+}
+
+// Update saves a like gorm's Save: it inserts when a has no ID yet.
+func (r *AchievementRepository) Update(ctx context.Context, a *models.Achievement) error {
+	if a.ID == 0 {
+		return r.Create(ctx, a)
+	}
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	for _, existing := range r.db.achievements {
+		if existing.ID != a.ID && existing.Code == a.Code {
+			return gorm.ErrDuplicatedKey
+		}
+	}
+	r.db.achievements[a.ID] = *a
+	return nil
+}
+
+type UserAchievementRepository struct {
+	db *DB
+}
+
+func NewUserAchievementRepository(db *DB) *UserAchievementRepository {
+	return &UserAchievementRepository{db: db}
+}
+
+var _ repository.UserAchievementStore = (*UserAchievementRepository)(nil)
+
+func (r *UserAchievementRepository) ListByUser(ctx context.Context, userID uint64) ([]models.UserAchievement, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	items := rows(r.db.userAchievements, func(ua models.UserAchievement) bool { return ua.UserID == userID })
+	sort.SliceStable(items, func(i, j int) bool { return items[i].UnlockedAt.After(items[j].UnlockedAt) })
+	return items, nil
+}
+
+func (r *UserAchievementRepository) Create(ctx context.Context, ua *models.UserAchievement) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	for _, existing := range r.db.userAchievements {
+		if existing.UserID == ua.UserID && existing.AchievementID == ua.AchievementID {
+			return gorm.ErrDuplicatedKey
+		}
+	}
+	ua.ID = r.db.nextID("user_achievements")
+	r.db.userAchievements[ua.ID] = *ua
+	return nil
+}
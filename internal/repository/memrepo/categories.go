@@ -0,0 +1,158 @@
+package memrepo
+
+import (
+	"context"
+	"sort"
+
+	"gorm.io/gorm"
+
+	"habit-tracker/internal/models"
+	"habit-tracker/internal/repository"
+)
+
+type CategoryRepository struct {
+	db *DB
+}
+
+func NewCategoryRepository(db *DB) *CategoryRepository {
+	return &CategoryRepository{db: db}
+}
+
+var _ repository.CategoryStore = (*CategoryRepository)(nil)
+
+func (r *CategoryRepository) ListByUser(ctx context.Context, userID uint64) ([]models.HabitCategory, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	items := rows(r.db.categories, func(c models.HabitCategory) bool { return c.UserID == userID })
+	sort.SliceStable(items, func(i, j int) bool { return items[i].SortOrder < items[j].SortOrder })
+	return items, nil
+}
+
+func (r *CategoryRepository) GetByID(ctx context.Context, id uint64) (*models.HabitCategory, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	item, ok := r.db.categories[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &item, nil
+}
+
+func (r *CategoryRepository) Create(ctx context.Context, category *models.HabitCategory) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	return r.save(category)
+}
+
+func (r *CategoryRepository) Update(ctx context.Context, category *models.HabitCategory) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	return r.save(category)
+}
+
+// Delete removes the category and detaches its habits, deleted ones included.
+func (r *CategoryRepository) Delete(ctx context.Context, id uint64) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	for habitID, h := range r.db.habits {
+		if h.CategoryID != nil && *h.CategoryID == id {
+			h.CategoryID = nil
+			r.db.habits[habitID] = h
+		}
+	}
+	delete(r.db.categories, id)
+	return nil
+}
+
+// save inserts or updates category, enforcing the unique (user_id, name);
+// callers hold mu.
+func (r *CategoryRepository) save(category *models.HabitCategory) error {
+	for _, c := range r.db.categories {
+		if c.ID != category.ID && c.UserID == category.UserID && c.Name == category.Name {
+			return gorm.ErrDuplicatedKey
+		}
+	}
+	if category.ID == 0 {
+		category.ID = r.db.nextID("habit_categories")
+	}
+	r.db.categories[category.ID] = *category
+	return nil
+}
+
+type TagRepository struct {
+	db *DB
+}
+
+func NewTagRepository(db *DB) *TagRepository {
+	return &TagRepository{db: db}
+}
+
+var _ repository.TagStore = (*TagRepository)(nil)
+
+func (r *TagRepository) ListByUser(ctx context.Context, userID uint64) ([]models.HabitTag, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	return sortedTags(rows(r.db.tags, func(t models.HabitTag) bool { return t.UserID == userID })), nil
+}
+
+func (r *TagRepository) GetByID(ctx context.Context, id uint64) (*models.HabitTag, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	item, ok := r.db.tags[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &item, nil
+}
+
+// EnsureByNames returns the user's tags with the given names, creating missing ones.
+func (r *TagRepository) EnsureByNames(ctx context.Context, userID uint64, names []string) ([]models.HabitTag, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	inNames := map[string]bool{}
+	for _, name := range names {
+		inNames[name] = true
+	}
+	taken := map[string]bool{}
+	for _, t := range r.db.tags {
+		if t.UserID == userID {
+			taken[t.Name] = true
+		}
+	}
+	for _, name := range names {
+		if !taken[name] {
+			taken[name] = true
+			id := r.db.nextID("habit_tags")
+			r.db.tags[id] = models.HabitTag{ID: id, UserID: userID, Name: name}
+		}
+	}
+	return sortedTags(rows(r.db.tags, func(t models.HabitTag) bool { return t.UserID == userID && inNames[t.Name] })), nil
+}
+
+// ReplaceForHabit sets the habit's tags to exactly tagIDs.
+func (r *TagRepository) ReplaceForHabit(ctx context.Context, habitID uint64, tagIDs []uint64) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	r.db.unlinkTags(func(l models.HabitTagLink) bool { return l.HabitID == habitID })
+	for _, id := range tagIDs {
+		r.db.tagLinks = append(r.db.tagLinks, models.HabitTagLink{HabitID: habitID, TagID: id})
+	}
+	return nil
+}
+
+// Delete removes the tag and its links to habits.
+func (r *TagRepository) Delete(ctx context.Context, id uint64) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	r.db.unlinkTags(func(l models.HabitTagLink) bool { return l.TagID == id })
+	delete(r.db.tags, id)
+	return nil
+}
+
+func sortedTags(tags []models.HabitTag) []models.HabitTag {
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].Name < tags[j].Name })
+	return tags
+}
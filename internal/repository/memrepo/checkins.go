@@ -0,0 +1,356 @@
+package memrepo
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"habit-tracker/internal/models"
+	"habit-tracker/internal/repository"
+)
+
+type CheckinRepository struct {
+	db *DB
+}
+
+func NewCheckinRepository(db *DB) *CheckinRepository {
+	return &CheckinRepository{db: db}
+}
+
+var _ repository.CheckinStore = (*CheckinRepository)(nil)
+
+// Upsert by (habit_id, checkin_date): an existing day gets the count and
+// quantity added, and note and mood only when they are set. It returns the
+// day's totals from before and leaves the stored row in checkin.
+func (r *CheckinRepository) Upsert(ctx context.Context, checkin *models.HabitCheckin) (repository.CheckinTotals, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	if existing, ok := r.db.checkinOn(checkin.HabitID, checkin.CheckinDate); ok {
+		before := repository.CheckinTotals{Count: int64(existing.Count), Quantity: existing.Quantity}
+		existing.Count += checkin.Count
+		existing.Quantity += checkin.Quantity
+		existing.UserID = checkin.UserID
+		if checkin.Note != "" {
+			existing.Note = checkin.Note
+		}
+		if checkin.Mood != nil {
+			mood := *checkin.Mood
+			existing.Mood = &mood
+		}
+		r.db.checkins[existing.ID] = existing
+		*checkin = existing
+		return before, nil
+	}
+	r.db.insertCheckin(checkin)
+	return repository.CheckinTotals{}, nil
+}
+
+func (r *CheckinRepository) GetByHabitAndDate(ctx context.Context, habitID uint64, date time.Time) (*models.HabitCheckin, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	rec, ok := r.db.checkinOn(habitID, date)
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &rec, nil
+}
+
+func (r *CheckinRepository) ListByHabitAndDateRange(ctx context.Context, habitID uint64, start, end time.Time) ([]models.HabitCheckin, error) {
+	records := r.list(func(c models.HabitCheckin) bool { return c.HabitID == habitID && between(c.CheckinDate, start, end) })
+	sortByDateDesc(records)
+	return records, nil
+}
+
+func (r *CheckinRepository) ListByUserAndDateRange(ctx context.Context, userID uint64, start, end time.Time) ([]models.HabitCheckin, error) {
+	records := r.list(func(c models.HabitCheckin) bool { return c.UserID == userID && between(c.CheckinDate, start, end) })
+	sort.SliceStable(records, func(i, j int) bool { return dayKey(records[i].CheckinDate) < dayKey(records[j].CheckinDate) })
+	return records, nil
+}
+
+func (r *CheckinRepository) SumCountByHabit(ctx context.Context, habitID uint64) (int64, error) {
+	return sumCount(r.list(func(c models.HabitCheckin) bool { return c.HabitID == habitID })), nil
+}
+
+func (r *CheckinRepository) SumCountByUser(ctx context.Context, userID uint64) (int64, error) {
+	return sumCount(r.list(func(c models.HabitCheckin) bool { return c.UserID == userID })), nil
+}
+
+// SumCountByUserAndRange sums the check-in counts of build habits between
+// start and end.
+func (r *CheckinRepository) SumCountByUserAndRange(ctx context.Context, userID uint64, start, end time.Time) (int64, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	return sumCount(rows(r.db.checkins, func(c models.HabitCheckin) bool {
+		h, ok := r.db.habits[c.HabitID]
+		return c.UserID == userID && between(c.CheckinDate, start, end) &&
+			!(ok && h.Polarity == models.HabitPolarityQuit)
+	})), nil
+}
+
+func (r *CheckinRepository) ListByHabitDesc(ctx context.Context, habitID uint64) ([]models.HabitCheckin, error) {
+	records := r.list(func(c models.HabitCheckin) bool { return c.HabitID == habitID })
+	sortByDateDesc(records)
+	return records, nil
+}
+
+func (r *CheckinRepository) GetByID(ctx context.Context, id uint64) (*models.HabitCheckin, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	rec, ok := r.db.checkins[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &rec, nil
+}
+
+func (r *CheckinRepository) UpdateJournal(ctx context.Context, id uint64, note *string, mood *int) error {
+	r.update(id, func(c *models.HabitCheckin) {
+		if note != nil {
+			c.Note = *note
+		}
+		if mood != nil {
+			v := *mood
+			c.Mood = &v
+		}
+	})
+	return nil
+}
+
+func (r *CheckinRepository) SetPhotoKey(ctx context.Context, id uint64, key string) error {
+	r.update(id, func(c *models.HabitCheckin) { c.PhotoKey = key })
+	return nil
+}
+
+func (r *CheckinRepository) SearchNotes(ctx context.Context, userID uint64, habitID *uint64, query string, n int) ([]models.HabitCheckin, error) {
+	query = strings.ToLower(query)
+	records := r.list(func(c models.HabitCheckin) bool {
+		return c.UserID == userID && (habitID == nil || c.HabitID == *habitID) &&
+			strings.Contains(strings.ToLower(c.Note), query)
+	})
+	sortByDateDesc(records)
+	return limit(records, n), nil
+}
+
+func (r *CheckinRepository) EachByUser(ctx context.Context, userID uint64, batchSize int, fn func([]models.HabitCheckin) error) error {
+	records := r.list(func(c models.HabitCheckin) bool { return c.UserID == userID })
+	return eachBatch(records, batchSize, fn)
+}
+
+func (r *CheckinRepository) ListDaysByHabit(ctx context.Context, habitID uint64) ([]time.Time, error) {
+	var days []time.Time
+	for _, c := range r.list(func(c models.HabitCheckin) bool { return c.HabitID == habitID }) {
+		days = append(days, c.CheckinDate)
+	}
+	return days, nil
+}
+
+// InsertMissing inserts the records whose (habit_id, checkin_date) is not
+// taken yet, setting their IDs, and returns how many were inserted.
+func (r *CheckinRepository) InsertMissing(ctx context.Context, records []models.HabitCheckin) (int64, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	var n int64
+	for i := range records {
+		if _, ok := r.db.checkinOn(records[i].HabitID, records[i].CheckinDate); ok {
+			continue
+		}
+		r.db.insertCheckin(&records[i])
+		n++
+	}
+	return n, nil
+}
+
+func (r *CheckinRepository) TotalsByHabit(ctx context.Context, habitID uint64) (repository.CheckinTotals, error) {
+	var totals repository.CheckinTotals
+	for _, c := range r.list(func(c models.HabitCheckin) bool { return c.HabitID == habitID }) {
+		totals.Count += int64(c.Count)
+		totals.Quantity += c.Quantity
+	}
+	return totals, nil
+}
+
+func (r *CheckinRepository) CountDaysByWeekday(ctx context.Context, habitID uint64, t repository.DayThreshold, from, to time.Time) ([7]int64, error) {
+	var out [7]int64
+	for _, c := range r.list(func(c models.HabitCheckin) bool {
+		return c.HabitID == habitID && between(c.CheckinDate, from, to) && t.Matches(c)
+	}) {
+		out[c.CheckinDate.Weekday()]++
+	}
+	return out, nil
+}
+
+func (r *CheckinRepository) CountDaysByRanges(ctx context.Context, habitID uint64, t repository.DayThreshold, bounds []time.Time) ([]int64, error) {
+	if len(bounds) < 2 {
+		return nil, nil
+	}
+	out := make([]int64, len(bounds)-1)
+	for _, c := range r.list(func(c models.HabitCheckin) bool { return c.HabitID == habitID && t.Matches(c) }) {
+		day := dayKey(c.CheckinDate)
+		for i := range out {
+			if dayKey(bounds[i]) <= day && day < dayKey(bounds[i+1]) {
+				out[i]++
+			}
+		}
+	}
+	return out, nil
+}
+
+// SumRatiosByDay sums the completion ratios, each capped at 1, of the
+// user's build habits per day in [from, to].
+func (r *CheckinRepository) SumRatiosByDay(ctx context.Context, userID uint64, habitID *uint64, from, to time.Time) ([]repository.DayScore, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	records := rows(r.db.checkins, func(c models.HabitCheckin) bool {
+		h, ok := r.db.habits[c.HabitID]
+		return ok && live(h) && h.Polarity == models.HabitPolarityBuild &&
+			c.UserID == userID && (habitID == nil || c.HabitID == *habitID) && between(c.CheckinDate, from, to)
+	})
+	sort.SliceStable(records, func(i, j int) bool { return dayKey(records[i].CheckinDate) < dayKey(records[j].CheckinDate) })
+	var scores []repository.DayScore
+	for _, c := range records {
+		ratio := completionRatio(r.db.habits[c.HabitID], c)
+		if n := len(scores); n > 0 && dayKey(scores[n-1].Day) == dayKey(c.CheckinDate) {
+			scores[n-1].Score += ratio
+			continue
+		}
+		scores = append(scores, repository.DayScore{Day: c.CheckinDate, Score: ratio})
+	}
+	return scores, nil
+}
+
+// SumCountByBucket sums the check-in counts of build habits in [from, to)
+// per bucket of unit.
+func (r *CheckinRepository) SumCountByBucket(ctx context.Context, userID uint64, unit string, from, to time.Time) ([]repository.BucketSum, error) {
+	if err := checkUnit(unit); err != nil {
+		return nil, err
+	}
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	records := rows(r.db.checkins, func(c models.HabitCheckin) bool {
+		day := dayKey(c.CheckinDate)
+		h, ok := r.db.habits[c.HabitID]
+		return c.UserID == userID && dayKey(from) <= day && day < dayKey(to) &&
+			!(ok && h.Polarity == models.HabitPolarityQuit)
+	})
+	sums := map[time.Time]int64{}
+	for _, c := range records {
+		sums[truncateWall(c.CheckinDate, unit)] += int64(c.Count)
+	}
+	return bucketSums(sums), nil
+}
+
+func (r *CheckinRepository) list(keep func(models.HabitCheckin) bool) []models.HabitCheckin {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	return rows(r.db.checkins, keep)
+}
+
+func (r *CheckinRepository) update(id uint64, fn func(*models.HabitCheckin)) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	if c, ok := r.db.checkins[id]; ok {
+		fn(&c)
+		r.db.checkins[id] = c
+	}
+}
+
+// checkinOn finds the record of a habit on a day; callers hold mu.
+func (db *DB) checkinOn(habitID uint64, day time.Time) (models.HabitCheckin, bool) {
+	for _, c := range db.checkins {
+		if c.HabitID == habitID && dayKey(c.CheckinDate) == dayKey(day) {
+			return c, true
+		}
+	}
+	return models.HabitCheckin{}, false
+}
+
+// insertCheckin stores c under a new ID; callers hold mu.
+func (db *DB) insertCheckin(c *models.HabitCheckin) {
+	c.ID = db.nextID("habit_checkins")
+	stamp(&c.CreatedAt)
+	row := *c
+	if c.Mood != nil {
+		mood := *c.Mood
+		row.Mood = &mood
+	}
+	db.checkins[c.ID] = row
+}
+
+func completionRatio(h models.Habit, c models.HabitCheckin) float64 {
+	if h.Kind == models.HabitKindMeasurable {
+		if h.TargetQuantity > 0 && c.Quantity < h.TargetQuantity {
+			return c.Quantity / h.TargetQuantity
+		}
+		return 1
+	}
+	if h.TargetTimes > 0 && c.Count < h.TargetTimes {
+		return float64(c.Count) / float64(h.TargetTimes)
+	}
+	return 1
+}
+
+// between is the SQL BETWEEN on a date column.
+func between(day, start, end time.Time) bool {
+	return dayKey(start) <= dayKey(day) && dayKey(day) <= dayKey(end)
+}
+
+func sortByDateDesc(records []models.HabitCheckin) {
+	sort.SliceStable(records, func(i, j int) bool { return dayKey(records[i].CheckinDate) > dayKey(records[j].CheckinDate) })
+}
+
+func sumCount(records []models.HabitCheckin) int64 {
+	var total int64
+	for _, c := range records {
+		total += int64(c.Count)
+	}
+	return total
+}
+
+// eachBatch feeds items to fn batchSize at a time; fn is not called when
+// there are no items, as with gorm's FindInBatches.
+func eachBatch[T any](items []T, batchSize int, fn func([]T) error) error {
+	for len(items) > 0 {
+		n := batchSize
+		if n <= 0 || n > len(items) {
+			n = len(items)
+		}
+		if err := fn(items[:n]); err != nil {
+			return err
+		}
+		items = items[n:]
+	}
+	return nil
+}
+
+func checkUnit(unit string) error {
+	switch unit {
+	case repository.BucketDay, repository.BucketWeek, repository.BucketMonth:
+		return nil
+	}
+	return fmt.Errorf("unsupported bucket unit %q", unit)
+}
+
+// truncateWall is date_trunc on the wall clock of t; weeks start on Monday.
+func truncateWall(t time.Time, unit string) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	switch unit {
+	case repository.BucketWeek:
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	case repository.BucketMonth:
+		return day.AddDate(0, 0, 1-day.Day())
+	}
+	return day
+}
+
+func bucketSums(sums map[time.Time]int64) []repository.BucketSum {
+	out := make([]repository.BucketSum, 0, len(sums))
+	for start, total := range sums {
+		out = append(out, repository.BucketSum{Start: start, Total: total})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Start.Before(out[j].Start) })
+	return out
+}
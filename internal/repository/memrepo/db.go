@@ -0,0 +1,143 @@
+// Package memrepo implements the repository interfaces in memory, so that
+// the services can be unit-tested without a database.
+//
+// All repositories created from one DB share its tables, the way the gorm
+// repositories share a connection:
+//
+//	db := memrepo.New()
+//	users := memrepo.NewUserRepository(db)
+//	habits := memrepo.NewHabitRepository(db)
+//
+// The fakes follow the semantics of the SQL they replace: missing rows are
+// gorm.ErrRecordNotFound, unique constraints fail with gorm.ErrDuplicatedKey,
+// soft-deleted habits are hidden unless a method is unscoped, and date
+// columns compare by calendar day while timestamps compare as instants.
+package memrepo
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"habit-tracker/internal/models"
+)
+
+// DB holds the tables. It is safe for concurrent use.
+type DB struct {
+	mu  sync.Mutex
+	seq map[string]uint64
+
+	users            map[uint64]models.User
+	habits           map[uint64]models.Habit
+	checkins         map[uint64]models.HabitCheckin
+	pointsLog        map[uint64]models.UserPointsLog
+	achievements     map[uint64]models.Achievement
+	userAchievements map[uint64]models.UserAchievement
+	categories       map[uint64]models.HabitCategory
+	tags             map[uint64]models.HabitTag
+	tagLinks         []models.HabitTagLink
+	freezes          map[uint64]models.StreakFreeze
+	vacations        map[uint64]models.Vacation
+	reminders        map[uint64]models.HabitReminder
+	notifications    map[uint64]models.Notification
+	endpoints        map[uint64]models.WebhookEndpoint
+	deliveries       map[uint64]models.WebhookDelivery
+	attempts         map[uint64]models.WebhookAttempt
+	adjustments      map[uint64]models.LedgerAdjustment
+}
+
+// New returns an empty database.
+func New() *DB {
+	return &DB{
+		seq:              map[string]uint64{},
+		users:            map[uint64]models.User{},
+		habits:           map[uint64]models.Habit{},
+		checkins:         map[uint64]models.HabitCheckin{},
+		pointsLog:        map[uint64]models.UserPointsLog{},
+		achievements:     map[uint64]models.Achievement{},
+		userAchievements: map[uint64]models.UserAchievement{},
+		categories:       map[uint64]models.HabitCategory{},
+		tags:             map[uint64]models.HabitTag{},
+		freezes:          map[uint64]models.StreakFreeze{},
+		vacations:        map[uint64]models.Vacation{},
+		reminders:        map[uint64]models.HabitReminder{},
+		notifications:    map[uint64]models.Notification{},
+		endpoints:        map[uint64]models.WebhookEndpoint{},
+		deliveries:       map[uint64]models.WebhookDelivery{},
+		attempts:         map[uint64]models.WebhookAttempt{},
+		adjustments:      map[uint64]models.LedgerAdjustment{},
+	}
+}
+
+// nextID is the auto-increment of table; callers hold mu.
+func (db *DB) nextID(table string) uint64 {
+	db.seq[table]++
+	return db.seq[table]
+}
+
+// rows returns the rows of a table that keep accepts, in id order.
+func rows[T any](table map[uint64]T, keep func(T) bool) []T {
+	ids := make([]uint64, 0, len(table))
+	for id, row := range table {
+		if keep == nil || keep(row) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	out := make([]T, 0, len(ids))
+	for _, id := range ids {
+		out = append(out, table[id])
+	}
+	return out
+}
+
+// limit applies a SQL LIMIT; a negative n means no limit.
+func limit[T any](items []T, n int) []T {
+	if n >= 0 && len(items) > n {
+		return items[:n]
+	}
+	return items
+}
+
+// dayKey maps a date to yyyymmdd, which is how date columns compare.
+func dayKey(t time.Time) int {
+	y, m, d := t.Date()
+	return y*10000 + int(m)*100 + d
+}
+
+// copyTime returns a pointer to a copy of *t, so stored rows never alias
+// values owned by the caller.
+func copyTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	v := *t
+	return &v
+}
+
+func copyID(id *uint64) *uint64 {
+	if id == nil {
+		return nil
+	}
+	v := *id
+	return &v
+}
+
+func copyString(s *string) *string {
+	if s == nil {
+		return nil
+	}
+	v := *s
+	return &v
+}
+
+// stamp sets a zero CreatedAt to now, as gorm does on insert.
+func stamp(createdAt *time.Time) {
+	if createdAt.IsZero() {
+		*createdAt = time.Now()
+	}
+}
+
+func sortIDs(ids []uint64) {
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+}
@@ -0,0 +1,405 @@
+package memrepo
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+
+	"habit-tracker/internal/models"
+	"habit-tracker/internal/repository"
+)
+
+type HabitRepository struct {
+	db *DB
+}
+
+func NewHabitRepository(db *DB) *HabitRepository {
+	return &HabitRepository{db: db}
+}
+
+var _ repository.HabitStore = (*HabitRepository)(nil)
+
+func live(h models.Habit) bool {
+	return !h.DeletedAt.Valid
+}
+
+func tracked(h models.Habit) bool {
+	return live(h) && h.IsActive && h.ArchivedAt == nil
+}
+
+func (r *HabitRepository) ListByUser(ctx context.Context, userID uint64) ([]models.Habit, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	habits := rows(r.db.habits, func(h models.Habit) bool { return live(h) && h.UserID == userID })
+	sort.SliceStable(habits, func(i, j int) bool { return dayKey(habits[i].StartDate) < dayKey(habits[j].StartDate) })
+	return habits, nil
+}
+
+func (r *HabitRepository) ListFiltered(ctx context.Context, userID uint64, f repository.HabitFilter) ([]models.Habit, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	habits := rows(r.db.habits, func(h models.Habit) bool {
+		switch {
+		case !live(h) || h.UserID != userID:
+			return false
+		case f.IsActive != nil && h.IsActive != *f.IsActive:
+			return false
+		case f.Archived != nil && (h.ArchivedAt != nil) != *f.Archived:
+			return false
+		case f.CategoryID != nil && (h.CategoryID == nil || *h.CategoryID != *f.CategoryID):
+			return false
+		}
+		return true
+	})
+	if f.Tag != "" {
+		kept := habits[:0]
+		for _, h := range habits {
+			for _, tag := range r.db.tagsOf(h.ID) {
+				if tag.UserID == userID && tag.Name == f.Tag {
+					kept = append(kept, h)
+					break
+				}
+			}
+		}
+		habits = kept
+	}
+
+	var less func(a, b models.Habit) bool
+	switch f.Sort {
+	case repository.HabitSortName:
+		less = func(a, b models.Habit) bool { return a.Name < b.Name }
+	case repository.HabitSortStartDate:
+		less = func(a, b models.Habit) bool { return dayKey(a.StartDate) < dayKey(b.StartDate) }
+	case repository.HabitSortCreated:
+		less = func(a, b models.Habit) bool { return false }
+	default:
+		less = func(a, b models.Habit) bool {
+			if a.SortOrder != b.SortOrder {
+				return a.SortOrder < b.SortOrder
+			}
+			return dayKey(a.StartDate) < dayKey(b.StartDate)
+		}
+	}
+	sort.SliceStable(habits, func(i, j int) bool { return less(habits[i], habits[j]) })
+	for i := range habits {
+		habits[i].Tags = r.db.tagsOf(habits[i].ID)
+	}
+	return habits, nil
+}
+
+func (r *HabitRepository) ListTrackedByPolarity(ctx context.Context, polarity string) ([]models.Habit, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	return rows(r.db.habits, func(h models.Habit) bool { return tracked(h) && h.Polarity == polarity }), nil
+}
+
+func (r *HabitRepository) ListAll(ctx context.Context) ([]models.Habit, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	return rows(r.db.habits, live), nil
+}
+
+func (r *HabitRepository) ListMissingStreakState(ctx context.Context) ([]models.Habit, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	hasCheckins := map[uint64]bool{}
+	for _, c := range r.db.checkins {
+		hasCheckins[c.HabitID] = true
+	}
+	return rows(r.db.habits, func(h models.Habit) bool {
+		return live(h) && h.LastCompletedDate == nil && (h.LastCleanDate != nil || hasCheckins[h.ID])
+	}), nil
+}
+
+func (r *HabitRepository) ListTrackedByUser(ctx context.Context, userID uint64, polarity string) ([]models.Habit, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	return rows(r.db.habits, func(h models.Habit) bool {
+		return tracked(h) && h.UserID == userID && h.Polarity == polarity
+	}), nil
+}
+
+func (r *HabitRepository) ListByUserWithDeleted(ctx context.Context, userID uint64) ([]models.Habit, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	habits := rows(r.db.habits, func(h models.Habit) bool { return h.UserID == userID })
+	for i := range habits {
+		habits[i].Tags = r.db.tagsOf(habits[i].ID)
+	}
+	return habits, nil
+}
+
+func (r *HabitRepository) ListDeletedByUser(ctx context.Context, userID uint64) ([]models.Habit, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	habits := rows(r.db.habits, func(h models.Habit) bool { return !live(h) && h.UserID == userID })
+	sort.SliceStable(habits, func(i, j int) bool { return habits[i].DeletedAt.Time.After(habits[j].DeletedAt.Time) })
+	return habits, nil
+}
+
+func (r *HabitRepository) ListDeletedBefore(ctx context.Context, cutoff time.Time) ([]models.Habit, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	return rows(r.db.habits, func(h models.Habit) bool { return !live(h) && h.DeletedAt.Time.Before(cutoff) }), nil
+}
+
+func (r *HabitRepository) GetByID(ctx context.Context, id uint64) (*models.Habit, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	h, ok := r.db.habits[id]
+	if !ok || !live(h) {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &h, nil
+}
+
+func (r *HabitRepository) GetByIDWithTags(ctx context.Context, id uint64) (*models.Habit, error) {
+	h, err := r.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	h.Tags = r.db.tagsOf(id)
+	return h, nil
+}
+
+func (r *HabitRepository) GetByIDUnscoped(ctx context.Context, id uint64) (*models.Habit, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	h, ok := r.db.habits[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &h, nil
+}
+
+// Create inserts the habit at the end of the user's manual order. Like gorm,
+// it stores the column default for zero values of Kind, Polarity,
+// TargetTimes and IsActive, and writes them back to habit.
+func (r *HabitRepository) Create(ctx context.Context, habit *models.Habit) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	maxOrder := 0
+	for _, h := range r.db.habits {
+		if h.UserID == habit.UserID && h.SortOrder > maxOrder {
+			maxOrder = h.SortOrder
+		}
+	}
+	if habit.Kind == "" {
+		habit.Kind = models.HabitKindCount
+	}
+	if habit.Polarity == "" {
+		habit.Polarity = models.HabitPolarityBuild
+	}
+	if habit.TargetTimes == 0 {
+		habit.TargetTimes = 1
+	}
+	habit.IsActive = true
+	habit.SortOrder = maxOrder + 1
+	habit.ID = r.db.nextID("habits")
+	r.db.habits[habit.ID] = storedHabit(*habit)
+	return nil
+}
+
+// Update saves the editable columns; streak state is only written by UpdateStreakState.
+func (r *HabitRepository) Update(ctx context.Context, habit *models.Habit) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	old, ok := r.db.habits[habit.ID]
+	if !ok || !live(old) {
+		return nil
+	}
+	row := storedHabit(*habit)
+	row.CurrentStreak, row.LongestStreak, row.LastCompletedDate = old.CurrentStreak, old.LongestStreak, old.LastCompletedDate
+	r.db.habits[habit.ID] = row
+	return nil
+}
+
+func (r *HabitRepository) Reorder(ctx context.Context, userID uint64, habitIDs []uint64) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	owned := rows(r.db.habits, func(h models.Habit) bool {
+		if !live(h) || h.UserID != userID {
+			return false
+		}
+		for _, id := range habitIDs {
+			if id == h.ID {
+				return true
+			}
+		}
+		return false
+	})
+	if len(owned) != len(habitIDs) {
+		return gorm.ErrRecordNotFound
+	}
+	for i, id := range habitIDs {
+		h := r.db.habits[id]
+		h.SortOrder = i + 1
+		r.db.habits[id] = h
+	}
+	return nil
+}
+
+func (r *HabitRepository) UpdateStatus(ctx context.Context, habitID uint64, isActive bool) error {
+	r.update(habitID, func(h *models.Habit) { h.IsActive = isActive })
+	return nil
+}
+
+func (r *HabitRepository) SetArchivedAt(ctx context.Context, habitID uint64, at *time.Time) error {
+	r.update(habitID, func(h *models.Habit) { h.ArchivedAt = copyTime(at) })
+	return nil
+}
+
+func (r *HabitRepository) UpdateLastCleanDate(ctx context.Context, habitID uint64, day time.Time) error {
+	r.update(habitID, func(h *models.Habit) {
+		if h.LastCleanDate == nil || h.LastCleanDate.Before(day) {
+			h.LastCleanDate = &day
+		}
+	})
+	return nil
+}
+
+func (r *HabitRepository) ClaimCleanDay(ctx context.Context, habitID, userID uint64, day time.Time) (bool, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	h, ok := r.db.habits[habitID]
+	if !ok || !live(h) || (h.LastCleanDate != nil && !h.LastCleanDate.Before(day)) {
+		return false, nil
+	}
+	h.LastCleanDate = &day
+	r.db.habits[habitID] = h
+	if u, ok := r.db.users[userID]; ok {
+		u.TotalCheckins++
+		r.db.users[userID] = u
+	}
+	return true, nil
+}
+
+func (r *HabitRepository) UpdateStreakState(ctx context.Context, habitID uint64, current, longest int, lastCompleted *time.Time) error {
+	r.update(habitID, func(h *models.Habit) {
+		h.CurrentStreak, h.LongestStreak, h.LastCompletedDate = current, longest, copyTime(lastCompleted)
+	})
+	return nil
+}
+
+func (r *HabitRepository) SoftDelete(ctx context.Context, habitID uint64) error {
+	r.update(habitID, func(h *models.Habit) { h.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true} })
+	return nil
+}
+
+func (r *HabitRepository) Restore(ctx context.Context, habitID uint64) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	if h, ok := r.db.habits[habitID]; ok {
+		h.DeletedAt = gorm.DeletedAt{}
+		r.db.habits[habitID] = h
+	}
+	return nil
+}
+
+// HardDelete removes the habit and its check-ins and returns the photo keys
+// of the removed check-ins. With revokePoints the related points log rows
+// are deleted and the user's counters reduced; otherwise they are detached.
+func (r *HabitRepository) HardDelete(ctx context.Context, habit *models.Habit, revokePoints bool) ([]string, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	var photoKeys []string
+	for id, c := range r.db.checkins {
+		if c.HabitID != habit.ID {
+			continue
+		}
+		if c.PhotoKey != "" {
+			photoKeys = append(photoKeys, c.PhotoKey)
+		}
+		delete(r.db.checkins, id)
+	}
+	sort.Strings(photoKeys)
+	r.db.unlinkTags(func(l models.HabitTagLink) bool { return l.HabitID == habit.ID })
+	for id, rem := range r.db.reminders {
+		if rem.HabitID == habit.ID {
+			delete(r.db.reminders, id)
+		}
+	}
+
+	var points, checkins int64
+	for id, l := range r.db.pointsLog {
+		if l.RelatedHabitID == nil || *l.RelatedHabitID != habit.ID {
+			continue
+		}
+		if revokePoints {
+			points += int64(l.ChangeAmount)
+			checkins += checkinsFromLog(l)
+			delete(r.db.pointsLog, id)
+		} else {
+			l.RelatedHabitID = nil
+			r.db.pointsLog[id] = l
+		}
+	}
+	if u, ok := r.db.users[habit.UserID]; ok && revokePoints {
+		u.Points -= points
+		u.TotalCheckins -= checkins
+		r.db.users[u.ID] = u
+	}
+	delete(r.db.habits, habit.ID)
+	return photoKeys, nil
+}
+
+// update changes a habit that is not soft-deleted.
+func (r *HabitRepository) update(habitID uint64, fn func(*models.Habit)) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	if h, ok := r.db.habits[habitID]; ok && live(h) {
+		fn(&h)
+		r.db.habits[habitID] = h
+	}
+}
+
+// storedHabit is the row saved for h: tags live in their own table.
+func storedHabit(h models.Habit) models.Habit {
+	h.Tags = nil
+	h.LastCleanDate = copyTime(h.LastCleanDate)
+	h.LastCompletedDate = copyTime(h.LastCompletedDate)
+	h.ArchivedAt = copyTime(h.ArchivedAt)
+	h.CategoryID = copyID(h.CategoryID)
+	return h
+}
+
+// tagsOf returns the tags linked to a habit in id order; callers hold mu.
+func (db *DB) tagsOf(habitID uint64) []models.HabitTag {
+	var tags []models.HabitTag
+	for _, l := range db.tagLinks {
+		if l.HabitID == habitID {
+			if tag, ok := db.tags[l.TagID]; ok {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i].ID < tags[j].ID })
+	return tags
+}
+
+// unlinkTags removes the tag links matching drop; callers hold mu.
+func (db *DB) unlinkTags(drop func(models.HabitTagLink) bool) {
+	kept := db.tagLinks[:0]
+	for _, l := range db.tagLinks {
+		if !drop(l) {
+			kept = append(kept, l)
+		}
+	}
+	db.tagLinks = kept
+}
+
+// checkinsFromLog is how many days a log row adds to users.total_checkins,
+// as in the SQL expression of the same name.
+func checkinsFromLog(l models.UserPointsLog) int64 {
+	switch l.Reason {
+	case "checkin", "clean_day":
+		return 1
+	case "import":
+		return int64(l.ChangeAmount)
+	}
+	return 0
+}
@@ -0,0 +1,58 @@
+package memrepo
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"habit-tracker/internal/models"
+	"habit-tracker/internal/repository"
+)
+
+type LedgerRepository struct {
+	db *DB
+}
+
+func NewLedgerRepository(db *DB) *LedgerRepository {
+	return &LedgerRepository{db: db}
+}
+
+var _ repository.LedgerStore = (*LedgerRepository)(nil)
+
+// Correct applies c and records a LedgerAdjustment for every changed
+// counter. It returns false when the counters no longer hold the Old values.
+func (r *LedgerRepository) Correct(ctx context.Context, c repository.CounterCorrection, source string, at time.Time) (bool, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	user, ok := r.db.users[c.UserID]
+	if !ok || user.Points != c.OldPoints || user.TotalCheckins != c.OldCheckins {
+		return false, nil
+	}
+	user.Points, user.TotalCheckins = c.NewPoints, c.NewCheckins
+	r.db.users[c.UserID] = user
+	if c.OldPoints != c.NewPoints {
+		r.audit(c.UserID, models.LedgerCounterPoints, c.OldPoints, c.NewPoints, source, at)
+	}
+	if c.OldCheckins != c.NewCheckins {
+		r.audit(c.UserID, models.LedgerCounterCheckins, c.OldCheckins, c.NewCheckins, source, at)
+	}
+	return true, nil
+}
+
+// ListAdjustments returns the newest adjustments first; userID 0 means all users.
+func (r *LedgerRepository) ListAdjustments(ctx context.Context, userID uint64, n int) ([]models.LedgerAdjustment, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	items := rows(r.db.adjustments, func(a models.LedgerAdjustment) bool { return userID == 0 || a.UserID == userID })
+	sort.Slice(items, func(i, j int) bool { return items[i].ID > items[j].ID })
+	return limit(items, n), nil
+}
+
+// audit inserts one adjustment row; callers hold mu.
+func (r *LedgerRepository) audit(userID uint64, counter string, oldValue, newValue int64, source string, at time.Time) {
+	id := r.db.nextID("ledger_adjustments")
+	r.db.adjustments[id] = models.LedgerAdjustment{
+		ID: id, UserID: userID, Counter: counter,
+		OldValue: oldValue, NewValue: newValue, Source: source, CreatedAt: at,
+	}
+}
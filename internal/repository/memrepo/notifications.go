@@ -0,0 +1,97 @@
+package memrepo
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"habit-tracker/internal/models"
+	"habit-tracker/internal/repository"
+)
+
+type NotificationRepository struct {
+	db *DB
+}
+
+func NewNotificationRepository(db *DB) *NotificationRepository {
+	return &NotificationRepository{db: db}
+}
+
+var _ repository.NotificationStore = (*NotificationRepository)(nil)
+
+func (r *NotificationRepository) Create(ctx context.Context, n *models.Notification) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	n.ID = r.db.nextID("notifications")
+	stamp(&n.CreatedAt)
+	row := *n
+	row.HabitID = copyID(n.HabitID)
+	row.ReadAt = copyTime(n.ReadAt)
+	r.db.notifications[n.ID] = row
+	return nil
+}
+
+// ListByUser returns the user's notifications newest first.
+func (r *NotificationRepository) ListByUser(ctx context.Context, userID uint64, unreadOnly bool, beforeID uint64, n int) ([]models.Notification, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	items := rows(r.db.notifications, func(item models.Notification) bool {
+		return item.UserID == userID && (!unreadOnly || item.ReadAt == nil) && (beforeID == 0 || item.ID < beforeID)
+	})
+	sort.Slice(items, func(i, j int) bool { return items[i].ID > items[j].ID })
+	return limit(items, n), nil
+}
+
+func (r *NotificationRepository) CountUnread(ctx context.Context, userID uint64) (int64, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	return int64(len(rows(r.db.notifications, func(item models.Notification) bool {
+		return item.UserID == userID && item.ReadAt == nil
+	}))), nil
+}
+
+// MarkRead marks one of the user's notifications read; it reports false when
+// the user has no such notification.
+func (r *NotificationRepository) MarkRead(ctx context.Context, userID, id uint64, at time.Time) (bool, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	item, ok := r.db.notifications[id]
+	if !ok || item.UserID != userID {
+		return false, nil
+	}
+	if item.ReadAt == nil {
+		item.ReadAt = &at
+		r.db.notifications[id] = item
+	}
+	return true, nil
+}
+
+func (r *NotificationRepository) MarkAllRead(ctx context.Context, userID uint64, at time.Time) (int64, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	var n int64
+	for id, item := range r.db.notifications {
+		if item.UserID == userID && item.ReadAt == nil {
+			readAt := at
+			item.ReadAt = &readAt
+			r.db.notifications[id] = item
+			n++
+		}
+	}
+	return n, nil
+}
+
+// Purge deletes read notifications created before readCutoff and all
+// notifications created before cutoff.
+func (r *NotificationRepository) Purge(ctx context.Context, readCutoff, cutoff time.Time) (int64, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	var n int64
+	for id, item := range r.db.notifications {
+		if (item.ReadAt != nil && item.CreatedAt.Before(readCutoff)) || item.CreatedAt.Before(cutoff) {
+			delete(r.db.notifications, id)
+			n++
+		}
+	}
+	return n, nil
+}
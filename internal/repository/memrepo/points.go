@@ -0,0 +1,121 @@
+package memrepo
+
+import (
+	"context"
+	"time"
+
+	"habit-tracker/internal/models"
+	"habit-tracker/internal/repository"
+)
+
+type PointsRepository struct {
+	db *DB
+}
+
+func NewPointsRepository(db *DB) *PointsRepository {
+	return &PointsRepository{db: db}
+}
+
+var _ repository.PointsStore = (*PointsRepository)(nil)
+
+func (r *PointsRepository) AddLog(ctx context.Context, log *models.UserPointsLog) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	log.ID = r.db.nextID("user_points_log")
+	stamp(&log.CreatedAt)
+	row := *log
+	row.RelatedHabitID = copyID(log.RelatedHabitID)
+	r.db.pointsLog[log.ID] = row
+	return nil
+}
+
+func (r *PointsRepository) SumByUserAndRange(ctx context.Context, userID uint64, start, end time.Time) (int64, error) {
+	return sumChange(r.list(func(l models.UserPointsLog) bool {
+		return l.UserID == userID && !l.CreatedAt.Before(start) && !l.CreatedAt.After(end)
+	})), nil
+}
+
+func (r *PointsRepository) ListUsersWithSumInRange(ctx context.Context, start, end time.Time, lo, hi int64, excludeUserID uint64) ([]uint64, error) {
+	sums := map[uint64]int64{}
+	for _, l := range r.list(func(l models.UserPointsLog) bool {
+		return l.UserID != excludeUserID && !l.CreatedAt.Before(start) && !l.CreatedAt.After(end)
+	}) {
+		sums[l.UserID] += int64(l.ChangeAmount)
+	}
+	var ids []uint64
+	for _, u := range r.users() {
+		if sum, ok := sums[u]; ok && sum >= lo && sum < hi {
+			ids = append(ids, u)
+		}
+	}
+	return ids, nil
+}
+
+func (r *PointsRepository) EachByUser(ctx context.Context, userID uint64, batchSize int, fn func([]models.UserPointsLog) error) error {
+	return eachBatch(r.list(func(l models.UserPointsLog) bool { return l.UserID == userID }), batchSize, fn)
+}
+
+// TotalsByUser sums the log per user; userID 0 means every user with rows.
+func (r *PointsRepository) TotalsByUser(ctx context.Context, userID uint64) (map[uint64]repository.LedgerTotals, error) {
+	totals := map[uint64]repository.LedgerTotals{}
+	for _, l := range r.list(func(l models.UserPointsLog) bool { return userID == 0 || l.UserID == userID }) {
+		t := totals[l.UserID]
+		t.UserID = l.UserID
+		t.Points += int64(l.ChangeAmount)
+		t.Checkins += checkinsFromLog(l)
+		totals[l.UserID] = t
+	}
+	return totals, nil
+}
+
+// SumByBucket sums the changes in [from, to) per bucket of the calendar in tz.
+func (r *PointsRepository) SumByBucket(ctx context.Context, userID uint64, unit, tz string, from, to time.Time) ([]repository.BucketSum, error) {
+	if err := checkUnit(unit); err != nil {
+		return nil, err
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return nil, err
+	}
+	sums := map[time.Time]int64{}
+	for _, l := range r.list(func(l models.UserPointsLog) bool {
+		return l.UserID == userID && !l.CreatedAt.Before(from) && l.CreatedAt.Before(to)
+	}) {
+		sums[truncateWall(l.CreatedAt.In(loc), unit)] += int64(l.ChangeAmount)
+	}
+	return bucketSums(sums), nil
+}
+
+func (r *PointsRepository) SumBefore(ctx context.Context, userID uint64, before time.Time) (int64, error) {
+	return sumChange(r.list(func(l models.UserPointsLog) bool {
+		return l.UserID == userID && l.CreatedAt.Before(before)
+	})), nil
+}
+
+func (r *PointsRepository) list(keep func(models.UserPointsLog) bool) []models.UserPointsLog {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	return rows(r.db.pointsLog, keep)
+}
+
+// users returns the ids of every user with log rows, ascending.
+func (r *PointsRepository) users() []uint64 {
+	var ids []uint64
+	seen := map[uint64]bool{}
+	for _, l := range r.list(nil) {
+		if !seen[l.UserID] {
+			seen[l.UserID] = true
+			ids = append(ids, l.UserID)
+		}
+	}
+	sortIDs(ids)
+	return ids
+}
+
+func sumChange(logs []models.UserPointsLog) int64 {
+	var total int64
+	for _, l := range logs {
+		total += int64(l.ChangeAmount)
+	}
+	return total
+}
@@ -0,0 +1,83 @@
+package memrepo
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"habit-tracker/internal/models"
+	"habit-tracker/internal/repository"
+)
+
+type ReminderRepository struct {
+	db *DB
+}
+
+func NewReminderRepository(db *DB) *ReminderRepository {
+	return &ReminderRepository{db: db}
+}
+
+var _ repository.ReminderStore = (*ReminderRepository)(nil)
+
+func (r *ReminderRepository) ListByHabit(ctx context.Context, habitID uint64) ([]models.HabitReminder, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	items := rows(r.db.reminders, func(rem models.HabitReminder) bool { return rem.HabitID == habitID })
+	sort.SliceStable(items, func(i, j int) bool { return items[i].MinuteOfDay < items[j].MinuteOfDay })
+	return items, nil
+}
+
+// ListTracked returns the reminders of active, unarchived, non-deleted habits.
+func (r *ReminderRepository) ListTracked(ctx context.Context) ([]models.HabitReminder, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	return rows(r.db.reminders, func(rem models.HabitReminder) bool {
+		h, ok := r.db.habits[rem.HabitID]
+		return ok && tracked(h)
+	}), nil
+}
+
+// ReplaceForHabit sets the habit's reminders to exactly minutes, keeping the
+// LastFiredOn of the ones that stay.
+func (r *ReminderRepository) ReplaceForHabit(ctx context.Context, habit *models.Habit, minutes []int) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	want := make(map[int]bool, len(minutes))
+	for _, m := range minutes {
+		want[m] = true
+	}
+	have := map[int]bool{}
+	for id, rem := range r.db.reminders {
+		if rem.HabitID != habit.ID {
+			continue
+		}
+		if !want[rem.MinuteOfDay] {
+			delete(r.db.reminders, id)
+			continue
+		}
+		have[rem.MinuteOfDay] = true
+	}
+	for _, m := range minutes {
+		if have[m] {
+			continue
+		}
+		have[m] = true
+		id := r.db.nextID("habit_reminders")
+		r.db.reminders[id] = models.HabitReminder{ID: id, UserID: habit.UserID, HabitID: habit.ID, MinuteOfDay: m, CreatedAt: time.Now()}
+	}
+	return nil
+}
+
+// MarkFired records that the reminder was handled on day. It reports false
+// when it had already been handled that day.
+func (r *ReminderRepository) MarkFired(ctx context.Context, id uint64, day time.Time) (bool, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	rem, ok := r.db.reminders[id]
+	if !ok || (rem.LastFiredOn != nil && dayKey(*rem.LastFiredOn) >= dayKey(day)) {
+		return false, nil
+	}
+	rem.LastFiredOn = &day
+	r.db.reminders[id] = rem
+	return true, nil
+}
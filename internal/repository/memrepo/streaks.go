@@ -0,0 +1,209 @@
+package memrepo
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+
+	"habit-tracker/internal/models"
+	"habit-tracker/internal/repository"
+)
+
+type StreakFreezeRepository struct {
+	db *DB
+}
+
+func NewStreakFreezeRepository(db *DB) *StreakFreezeRepository {
+	return &StreakFreezeRepository{db: db}
+}
+
+var _ repository.StreakFreezeStore = (*StreakFreezeRepository)(nil)
+
+func (r *StreakFreezeRepository) CountAvailable(ctx context.Context, userID uint64) (int64, error) {
+	return int64(len(r.list(func(f models.StreakFreeze) bool { return f.UserID == userID && f.UsedOn == nil }))), nil
+}
+
+func (r *StreakFreezeRepository) Create(ctx context.Context, freeze *models.StreakFreeze) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	return r.db.insertFreeze(freeze)
+}
+
+// Purchase deducts price from the user's points, logs the spend and adds a
+// freeze. It reports false when the balance is too low.
+func (r *StreakFreezeRepository) Purchase(ctx context.Context, userID uint64, price int64) (*models.StreakFreeze, bool, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	user, ok := r.db.users[userID]
+	if !ok || user.Points < price {
+		return nil, false, nil
+	}
+	user.Points -= price
+	r.db.users[userID] = user
+	now := time.Now()
+	logID := r.db.nextID("user_points_log")
+	r.db.pointsLog[logID] = models.UserPointsLog{
+		ID:           logID,
+		UserID:       userID,
+		ChangeAmount: int(-price),
+		Reason:       "streak_freeze",
+		CreatedAt:    now,
+	}
+	freeze := &models.StreakFreeze{UserID: userID, Source: models.StreakFreezePurchased, CreatedAt: now}
+	if err := r.db.insertFreeze(freeze); err != nil {
+		return nil, false, err
+	}
+	return freeze, true, nil
+}
+
+// Consume marks the user's oldest unused freeze as covering day for habitID.
+// It reports false when the user has no freeze left.
+func (r *StreakFreezeRepository) Consume(ctx context.Context, userID, habitID uint64, day time.Time) (bool, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	if _, taken := r.db.freezeOn(habitID, day); taken {
+		return false, gorm.ErrDuplicatedKey
+	}
+	unused := rows(r.db.freezes, func(f models.StreakFreeze) bool { return f.UserID == userID && f.UsedOn == nil })
+	if len(unused) == 0 {
+		return false, nil
+	}
+	freeze := unused[0]
+	freeze.HabitID = &habitID
+	freeze.UsedOn = &day
+	r.db.freezes[freeze.ID] = freeze
+	return true, nil
+}
+
+func (r *StreakFreezeRepository) ListUsedDays(ctx context.Context, habitID uint64) ([]time.Time, error) {
+	used := r.used(func(f models.StreakFreeze) bool { return f.HabitID != nil && *f.HabitID == habitID })
+	var days []time.Time
+	for i := len(used) - 1; i >= 0; i-- {
+		days = append(days, *used[i].UsedOn)
+	}
+	return days, nil
+}
+
+func (r *StreakFreezeRepository) ListUsedByUser(ctx context.Context, userID uint64, n int) ([]models.StreakFreeze, error) {
+	return limit(r.used(func(f models.StreakFreeze) bool { return f.UserID == userID }), n), nil
+}
+
+func (r *StreakFreezeRepository) ListUserIDsWithAvailable(ctx context.Context) ([]uint64, error) {
+	var ids []uint64
+	seen := map[uint64]bool{}
+	for _, f := range r.list(func(f models.StreakFreeze) bool { return f.UsedOn == nil }) {
+		if !seen[f.UserID] {
+			seen[f.UserID] = true
+			ids = append(ids, f.UserID)
+		}
+	}
+	sortIDs(ids)
+	return ids, nil
+}
+
+func (r *StreakFreezeRepository) list(keep func(models.StreakFreeze) bool) []models.StreakFreeze {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	return rows(r.db.freezes, keep)
+}
+
+// used returns the consumed freezes matching keep, used_on desc.
+func (r *StreakFreezeRepository) used(keep func(models.StreakFreeze) bool) []models.StreakFreeze {
+	items := r.list(func(f models.StreakFreeze) bool { return f.UsedOn != nil && keep(f) })
+	sort.SliceStable(items, func(i, j int) bool { return dayKey(*items[i].UsedOn) > dayKey(*items[j].UsedOn) })
+	return items
+}
+
+// freezeOn finds the freeze covering a day of a habit; callers hold mu.
+func (db *DB) freezeOn(habitID uint64, day time.Time) (models.StreakFreeze, bool) {
+	for _, f := range db.freezes {
+		if f.HabitID != nil && *f.HabitID == habitID && f.UsedOn != nil && dayKey(*f.UsedOn) == dayKey(day) {
+			return f, true
+		}
+	}
+	return models.StreakFreeze{}, false
+}
+
+// insertFreeze enforces the unique (habit_id, used_on); callers hold mu.
+func (db *DB) insertFreeze(freeze *models.StreakFreeze) error {
+	if freeze.HabitID != nil && freeze.UsedOn != nil {
+		if _, taken := db.freezeOn(*freeze.HabitID, *freeze.UsedOn); taken {
+			return gorm.ErrDuplicatedKey
+		}
+	}
+	freeze.ID = db.nextID("streak_freezes")
+	stamp(&freeze.CreatedAt)
+	row := *freeze
+	row.HabitID = copyID(freeze.HabitID)
+	row.UsedOn = copyTime(freeze.UsedOn)
+	db.freezes[freeze.ID] = row
+	return nil
+}
+
+type VacationRepository struct {
+	db *DB
+}
+
+func NewVacationRepository(db *DB) *VacationRepository {
+	return &VacationRepository{db: db}
+}
+
+var _ repository.VacationStore = (*VacationRepository)(nil)
+
+func (r *VacationRepository) ListByUser(ctx context.Context, userID uint64) ([]models.Vacation, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	items := rows(r.db.vacations, func(v models.Vacation) bool { return v.UserID == userID })
+	sort.SliceStable(items, func(i, j int) bool { return dayKey(items[i].StartDate) < dayKey(items[j].StartDate) })
+	return items, nil
+}
+
+func (r *VacationRepository) GetByID(ctx context.Context, id uint64) (*models.Vacation, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	item, ok := r.db.vacations[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &item, nil
+}
+
+// Overlaps reports whether any vacation of the user intersects [start, end].
+func (r *VacationRepository) Overlaps(ctx context.Context, userID uint64, start, end time.Time) (bool, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	for _, v := range r.db.vacations {
+		if v.UserID == userID && dayKey(v.StartDate) <= dayKey(end) && dayKey(v.EndDate) >= dayKey(start) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *VacationRepository) Create(ctx context.Context, item *models.Vacation) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	item.ID = r.db.nextID("vacations")
+	stamp(&item.CreatedAt)
+	r.db.vacations[item.ID] = *item
+	return nil
+}
+
+func (r *VacationRepository) UpdateEndDate(ctx context.Context, id uint64, end time.Time) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	if v, ok := r.db.vacations[id]; ok {
+		v.EndDate = end
+		r.db.vacations[id] = v
+	}
+	return nil
+}
+
+func (r *VacationRepository) Delete(ctx context.Context, id uint64) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	delete(r.db.vacations, id)
+	return nil
+}
@@ -0,0 +1,122 @@
+package memrepo
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+
+	"habit-tracker/internal/models"
+	"habit-tracker/internal/repository"
+)
+
+type UserRepository struct {
+	db *DB
+}
+
+func NewUserRepository(db *DB) *UserRepository {
+	return &UserRepository{db: db}
+}
+
+var _ repository.UserStore = (*UserRepository)(nil)
+
+func (r *UserRepository) GetByUsername(ctx context.Context, username string) (*models.User, error) {
+	return r.find(func(u models.User) bool { return u.Username == username })
+}
+
+func (r *UserRepository) GetByID(ctx context.Context, id uint64) (*models.User, error) {
+	return r.find(func(u models.User) bool { return u.ID == id })
+}
+
+func (r *UserRepository) GetTotalCheckins(ctx context.Context, userID uint64) (int64, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	return r.db.users[userID].TotalCheckins, nil
+}
+
+func (r *UserRepository) Create(ctx context.Context, user *models.User) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	for _, u := range r.db.users {
+		if u.Username == user.Username ||
+			(user.CalendarTokenHash != nil && u.CalendarTokenHash != nil && *u.CalendarTokenHash == *user.CalendarTokenHash) {
+			return gorm.ErrDuplicatedKey
+		}
+	}
+	user.ID = r.db.nextID("users")
+	stamp(&user.CreatedAt)
+	row := *user
+	row.CalendarTokenHash = copyString(user.CalendarTokenHash)
+	r.db.users[user.ID] = row
+	return nil
+}
+
+func (r *UserRepository) ListAll(ctx context.Context) ([]models.User, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	return rows(r.db.users, nil), nil
+}
+
+func (r *UserRepository) UpdatePoints(ctx context.Context, userID uint64, delta int64) error {
+	r.update(userID, func(u *models.User) { u.Points += delta })
+	return nil
+}
+
+func (r *UserRepository) IncrementCheckins(ctx context.Context, userID uint64, delta int64) error {
+	r.update(userID, func(u *models.User) { u.TotalCheckins += delta })
+	return nil
+}
+
+func (r *UserRepository) UpdateTimezone(ctx context.Context, userID uint64, tz string) error {
+	r.update(userID, func(u *models.User) { u.Timezone = tz })
+	return nil
+}
+
+func (r *UserRepository) UpdateReminderWebhookURL(ctx context.Context, userID uint64, url string) error {
+	r.update(userID, func(u *models.User) { u.ReminderWebhookURL = url })
+	return nil
+}
+
+func (r *UserRepository) SetCalendarTokenHash(ctx context.Context, userID uint64, hash *string) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	if hash != nil {
+		for _, u := range r.db.users {
+			if u.ID != userID && u.CalendarTokenHash != nil && *u.CalendarTokenHash == *hash {
+				return gorm.ErrDuplicatedKey
+			}
+		}
+	}
+	if u, ok := r.db.users[userID]; ok {
+		u.CalendarTokenHash = copyString(hash)
+		r.db.users[userID] = u
+	}
+	return nil
+}
+
+func (r *UserRepository) GetByCalendarTokenHash(ctx context.Context, hash string) (*models.User, error) {
+	return r.find(func(u models.User) bool { return u.CalendarTokenHash != nil && *u.CalendarTokenHash == hash })
+}
+
+func (r *UserRepository) UpdatePasswordHash(ctx context.Context, userID uint64, hash string) error {
+	r.update(userID, func(u *models.User) { u.PasswordHash = hash })
+	return nil
+}
+
+func (r *UserRepository) find(match func(models.User) bool) (*models.User, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	found := rows(r.db.users, match)
+	if len(found) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &found[0], nil
+}
+
+func (r *UserRepository) update(userID uint64, fn func(*models.User)) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	if u, ok := r.db.users[userID]; ok {
+		fn(&u)
+		r.db.users[userID] = u
+	}
+}
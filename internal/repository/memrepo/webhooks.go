@@ -0,0 +1,153 @@
+package memrepo
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+
+	"habit-tracker/internal/models"
+	"habit-tracker/internal/repository"
+)
+
+type WebhookRepository struct {
+	db *DB
+}
+
+func NewWebhookRepository(db *DB) *WebhookRepository {
+	return &WebhookRepository{db: db}
+}
+
+var _ repository.WebhookStore = (*WebhookRepository)(nil)
+
+func (r *WebhookRepository) CreateEndpoint(ctx context.Context, ep *models.WebhookEndpoint) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	ep.ID = r.db.nextID("webhook_endpoints")
+	stamp(&ep.CreatedAt)
+	r.db.endpoints[ep.ID] = *ep
+	return nil
+}
+
+func (r *WebhookRepository) ListEndpoints(ctx context.Context, userID uint64) ([]models.WebhookEndpoint, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	return rows(r.db.endpoints, func(ep models.WebhookEndpoint) bool { return ep.UserID == userID }), nil
+}
+
+func (r *WebhookRepository) GetEndpoint(ctx context.Context, id uint64) (*models.WebhookEndpoint, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	ep, ok := r.db.endpoints[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &ep, nil
+}
+
+// DeleteEndpoint removes the endpoint together with its outbox and delivery log.
+func (r *WebhookRepository) DeleteEndpoint(ctx context.Context, id uint64) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	r.db.deleteDeliveries(func(d models.WebhookDelivery) bool { return d.EndpointID == id })
+	delete(r.db.endpoints, id)
+	return nil
+}
+
+// Enqueue inserts the deliveries and sets their IDs in place.
+func (r *WebhookRepository) Enqueue(ctx context.Context, deliveries []models.WebhookDelivery) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	for i := range deliveries {
+		d := &deliveries[i]
+		d.ID = r.db.nextID("webhook_deliveries")
+		stamp(&d.CreatedAt)
+		row := *d
+		row.DeliveredAt = copyTime(d.DeliveredAt)
+		r.db.deliveries[d.ID] = row
+	}
+	return nil
+}
+
+// ClaimDue leases up to n due pending deliveries until now+lease.
+func (r *WebhookRepository) ClaimDue(ctx context.Context, now time.Time, lease time.Duration, n int) ([]models.WebhookDelivery, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	due := rows(r.db.deliveries, func(d models.WebhookDelivery) bool {
+		return d.Status == models.WebhookDeliveryPending && !d.NextAttemptAt.After(now)
+	})
+	sort.SliceStable(due, func(i, j int) bool { return due[i].NextAttemptAt.Before(due[j].NextAttemptAt) })
+	due = limit(due, n)
+	until := now.Add(lease)
+	for i := range due {
+		due[i].NextAttemptAt = until
+		r.db.deliveries[due[i].ID] = due[i]
+	}
+	return due, nil
+}
+
+// Finish stores the outcome of one attempt: the log entry and the delivery's new state.
+func (r *WebhookRepository) Finish(ctx context.Context, d *models.WebhookDelivery, attempt *models.WebhookAttempt) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	attempt.ID = r.db.nextID("webhook_attempts")
+	stamp(&attempt.CreatedAt)
+	r.db.attempts[attempt.ID] = *attempt
+	if row, ok := r.db.deliveries[d.ID]; ok {
+		row.Status = d.Status
+		row.Attempts = d.Attempts
+		row.NextAttemptAt = d.NextAttemptAt
+		row.DeliveredAt = copyTime(d.DeliveredAt)
+		r.db.deliveries[d.ID] = row
+	}
+	return nil
+}
+
+// ListDeliveries returns the endpoint's most recent deliveries first.
+func (r *WebhookRepository) ListDeliveries(ctx context.Context, endpointID uint64, n int) ([]models.WebhookDelivery, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	items := rows(r.db.deliveries, func(d models.WebhookDelivery) bool { return d.EndpointID == endpointID })
+	sort.Slice(items, func(i, j int) bool { return items[i].ID > items[j].ID })
+	return limit(items, n), nil
+}
+
+func (r *WebhookRepository) ListAttempts(ctx context.Context, deliveryIDs []uint64) ([]models.WebhookAttempt, error) {
+	ids := make(map[uint64]bool, len(deliveryIDs))
+	for _, id := range deliveryIDs {
+		ids[id] = true
+	}
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	return rows(r.db.attempts, func(a models.WebhookAttempt) bool { return ids[a.DeliveryID] }), nil
+}
+
+// PurgeFinishedBefore deletes delivered and failed deliveries created before
+// cutoff, with their attempt logs. Pending deliveries are kept.
+func (r *WebhookRepository) PurgeFinishedBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	return r.db.deleteDeliveries(func(d models.WebhookDelivery) bool {
+		return d.Status != models.WebhookDeliveryPending && d.CreatedAt.Before(cutoff)
+	}), nil
+}
+
+// deleteDeliveries removes the matching deliveries and their attempts and
+// returns how many deliveries went; callers hold mu.
+func (db *DB) deleteDeliveries(match func(models.WebhookDelivery) bool) int64 {
+	var n int64
+	for id, d := range db.deliveries {
+		if !match(d) {
+			continue
+		}
+		for attemptID, a := range db.attempts {
+			if a.DeliveryID == id {
+				delete(db.attempts, attemptID)
+			}
+		}
+		delete(db.deliveries, id)
+		n++
+	}
+	return n
+}
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+
+	"habit-tracker/internal/models"
+)
+
+type NotificationRepository struct {
+	db *gorm.DB
+}
+
+func NewNotificationRepository(db *gorm.DB) *NotificationRepository {
+	return &NotificationRepository{db: db}
+}
+
+func (r *NotificationRepository) Create(ctx context.Context, n *models.Notification) error {
+	return r.db.WithContext(ctx).Create(n).Error
+}
+
+// ListByUser returns the user's notifications newest first. beforeID > 0
+// continues a previous page; unreadOnly skips read ones.
+func (r *NotificationRepository) ListByUser(ctx context.Context, userID uint64, unreadOnly bool, beforeID uint64, limit int) ([]models.Notification, error) {
+	var items []models.Notification
+	q := r.db.WithContext(ctx).Where("user_id = ?", userID)
+	if unreadOnly {
+		q = q.Where("read_at IS NULL")
+	}
+	if beforeID > 0 {
+		q = q.Where("id < ?", beforeID)
+	}
+	err := q.Order("id desc").Limit(limit).Find(&items).Error
+	return items, err
+}
+
+func (r *NotificationRepository) CountUnread(ctx context.Context, userID uint64) (int64, error) {
+	var n int64
+	err := r.db.WithContext(ctx).
+		Model(&models.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Count(&n).Error
+	return n, err
+}
+
+// MarkRead marks one of the user's notifications read; it reports false when
+// the user has no such notification.
+func (r *NotificationRepository) MarkRead(ctx context.Context, userID, id uint64, at time.Time) (bool, error) {
+	var n models.Notification
+	err := r.db.WithContext(ctx).Where("id = ? AND user_id = ?", id, userID).First(&n).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	if n.ReadAt != nil {
+		return true, nil
+	}
+	err = r.db.WithContext(ctx).
+		Model(&models.Notification{}).
+		Where("id = ? AND read_at IS NULL", id).
+		Update("read_at", at).Error
+	return err == nil, err
+}
+
+func (r *NotificationRepository) MarkAllRead(ctx context.Context, userID uint64, at time.Time) (int64, error) {
+	res := r.db.WithContext(ctx).
+		Model(&models.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Update("read_at", at)
+	return res.RowsAffected, res.Error
+}
+
+// Purge deletes read notifications created before readCutoff and all
+// notifications created before cutoff.
+func (r *NotificationRepository) Purge(ctx context.Context, readCutoff, cutoff time.Time) (int64, error) {
+	res := r.db.WithContext(ctx).
+		Where("(read_at IS NOT NULL AND created_at < ?) OR created_at < ?", readCutoff, cutoff).
+		Delete(&models.Notification{})
+	return res.RowsAffected, res.Error
+}
@@ -9,6 +9,11 @@ import (
 	"habit-tracker/internal/models"
 )
 
+// checkinsFromLog is how many days a log row adds to users.total_checkins:
+// one per completed day of a build habit and per clean day of a quit habit,
+// and the awarded amount for imports, which pay one point per imported day.
+const checkinsFromLog = "CASE WHEN reason IN ('checkin', 'clean_day') THEN 1 WHEN reason = 'import' THEN change_amount ELSE 0 END"
+
 type PointsRepository struct {
 	db *gorm.DB
 }
@@ -32,3 +37,55 @@ func (r *PointsRepository) SumByUserAndRange(ctx context.Context, userID uint64,
 		Scan(&total).Error
 	return total, err
 }
+
+// ListUsersWithSumInRange returns the users other than excludeUserID whose
+// points change in [start, end] sums to at least lo and less than hi.
+func (r *PointsRepository) ListUsersWithSumInRange(ctx context.Context, start, end time.Time, lo, hi int64, excludeUserID uint64) ([]uint64, error) {
+	var ids []uint64
+	err := r.db.WithContext(ctx).
+		Model(&models.UserPointsLog{}).
+		Select("user_id").
+		Where("user_id <> ? AND created_at BETWEEN ? AND ?", excludeUserID, start, end).
+		Group("user_id").
+		Having("SUM(change_amount) >= ? AND SUM(change_amount) < ?", lo, hi).
+		Pluck("user_id", &ids).Error
+	return ids, err
+}
+
+// EachByUser feeds the user's points log to fn in id order, batchSize rows at a time.
+func (r *PointsRepository) EachByUser(ctx context.Context, userID uint64, batchSize int, fn func([]models.UserPointsLog) error) error {
+	var batch []models.UserPointsLog
+	return r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		FindInBatches(&batch, batchSize, func(tx *gorm.DB, _ int) error {
+			return fn(batch)
+		}).Error
+}
+
+// LedgerTotals is what the points log says a user's counters should be.
+type LedgerTotals struct {
+	UserID   uint64
+	Points   int64
+	Checkins int64
+}
+
+// TotalsByUser sums the points log per user; userID 0 means every user with
+// log rows. Users without rows are missing from the result.
+func (r *PointsRepository) TotalsByUser(ctx context.Context, userID uint64) (map[uint64]LedgerTotals, error) {
+	var rows []LedgerTotals
+	q := r.db.WithContext(ctx).
+		Model(&models.UserPointsLog{}).
+		Select("user_id, COALESCE(SUM(change_amount),0) AS points, COALESCE(SUM(" + checkinsFromLog + "),0) AS checkins").
+		Group("user_id")
+	if userID != 0 {
+		q = q.Where("user_id = ?", userID)
+	}
+	if err := q.Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	totals := make(map[uint64]LedgerTotals, len(rows))
+	for _, row := range rows {
+		totals[row.UserID] = row
+	}
+	return totals, nil
+}
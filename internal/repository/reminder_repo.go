@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"habit-tracker/internal/models"
+)
+
+type ReminderRepository struct {
+	db *gorm.DB
+}
+
+func NewReminderRepository(db *gorm.DB) *ReminderRepository {
+	return &ReminderRepository{db: db}
+}
+
+func (r *ReminderRepository) ListByHabit(ctx context.Context, habitID uint64) ([]models.HabitReminder, error) {
+	var items []models.HabitReminder
+	err := r.db.WithContext(ctx).
+		Where("habit_id = ?", habitID).
+		Order("minute_of_day asc").
+		Find(&items).Error
+	return items, err
+}
+
+// ListTracked returns the reminders of active, unarchived, non-deleted habits.
+func (r *ReminderRepository) ListTracked(ctx context.Context) ([]models.HabitReminder, error) {
+	var items []models.HabitReminder
+	err := r.db.WithContext(ctx).
+		Where("habit_id IN (?)", r.db.Model(&models.Habit{}).
+			Select("id").
+			Where("is_active = ? AND archived_at IS NULL", true)).
+		Order("id asc").
+		Find(&items).Error
+	return items, err
+}
+
+// ReplaceForHabit sets the habit's reminders to exactly minutes. Reminders
+// that are kept retain their LastFiredOn so they do not fire twice a day.
+func (r *ReminderRepository) ReplaceForHabit(ctx context.Context, habit *models.Habit, minutes []int) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		del := tx.Where("habit_id = ?", habit.ID)
+		if len(minutes) > 0 {
+			del = del.Where("minute_of_day NOT IN ?", minutes)
+		}
+		if err := del.Delete(&models.HabitReminder{}).Error; err != nil {
+			return err
+		}
+		var existing []int
+		if err := tx.Model(&models.HabitReminder{}).
+			Where("habit_id = ?", habit.ID).
+			Pluck("minute_of_day", &existing).Error; err != nil {
+			return err
+		}
+		have := make(map[int]bool, len(existing))
+		for _, m := range existing {
+			have[m] = true
+		}
+		for _, m := range minutes {
+			if have[m] {
+				continue
+			}
+			item := &models.HabitReminder{UserID: habit.UserID, HabitID: habit.ID, MinuteOfDay: m, CreatedAt: time.Now()}
+			if err := tx.Create(item).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// MarkFired records that the reminder was handled on day. It reports false
+// when it had already been handled that day, e.g. by another instance.
+func (r *ReminderRepository) MarkFired(ctx context.Context, id uint64, day time.Time) (bool, error) {
+	res := r.db.WithContext(ctx).
+		Model(&models.HabitReminder{}).
+		Where("id = ? AND (last_fired_on IS NULL OR last_fired_on < ?)", id, day).
+		Update("last_fired_on", day)
+	return res.RowsAffected == 1, res.Error
+}
@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"habit-tracker/internal/models"
+)
+
+// Bucket units of the time-series queries; they match date_trunc fields.
+const (
+	BucketDay   = "day"
+	BucketWeek  = "week" // weeks start on Monday
+	BucketMonth = "month"
+)
+
+// BucketSum is one aggregated bucket; Start is the bucket's first day as a
+// wall-clock date (its location carries no meaning).
+type BucketSum struct {
+	Start time.Time `gorm:"column:bucket"`
+	Total int64     `gorm:"column:total"`
+}
+
+func truncExpr(unit, column string) (string, error) {
+	switch unit {
+	case BucketDay, BucketWeek, BucketMonth:
+		return fmt.Sprintf("date_trunc('%s', %s)", unit, column), nil
+	}
+	return "", fmt.Errorf("unsupported bucket unit %q", unit)
+}
+
+// SumByBucket sums points changes in [from, to) per bucket of the user's
+// local calendar in time zone tz (an IANA name).
+func (r *PointsRepository) SumByBucket(ctx context.Context, userID uint64, unit, tz string, from, to time.Time) ([]BucketSum, error) {
+	if isSQLite(r.db) {
+		return r.sumByBucketInGo(ctx, userID, unit, tz, from, to)
+	}
+	expr, err := truncExpr(unit, "(created_at AT TIME ZONE ?)")
+	if err != nil {
+		return nil, err
+	}
+	return scanBuckets(r.db.WithContext(ctx).
+		Model(&models.UserPointsLog{}).
+		Select(expr+" AS bucket, COALESCE(SUM(change_amount),0) AS total", tz).
+		Where("user_id = ? AND created_at >= ? AND created_at < ?", userID, from, to).
+		Group("bucket").
+		Order("bucket asc"))
+}
+
+// sumByBucketInGo is SumByBucket for SQLite, which has no time zone support.
+func (r *PointsRepository) sumByBucketInGo(ctx context.Context, userID uint64, unit, tz string, from, to time.Time) ([]BucketSum, error) {
+	if _, err := truncExpr(unit, "created_at"); err != nil {
+		return nil, err
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return nil, err
+	}
+	var logs []models.UserPointsLog
+	err = r.db.WithContext(ctx).
+		Select("created_at, change_amount").
+		Where("user_id = ? AND created_at >= ? AND created_at < ?", userID, from.Add(-sqliteTimeSlack), to.Add(sqliteTimeSlack)).
+		Find(&logs).Error
+	if err != nil {
+		return nil, err
+	}
+	totals := map[time.Time]int64{}
+	var starts []time.Time
+	for _, l := range logs {
+		if l.CreatedAt.Before(from) || !l.CreatedAt.Before(to) {
+			continue
+		}
+		start := truncateWall(l.CreatedAt.In(loc), unit)
+		if _, ok := totals[start]; !ok {
+			starts = append(starts, start)
+		}
+		totals[start] += int64(l.ChangeAmount)
+	}
+	sort.Slice(starts, func(i, j int) bool { return starts[i].Before(starts[j]) })
+	out := make([]BucketSum, len(starts))
+	for i, start := range starts {
+		out[i] = BucketSum{Start: start, Total: totals[start]}
+	}
+	return out, nil
+}
+
+// SumBefore is the user's balance from the points log before the given instant.
+func (r *PointsRepository) SumBefore(ctx context.Context, userID uint64, before time.Time) (int64, error) {
+	if isSQLite(r.db) {
+		return r.sumBeforeInGo(ctx, userID, before)
+	}
+	var total int64
+	err := r.db.WithContext(ctx).
+		Model(&models.UserPointsLog{}).
+		Select("COALESCE(SUM(change_amount),0)").
+		Where("user_id = ? AND created_at < ?", userID, before).
+		Scan(&total).Error
+	return total, err
+}
+
+// sumBeforeInGo is SumBefore for SQLite: rows near the bound are compared in Go.
+func (r *PointsRepository) sumBeforeInGo(ctx context.Context, userID uint64, before time.Time) (int64, error) {
+	lo, hi := before.Add(-sqliteTimeSlack), before.Add(sqliteTimeSlack)
+	var total int64
+	err := r.db.WithContext(ctx).
+		Model(&models.UserPointsLog{}).
+		Select("COALESCE(SUM(change_amount),0)").
+		Where("user_id = ? AND created_at < ?", userID, lo).
+		Scan(&total).Error
+	if err != nil {
+		return 0, err
+	}
+	var logs []models.UserPointsLog
+	err = r.db.WithContext(ctx).
+		Select("created_at, change_amount").
+		Where("user_id = ? AND created_at >= ? AND created_at < ?", userID, lo, hi).
+		Find(&logs).Error
+	if err != nil {
+		return 0, err
+	}
+	for _, l := range logs {
+		if l.CreatedAt.Before(before) {
+			total += int64(l.ChangeAmount)
+		}
+	}
+	return total, nil
+}
+
+// SumCountByBucket sums the check-in counts of the user's build habits for
+// check-in dates in [from, to) per bucket. Relapses of quit habits are excluded.
+func (r *CheckinRepository) SumCountByBucket(ctx context.Context, userID uint64, unit string, from, to time.Time) ([]BucketSum, error) {
+	expr, err := dateTruncExpr(r.db, unit, "checkin_date")
+	if err != nil {
+		return nil, err
+	}
+	return scanBuckets(r.db.WithContext(ctx).
+		Model(&models.HabitCheckin{}).
+		Select(expr+" AS bucket, COALESCE(SUM(count),0) AS total").
+		Where("user_id = ? AND checkin_date >= ? AND checkin_date < ?", userID, from, to).
+		Where("habit_id NOT IN (?)", r.db.Unscoped().Model(&models.Habit{}).
+			Select("id").
+			Where("polarity = ?", models.HabitPolarityQuit)).
+		Group("bucket").
+		Order("bucket asc"))
+}
@@ -0,0 +1,184 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"habit-tracker/internal/models"
+)
+
+// The services depend on these interfaces rather than on the gorm-backed
+// repositories, so that they can run on the in-memory fakes of package
+// memrepo in unit tests. Each interface lists every method of its repository.
+
+type UserStore interface {
+	GetByUsername(ctx context.Context, username string) (*models.User, error)
+	GetByID(ctx context.Context, id uint64) (*models.User, error)
+	GetTotalCheckins(ctx context.Context, userID uint64) (int64, error)
+	Create(ctx context.Context, user *models.User) error
+	ListAll(ctx context.Context) ([]models.User, error)
+	UpdatePoints(ctx context.Context, userID uint64, delta int64) error
+	IncrementCheckins(ctx context.Context, userID uint64, delta int64) error
+	UpdateTimezone(ctx context.Context, userID uint64, tz string) error
+	UpdateReminderWebhookURL(ctx context.Context, userID uint64, url string) error
+	SetCalendarTokenHash(ctx context.Context, userID uint64, hash *string) error
+	GetByCalendarTokenHash(ctx context.Context, hash string) (*models.User, error)
+	UpdatePasswordHash(ctx context.Context, userID uint64, hash string) error
+}
+
+type HabitStore interface {
+	ListByUser(ctx context.Context, userID uint64) ([]models.Habit, error)
+	ListFiltered(ctx context.Context, userID uint64, f HabitFilter) ([]models.Habit, error)
+	ListTrackedByPolarity(ctx context.Context, polarity string) ([]models.Habit, error)
+	ListAll(ctx context.Context) ([]models.Habit, error)
+	ListMissingStreakState(ctx context.Context) ([]models.Habit, error)
+	ListTrackedByUser(ctx context.Context, userID uint64, polarity string) ([]models.Habit, error)
+	ListByUserWithDeleted(ctx context.Context, userID uint64) ([]models.Habit, error)
+	ListDeletedByUser(ctx context.Context, userID uint64) ([]models.Habit, error)
+	ListDeletedBefore(ctx context.Context, cutoff time.Time) ([]models.Habit, error)
+	GetByID(ctx context.Context, id uint64) (*models.Habit, error)
+	GetByIDWithTags(ctx context.Context, id uint64) (*models.Habit, error)
+	GetByIDUnscoped(ctx context.Context, id uint64) (*models.Habit, error)
+	Create(ctx context.Context, habit *models.Habit) error
+	Update(ctx context.Context, habit *models.Habit) error
+	Reorder(ctx context.Context, userID uint64, habitIDs []uint64) error
+	UpdateStatus(ctx context.Context, habitID uint64, isActive bool) error
+	SetArchivedAt(ctx context.Context, habitID uint64, at *time.Time) error
+	UpdateLastCleanDate(ctx context.Context, habitID uint64, day time.Time) error
+	ClaimCleanDay(ctx context.Context, habitID, userID uint64, day time.Time) (bool, error)
+	UpdateStreakState(ctx context.Context, habitID uint64, current, longest int, lastCompleted *time.Time) error
+	SoftDelete(ctx context.Context, habitID uint64) error
+	Restore(ctx context.Context, habitID uint64) error
+	HardDelete(ctx context.Context, habit *models.Habit, revokePoints bool) ([]string, error)
+}
+
+type CheckinStore interface {
+	Upsert(ctx context.Context, checkin *models.HabitCheckin) (CheckinTotals, error)
+	GetByHabitAndDate(ctx context.Context, habitID uint64, date time.Time) (*models.HabitCheckin, error)
+	ListByHabitAndDateRange(ctx context.Context, habitID uint64, start, end time.Time) ([]models.HabitCheckin, error)
+	ListByUserAndDateRange(ctx context.Context, userID uint64, start, end time.Time) ([]models.HabitCheckin, error)
+	SumCountByHabit(ctx context.Context, habitID uint64) (int64, error)
+	SumCountByUser(ctx context.Context, userID uint64) (int64, error)
+	SumCountByUserAndRange(ctx context.Context, userID uint64, start, end time.Time) (int64, error)
+	ListByHabitDesc(ctx context.Context, habitID uint64) ([]models.HabitCheckin, error)
+	GetByID(ctx context.Context, id uint64) (*models.HabitCheckin, error)
+	UpdateJournal(ctx context.Context, id uint64, note *string, mood *int) error
+	SetPhotoKey(ctx context.Context, id uint64, key string) error
+	SearchNotes(ctx context.Context, userID uint64, habitID *uint64, query string, limit int) ([]models.HabitCheckin, error)
+	EachByUser(ctx context.Context, userID uint64, batchSize int, fn func([]models.HabitCheckin) error) error
+	ListDaysByHabit(ctx context.Context, habitID uint64) ([]time.Time, error)
+	InsertMissing(ctx context.Context, records []models.HabitCheckin) (int64, error)
+	TotalsByHabit(ctx context.Context, habitID uint64) (CheckinTotals, error)
+	CountDaysByWeekday(ctx context.Context, habitID uint64, t DayThreshold, from, to time.Time) ([7]int64, error)
+	CountDaysByRanges(ctx context.Context, habitID uint64, t DayThreshold, bounds []time.Time) ([]int64, error)
+	SumRatiosByDay(ctx context.Context, userID uint64, habitID *uint64, from, to time.Time) ([]DayScore, error)
+	SumCountByBucket(ctx context.Context, userID uint64, unit string, from, to time.Time) ([]BucketSum, error)
+}
+
+type PointsStore interface {
+	AddLog(ctx context.Context, log *models.UserPointsLog) error
+	SumByUserAndRange(ctx context.Context, userID uint64, start, end time.Time) (int64, error)
+	ListUsersWithSumInRange(ctx context.Context, start, end time.Time, lo, hi int64, excludeUserID uint64) ([]uint64, error)
+	EachByUser(ctx context.Context, userID uint64, batchSize int, fn func([]models.UserPointsLog) error) error
+	TotalsByUser(ctx context.Context, userID uint64) (map[uint64]LedgerTotals, error)
+	SumByBucket(ctx context.Context, userID uint64, unit, tz string, from, to time.Time) ([]BucketSum, error)
+	SumBefore(ctx context.Context, userID uint64, before time.Time) (int64, error)
+}
+
+type AchievementStore interface {
+	ListAll(ctx context.Context) ([]models.Achievement, error)
+	ListByConditionType(ctx context.Context, conditionType string) ([]models.Achievement, error)
+	Create(ctx context.Context, a *models.Achievement) error
+	Update(ctx context.Context, a *models.Achievement) error
+}
+
+type UserAchievementStore interface {
+	ListByUser(ctx context.Context, userID uint64) ([]models.UserAchievement, error)
+	Create(ctx context.Context, ua *models.UserAchievement) error
+}
+
+type CategoryStore interface {
+	ListByUser(ctx context.Context, userID uint64) ([]models.HabitCategory, error)
+	GetByID(ctx context.Context, id uint64) (*models.HabitCategory, error)
+	Create(ctx context.Context, category *models.HabitCategory) error
+	Update(ctx context.Context, category *models.HabitCategory) error
+	Delete(ctx context.Context, id uint64) error
+}
+
+type TagStore interface {
+	ListByUser(ctx context.Context, userID uint64) ([]models.HabitTag, error)
+	GetByID(ctx context.Context, id uint64) (*models.HabitTag, error)
+	EnsureByNames(ctx context.Context, userID uint64, names []string) ([]models.HabitTag, error)
+	ReplaceForHabit(ctx context.Context, habitID uint64, tagIDs []uint64) error
+	Delete(ctx context.Context, id uint64) error
+}
+
+type StreakFreezeStore interface {
+	CountAvailable(ctx context.Context, userID uint64) (int64, error)
+	Create(ctx context.Context, freeze *models.StreakFreeze) error
+	Purchase(ctx context.Context, userID uint64, price int64) (*models.StreakFreeze, bool, error)
+	Consume(ctx context.Context, userID, habitID uint64, day time.Time) (bool, error)
+	ListUsedDays(ctx context.Context, habitID uint64) ([]time.Time, error)
+	ListUsedByUser(ctx context.Context, userID uint64, limit int) ([]models.StreakFreeze, error)
+	ListUserIDsWithAvailable(ctx context.Context) ([]uint64, error)
+}
+
+type VacationStore interface {
+	ListByUser(ctx context.Context, userID uint64) ([]models.Vacation, error)
+	GetByID(ctx context.Context, id uint64) (*models.Vacation, error)
+	Overlaps(ctx context.Context, userID uint64, start, end time.Time) (bool, error)
+	Create(ctx context.Context, item *models.Vacation) error
+	UpdateEndDate(ctx context.Context, id uint64, end time.Time) error
+	Delete(ctx context.Context, id uint64) error
+}
+
+type ReminderStore interface {
+	ListByHabit(ctx context.Context, habitID uint64) ([]models.HabitReminder, error)
+	ListTracked(ctx context.Context) ([]models.HabitReminder, error)
+	ReplaceForHabit(ctx context.Context, habit *models.Habit, minutes []int) error
+	MarkFired(ctx context.Context, id uint64, day time.Time) (bool, error)
+}
+
+type NotificationStore interface {
+	Create(ctx context.Context, n *models.Notification) error
+	ListByUser(ctx context.Context, userID uint64, unreadOnly bool, beforeID uint64, limit int) ([]models.Notification, error)
+	CountUnread(ctx context.Context, userID uint64) (int64, error)
+	MarkRead(ctx context.Context, userID, id uint64, at time.Time) (bool, error)
+	MarkAllRead(ctx context.Context, userID uint64, at time.Time) (int64, error)
+	Purge(ctx context.Context, readCutoff, cutoff time.Time) (int64, error)
+}
+
+type WebhookStore interface {
+	CreateEndpoint(ctx context.Context, ep *models.WebhookEndpoint) error
+	ListEndpoints(ctx context.Context, userID uint64) ([]models.WebhookEndpoint, error)
+	GetEndpoint(ctx context.Context, id uint64) (*models.WebhookEndpoint, error)
+	DeleteEndpoint(ctx context.Context, id uint64) error
+	Enqueue(ctx context.Context, deliveries []models.WebhookDelivery) error
+	ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]models.WebhookDelivery, error)
+	Finish(ctx context.Context, d *models.WebhookDelivery, attempt *models.WebhookAttempt) error
+	ListDeliveries(ctx context.Context, endpointID uint64, limit int) ([]models.WebhookDelivery, error)
+	ListAttempts(ctx context.Context, deliveryIDs []uint64) ([]models.WebhookAttempt, error)
+	PurgeFinishedBefore(ctx context.Context, cutoff time.Time) (int64, error)
+}
+
+type LedgerStore interface {
+	Correct(ctx context.Context, c CounterCorrection, source string, at time.Time) (bool, error)
+	ListAdjustments(ctx context.Context, userID uint64, limit int) ([]models.LedgerAdjustment, error)
+}
+
+var (
+	_ UserStore            = (*UserRepository)(nil)
+	_ HabitStore           = (*HabitRepository)(nil)
+	_ CheckinStore         = (*CheckinRepository)(nil)
+	_ PointsStore          = (*PointsRepository)(nil)
+	_ AchievementStore     = (*AchievementRepository)(nil)
+	_ UserAchievementStore = (*UserAchievementRepository)(nil)
+	_ CategoryStore        = (*CategoryRepository)(nil)
+	_ TagStore             = (*TagRepository)(nil)
+	_ StreakFreezeStore    = (*StreakFreezeRepository)(nil)
+	_ VacationStore        = (*VacationRepository)(nil)
+	_ ReminderStore        = (*ReminderRepository)(nil)
+	_ NotificationStore    = (*NotificationRepository)(nil)
+	_ WebhookStore         = (*WebhookRepository)(nil)
+	_ LedgerStore          = (*LedgerRepository)(nil)
+)
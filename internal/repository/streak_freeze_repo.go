@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"habit-tracker/internal/models"
+)
+
+type StreakFreezeRepository struct {
+	db *gorm.DB
+}
+
+func NewStreakFreezeRepository(db *gorm.DB) *StreakFreezeRepository {
+	return &StreakFreezeRepository{db: db}
+}
+
+func (r *StreakFreezeRepository) CountAvailable(ctx context.Context, userID uint64) (int64, error) {
+	var n int64
+	err := r.db.WithContext(ctx).
+		Model(&models.StreakFreeze{}).
+		Where("user_id = ? AND used_on IS NULL", userID).
+		Count(&n).Error
+	return n, err
+}
+
+func (r *StreakFreezeRepository) Create(ctx context.Context, freeze *models.StreakFreeze) error {
+	return r.db.WithContext(ctx).Create(freeze).Error
+}
+
+// Purchase deducts price from the user's points, logs the spend and adds a
+// freeze in one transaction. It reports false when the balance is too low.
+func (r *StreakFreezeRepository) Purchase(ctx context.Context, userID uint64, price int64) (*models.StreakFreeze, bool, error) {
+	var freeze *models.StreakFreeze
+	bought := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&models.User{}).
+			Where("id = ? AND points >= ?", userID, price).
+			UpdateColumn("points", gorm.Expr("points - ?", price))
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return nil
+		}
+		now := time.Now()
+		if err := tx.Create(&models.UserPointsLog{
+			UserID:       userID,
+			ChangeAmount: int(-price),
+			Reason:       "streak_freeze",
+			CreatedAt:    now,
+		}).Error; err != nil {
+			return err
+		}
+		freeze = &models.StreakFreeze{UserID: userID, Source: models.StreakFreezePurchased, CreatedAt: now}
+		if err := tx.Create(freeze).Error; err != nil {
+			return err
+		}
+		bought = true
+		return nil
+	})
+	return freeze, bought, err
+}
+
+// Consume marks the user's oldest unused freeze as covering day for habitID.
+// It reports false when the user has no freeze left.
+func (r *StreakFreezeRepository) Consume(ctx context.Context, userID, habitID uint64, day time.Time) (bool, error) {
+	consumed := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var freeze models.StreakFreeze
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ? AND used_on IS NULL", userID).
+			Order("id asc").
+			First(&freeze).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&freeze).Updates(map[string]interface{}{
+			"habit_id": habitID,
+			"used_on":  day,
+		}).Error; err != nil {
+			return err
+		}
+		consumed = true
+		return nil
+	})
+	return consumed, err
+}
+
+// ListUsedDays returns the days of habitID covered by consumed freezes.
+func (r *StreakFreezeRepository) ListUsedDays(ctx context.Context, habitID uint64) ([]time.Time, error) {
+	var days []time.Time
+	err := r.db.WithContext(ctx).
+		Model(&models.StreakFreeze{}).
+		Where("habit_id = ? AND used_on IS NOT NULL", habitID).
+		Order("used_on asc").
+		Pluck("used_on", &days).Error
+	return days, err
+}
+
+func (r *StreakFreezeRepository) ListUsedByUser(ctx context.Context, userID uint64, limit int) ([]models.StreakFreeze, error) {
+	var items []models.StreakFreeze
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND used_on IS NOT NULL", userID).
+		Order("used_on desc").
+		Limit(limit).
+		Find(&items).Error
+	return items, err
+}
+
+// ListUserIDsWithAvailable returns users holding at least one unused freeze.
+func (r *StreakFreezeRepository) ListUserIDsWithAvailable(ctx context.Context) ([]uint64, error) {
+	var ids []uint64
+	err := r.db.WithContext(ctx).
+		Model(&models.StreakFreeze{}).
+		Where("used_on IS NULL").
+		Distinct().
+		Order("user_id asc").
+		Pluck("user_id", &ids).Error
+	return ids, err
+}
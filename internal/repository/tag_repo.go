@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"habit-tracker/internal/models"
+)
+
+type TagRepository struct {
+	db *gorm.DB
+}
+
+func NewTagRepository(db *gorm.DB) *TagRepository {
+	return &TagRepository{db: db}
+}
+
+func (r *TagRepository) ListByUser(ctx context.Context, userID uint64) ([]models.HabitTag, error) {
+	var items []models.HabitTag
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("name asc").
+		Find(&items).Error
+	return items, err
+}
+
+func (r *TagRepository) GetByID(ctx context.Context, id uint64) (*models.HabitTag, error) {
+	var item models.HabitTag
+	if err := r.db.WithContext(ctx).First(&item, id).Error; err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+// EnsureByNames returns the user's tags with the given names, creating missing ones.
+func (r *TagRepository) EnsureByNames(ctx context.Context, userID uint64, names []string) ([]models.HabitTag, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+	tags := make([]models.HabitTag, 0, len(names))
+	for _, name := range names {
+		tags = append(tags, models.HabitTag{UserID: userID, Name: name})
+	}
+	db := r.db.WithContext(ctx)
+	if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&tags).Error; err != nil {
+		return nil, err
+	}
+
+	var items []models.HabitTag
+	err := db.Where("user_id = ? AND name IN ?", userID, names).
+		Order("name asc").
+		Find(&items).Error
+	return items, err
+}
+
+// ReplaceForHabit sets the habit's tags to exactly tagIDs.
+func (r *TagRepository) ReplaceForHabit(ctx context.Context, habitID uint64, tagIDs []uint64) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("habit_id = ?", habitID).Delete(&models.HabitTagLink{}).Error; err != nil {
+			return err
+		}
+		if len(tagIDs) == 0 {
+			return nil
+		}
+		links := make([]models.HabitTagLink, 0, len(tagIDs))
+		for _, id := range tagIDs {
+			links = append(links, models.HabitTagLink{HabitID: habitID, TagID: id})
+		}
+		return tx.Create(&links).Error
+	})
+}
+
+// Delete removes the tag and its links to habits.
+func (r *TagRepository) Delete(ctx context.Context, id uint64) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("tag_id = ?", id).Delete(&models.HabitTagLink{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.HabitTag{}, id).Error
+	})
+}
@@ -67,3 +67,40 @@ func (r *UserRepository) IncrementCheckins(ctx context.Context, userID uint64, d
 		UpdateColumn("total_checkins", gorm.Expr("total_checkins + ?", delta)).
 		Error
 }
+
+func (r *UserRepository) UpdateTimezone(ctx context.Context, userID uint64, tz string) error {
+	return r.db.WithContext(ctx).
+		Model(&models.User{}).
+		Where("id = ?", userID).
+		Update("timezone", tz).Error
+}
+
+func (r *UserRepository) UpdateReminderWebhookURL(ctx context.Context, userID uint64, url string) error {
+	return r.db.WithContext(ctx).
+		Model(&models.User{}).
+		Where("id = ?", userID).
+		Update("reminder_webhook_url", url).Error
+}
+
+// SetCalendarTokenHash replaces the calendar feed secret; nil disables the feed.
+func (r *UserRepository) SetCalendarTokenHash(ctx context.Context, userID uint64, hash *string) error {
+	return r.db.WithContext(ctx).
+		Model(&models.User{}).
+		Where("id = ?", userID).
+		Update("calendar_token_hash", hash).Error
+}
+
+func (r *UserRepository) GetByCalendarTokenHash(ctx context.Context, hash string) (*models.User, error) {
+	var user models.User
+	if err := r.db.WithContext(ctx).Where("calendar_token_hash = ?", hash).First(&user).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *UserRepository) UpdatePasswordHash(ctx context.Context, userID uint64, hash string) error {
+	return r.db.WithContext(ctx).
+		Model(&models.User{}).
+		Where("id = ?", userID).
+		Update("password_hash", hash).Error
+}
@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"habit-tracker/internal/models"
+)
+
+type VacationRepository struct {
+	db *gorm.DB
+}
+
+func NewVacationRepository(db *gorm.DB) *VacationRepository {
+	return &VacationRepository{db: db}
+}
+
+func (r *VacationRepository) ListByUser(ctx context.Context, userID uint64) ([]models.Vacation, error) {
+	var items []models.Vacation
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("start_date asc").
+		Find(&items).Error
+	return items, err
+}
+
+func (r *VacationRepository) GetByID(ctx context.Context, id uint64) (*models.Vacation, error) {
+	var item models.Vacation
+	if err := r.db.WithContext(ctx).First(&item, id).Error; err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+// Overlaps reports whether any vacation of the user intersects [start, end].
+func (r *VacationRepository) Overlaps(ctx context.Context, userID uint64, start, end time.Time) (bool, error) {
+	var n int64
+	err := r.db.WithContext(ctx).
+		Model(&models.Vacation{}).
+		Where("user_id = ? AND start_date <= ? AND end_date >= ?", userID, end, start).
+		Count(&n).Error
+	return n > 0, err
+}
+
+func (r *VacationRepository) Create(ctx context.Context, item *models.Vacation) error {
+	return r.db.WithContext(ctx).Create(item).Error
+}
+
+func (r *VacationRepository) UpdateEndDate(ctx context.Context, id uint64, end time.Time) error {
+	return r.db.WithContext(ctx).
+		Model(&models.Vacation{}).
+		Where("id = ?", id).
+		Update("end_date", end).Error
+}
+
+func (r *VacationRepository) Delete(ctx context.Context, id uint64) error {
+	return r.db.WithContext(ctx).Delete(&models.Vacation{}, id).Error
+}
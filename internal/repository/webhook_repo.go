@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"habit-tracker/internal/models"
+)
+
+type WebhookRepository struct {
+	db *gorm.DB
+}
+
+func NewWebhookRepository(db *gorm.DB) *WebhookRepository {
+	return &WebhookRepository{db: db}
+}
+
+func (r *WebhookRepository) CreateEndpoint(ctx context.Context, ep *models.WebhookEndpoint) error {
+	return r.db.WithContext(ctx).Create(ep).Error
+}
+
+func (r *WebhookRepository) ListEndpoints(ctx context.Context, userID uint64) ([]models.WebhookEndpoint, error) {
+	var items []models.WebhookEndpoint
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("id asc").
+		Find(&items).Error
+	return items, err
+}
+
+func (r *WebhookRepository) GetEndpoint(ctx context.Context, id uint64) (*models.WebhookEndpoint, error) {
+	var ep models.WebhookEndpoint
+	if err := r.db.WithContext(ctx).First(&ep, id).Error; err != nil {
+		return nil, err
+	}
+	return &ep, nil
+}
+
+// DeleteEndpoint removes the endpoint together with its outbox and delivery log.
+func (r *WebhookRepository) DeleteEndpoint(ctx context.Context, id uint64) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		deliveries := tx.Model(&models.WebhookDelivery{}).Select("id").Where("endpoint_id = ?", id)
+		if err := tx.Where("delivery_id IN (?)", deliveries).Delete(&models.WebhookAttempt{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("endpoint_id = ?", id).Delete(&models.WebhookDelivery{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.WebhookEndpoint{}, id).Error
+	})
+}
+
+func (r *WebhookRepository) Enqueue(ctx context.Context, deliveries []models.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).CreateInBatches(&deliveries, 100).Error
+}
+
+// ClaimDue returns up to limit pending deliveries whose next attempt is due
+// and leases them until now+lease, so that concurrent workers do not send
+// the same delivery twice. A delivery whose worker dies becomes due again
+// when the lease runs out.
+func (r *WebhookRepository) ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]models.WebhookDelivery, error) {
+	var due []models.WebhookDelivery
+	err := r.db.WithContext(ctx).
+		Where("status = ? AND next_attempt_at <= ?", models.WebhookDeliveryPending, now).
+		Order("next_attempt_at asc").
+		Limit(limit).
+		Find(&due).Error
+	if err != nil {
+		return nil, err
+	}
+	claimed := due[:0]
+	until := now.Add(lease)
+	for _, d := range due {
+		res := r.db.WithContext(ctx).
+			Model(&models.WebhookDelivery{}).
+			Where("id = ? AND status = ? AND next_attempt_at <= ?", d.ID, models.WebhookDeliveryPending, now).
+			Update("next_attempt_at", until)
+		if res.Error != nil {
+			return nil, res.Error
+		}
+		if res.RowsAffected == 1 {
+			d.NextAttemptAt = until
+			claimed = append(claimed, d)
+		}
+	}
+	return claimed, nil
+}
+
+// Finish stores the outcome of one attempt: the log entry and the delivery's new state.
+func (r *WebhookRepository) Finish(ctx context.Context, d *models.WebhookDelivery, attempt *models.WebhookAttempt) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(attempt).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.WebhookDelivery{}).
+			Where("id = ?", d.ID).
+			Updates(map[string]interface{}{
+				"status":          d.Status,
+				"attempts":        d.Attempts,
+				"next_attempt_at": d.NextAttemptAt,
+				"delivered_at":    d.DeliveredAt,
+			}).Error
+	})
+}
+
+// ListDeliveries returns the endpoint's most recent deliveries first.
+func (r *WebhookRepository) ListDeliveries(ctx context.Context, endpointID uint64, limit int) ([]models.WebhookDelivery, error) {
+	var items []models.WebhookDelivery
+	err := r.db.WithContext(ctx).
+		Where("endpoint_id = ?", endpointID).
+		Order("id desc").
+		Limit(limit).
+		Find(&items).Error
+	return items, err
+}
+
+func (r *WebhookRepository) ListAttempts(ctx context.Context, deliveryIDs []uint64) ([]models.WebhookAttempt, error) {
+	var items []models.WebhookAttempt
+	if len(deliveryIDs) == 0 {
+		return items, nil
+	}
+	err := r.db.WithContext(ctx).
+		Where("delivery_id IN ?", deliveryIDs).
+		Order("id asc").
+		Find(&items).Error
+	return items, err
+}
+
+// PurgeFinishedBefore deletes delivered and failed deliveries created before
+// cutoff, with their attempt logs. Pending deliveries are kept.
+func (r *WebhookRepository) PurgeFinishedBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	var n int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		old := tx.Model(&models.WebhookDelivery{}).Select("id").
+			Where("status <> ? AND created_at < ?", models.WebhookDeliveryPending, cutoff)
+		if err := tx.Where("delivery_id IN (?)", old).Delete(&models.WebhookAttempt{}).Error; err != nil {
+			return err
+		}
+		res := tx.Where("status <> ? AND created_at < ?", models.WebhookDeliveryPending, cutoff).Delete(&models.WebhookDelivery{})
+		n = res.RowsAffected
+		return res.Error
+	})
+	return n, err
+}
@@ -2,18 +2,30 @@ package router
 
 import (
 	"habit-tracker/internal/handler"
+	"habit-tracker/internal/openapi"
 
 	"github.com/gin-gonic/gin"
 )
 
 type Deps struct {
-	AuthHandler        *handler.AuthHandler
-	HabitHandler       *handler.HabitHandler
-	CheckinHandler     *handler.CheckinHandler
-	LeaderboardHandler *handler.LeaderboardHandler
-	UserHandler        *handler.UserHandler
-	AchievementHandler *handler.AchievementHandler
-	AuthMW             gin.HandlerFunc
+	AuthHandler         *handler.AuthHandler
+	HabitHandler        *handler.HabitHandler
+	CheckinHandler      *handler.CheckinHandler
+	LeaderboardHandler  *handler.LeaderboardHandler
+	UserHandler         *handler.UserHandler
+	AchievementHandler  *handler.AchievementHandler
+	CategoryHandler     *handler.CategoryHandler
+	JournalHandler      *handler.JournalHandler
+	StreakHandler       *handler.StreakHandler
+	StatsHandler        *handler.StatsHandler
+	ExportHandler       *handler.ExportHandler
+	ImportHandler       *handler.ImportHandler
+	CalendarHandler     *handler.CalendarHandler
+	ReminderHandler     *handler.ReminderHandler
+	WebhookHandler      *handler.WebhookHandler
+	StreamHandler       *handler.StreamHandler
+	NotificationHandler *handler.NotificationHandler
+	AuthMW              gin.HandlerFunc
 }
 
 func Register(r *gin.Engine, deps Deps) {
@@ -29,6 +41,9 @@ func Register(r *gin.Engine, deps Deps) {
 	api.GET("/ping", func(c *gin.Context) {
 		c.JSON(200, gin.H{"message": "pong"})
 	})
+	api.GET("/openapi.json", func(c *gin.Context) {
+		c.Data(200, "application/json", openapi.Spec)
+	})
 
 	authGroup := api.Group("/auth")
 	deps.AuthHandler.RegisterRoutes(authGroup)
@@ -36,6 +51,20 @@ func Register(r *gin.Engine, deps Deps) {
 	userGroup := api.Group("/user")
 	userGroup.Use(deps.AuthMW)
 	deps.UserHandler.RegisterRoutes(userGroup)
+	deps.StreakHandler.RegisterRoutes(userGroup)
+	deps.ExportHandler.RegisterRoutes(userGroup)
+	deps.ImportHandler.RegisterRoutes(userGroup)
+	deps.CalendarHandler.RegisterRoutes(userGroup)
+	deps.WebhookHandler.RegisterRoutes(userGroup)
+
+	// 日历订阅地址由日历应用直接拉取，靠 URL 中的密钥鉴权
+	calendar := api.Group("/calendar")
+	deps.CalendarHandler.RegisterFeed(calendar)
+
+	// 浏览器的 EventSource 不能设置请求头：先用登录令牌换取一次性票据，再以 ?ticket= 连接
+	stream := api.Group("/events")
+	stream.Use(deps.StreamHandler.TicketAuth(deps.AuthMW))
+	deps.StreamHandler.RegisterRoutes(stream)
 
 	leaderboard := api.Group("/leaderboard")
 	leaderboard.Use(deps.AuthMW)
@@ -52,4 +81,21 @@ func Register(r *gin.Engine, deps Deps) {
 	checkins := api.Group("")
 	checkins.Use(deps.AuthMW)
 	deps.CheckinHandler.RegisterRoutes(checkins)
+	deps.JournalHandler.RegisterRoutes(checkins)
+
+	categories := api.Group("")
+	categories.Use(deps.AuthMW)
+	deps.CategoryHandler.RegisterRoutes(categories)
+
+	stats := api.Group("")
+	stats.Use(deps.AuthMW)
+	deps.StatsHandler.RegisterRoutes(stats)
+
+	reminders := api.Group("")
+	reminders.Use(deps.AuthMW)
+	deps.ReminderHandler.RegisterRoutes(reminders)
+
+	notifications := api.Group("/notifications")
+	notifications.Use(deps.AuthMW)
+	deps.NotificationHandler.RegisterRoutes(notifications)
 }
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+
+	"habit-tracker/internal/models"
+)
+
+// AchievementCatalog is the built-in set of achievements, keyed by Code.
+// SyncCatalog writes it to the achievements table.
+var AchievementCatalog = []models.Achievement{
+	{Code: "first_checkin", Name: "初次打卡", Description: "完成第一次打卡", ConditionType: "total_checkins", ConditionValue: 1},
+	{Code: "checkins_10", Name: "小有所成", Description: "累计打卡 10 次", ConditionType: "total_checkins", ConditionValue: 10},
+	{Code: "checkins_100", Name: "百次坚持", Description: "累计打卡 100 次", ConditionType: "total_checkins", ConditionValue: 100},
+	{Code: "checkins_500", Name: "习惯大师", Description: "累计打卡 500 次", ConditionType: "total_checkins", ConditionValue: 500},
+	{Code: "streak_7", Name: "一周不断", Description: "连续打卡 7 天", ConditionType: "streak_days", ConditionValue: 7},
+	{Code: "streak_30", Name: "月度坚持", Description: "连续打卡 30 天", ConditionType: "streak_days", ConditionValue: 30},
+	{Code: "streak_100", Name: "百日筑基", Description: "连续打卡 100 天", ConditionType: "streak_days", ConditionValue: 100},
+	{Code: "points_100", Name: "积分新星", Description: "累计获得 100 积分", ConditionType: "points", ConditionValue: 100},
+	{Code: "points_1000", Name: "积分达人", Description: "累计获得 1000 积分", ConditionType: "points", ConditionValue: 1000},
+}
+
+// CatalogSyncReport lists the codes SyncCatalog created and updated, and the
+// codes in the database that are not in the catalog. Those are kept because
+// users may have unlocked them.
+type CatalogSyncReport struct {
+	Created []string `json:"created,omitempty"`
+	Updated []string `json:"updated,omitempty"`
+	Unknown []string `json:"unknown,omitempty"`
+}
+
+// SyncCatalog makes the achievements table match AchievementCatalog. With
+// dryRun it only reports the changes.
+func (s *AchievementService) SyncCatalog(ctx context.Context, dryRun bool) (*CatalogSyncReport, error) {
+	existing, err := s.achievements.ListAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byCode := make(map[string]models.Achievement, len(existing))
+	for _, a := range existing {
+		byCode[a.Code] = a
+	}
+
+	report := &CatalogSyncReport{}
+	for _, want := range AchievementCatalog {
+		have, ok := byCode[want.Code]
+		delete(byCode, want.Code)
+		if !ok {
+			report.Created = append(report.Created, want.Code)
+			if !dryRun {
+				a := want
+				if err := s.achievements.Create(ctx, &a); err != nil {
+					return report, err
+				}
+			}
+			continue
+		}
+		want.ID = have.ID
+		if want == have {
+			continue
+		}
+		report.Updated = append(report.Updated, want.Code)
+		if !dryRun {
+			if err := s.achievements.Update(ctx, &want); err != nil {
+				return report, err
+			}
+		}
+	}
+	for _, a := range existing {
+		if _, ok := byCode[a.Code]; ok {
+			report.Unknown = append(report.Unknown, a.Code)
+		}
+	}
+	return report, nil
+}
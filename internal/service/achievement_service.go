@@ -4,6 +4,7 @@ import (
 	"context"
 	"time"
 
+	"habit-tracker/internal/events"
 	"habit-tracker/internal/models"
 	"habit-tracker/internal/repository"
 )
@@ -15,12 +16,14 @@ type AchievementMetrics struct {
 }
 
 type AchievementService struct {
-	achievements *repository.AchievementRepository
-	userAch      *repository.UserAchievementRepository
+	achievements repository.AchievementStore
+	userAch      repository.UserAchievementStore
+	users        repository.UserStore
+	bus          *events.Bus
 }
 
-func NewAchievementService(ach *repository.AchievementRepository, userAch *repository.UserAchievementRepository) *AchievementService {
-	return &AchievementService{achievements: ach, userAch: userAch}
+func NewAchievementService(ach repository.AchievementStore, userAch repository.UserAchievementStore, users repository.UserStore, bus *events.Bus) *AchievementService {
+	return &AchievementService{achievements: ach, userAch: userAch, users: users, bus: bus}
 }
 
 func (s *AchievementService) ListAll(ctx context.Context) ([]models.Achievement, error) {
@@ -31,7 +34,35 @@ func (s *AchievementService) ListByUser(ctx context.Context, userID uint64) ([]m
 	return s.userAch.ListByUser(ctx, userID)
 }
 
-// EvaluateAndUnlock checks achievements and inserts newly unlocked ones.
+// OnCheckinRecorded evaluates achievements after every check-in of a build habit.
+func (s *AchievementService) OnCheckinRecorded(ctx context.Context, e events.CheckinRecorded) error {
+	return s.evaluate(ctx, e.UserID, e.StreakDays, e.TotalCheckins)
+}
+
+// OnTargetReached evaluates the clean days of quit habits, which have no
+// check-ins; build habits are already covered by OnCheckinRecorded.
+func (s *AchievementService) OnTargetReached(ctx context.Context, e events.TargetReached) error {
+	if e.Polarity != models.HabitPolarityQuit {
+		return nil
+	}
+	return s.evaluate(ctx, e.UserID, e.StreakDays, e.TotalCheckins)
+}
+
+func (s *AchievementService) evaluate(ctx context.Context, userID uint64, streak, total int) error {
+	user, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	_, err = s.EvaluateAndUnlock(ctx, userID, AchievementMetrics{
+		CurrentStreakDays: streak,
+		TotalCheckins:     total,
+		TotalPoints:       user.Points,
+	})
+	return err
+}
+
+// EvaluateAndUnlock checks achievements and inserts newly unlocked ones,
+// publishing AchievementUnlocked for each.
 func (s *AchievementService) EvaluateAndUnlock(ctx context.Context, userID uint64, m AchievementMetrics) ([]models.UserAchievement, error) {
 	all, err := s.achievements.ListAll(ctx)
 	if err != nil {
@@ -64,6 +95,16 @@ func (s *AchievementService) EvaluateAndUnlock(ctx context.Context, userID uint6
 			return newly, err
 		}
 		newly = append(newly, ua)
+		if err := s.bus.Publish(ctx, events.AchievementUnlocked{
+			UserID:            userID,
+			UserAchievementID: ua.ID,
+			AchievementID:     ach.ID,
+			Code:              ach.Code,
+			Title:             ach.Name,
+			UnlockedAt:        ua.UnlockedAt,
+		}); err != nil {
+			return newly, err
+		}
 	}
 	return newly, nil
 }
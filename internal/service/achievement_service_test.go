@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"habit-tracker/internal/events"
+	"habit-tracker/internal/models"
+)
+
+func TestEvaluateAndUnlock(t *testing.T) {
+	env := newTestEnv(t)
+	u := env.user(t, "alice")
+	streak := env.achievement(t, "streak_7", "streak_days", 7)
+	total := env.achievement(t, "total_10", "total_checkins", 10)
+	rich := env.achievement(t, "points_100", "points", 100)
+	env.achievement(t, "unknown", "moon_phase", 0)
+
+	var published []events.AchievementUnlocked
+	events.Subscribe(env.bus, func(ctx context.Context, e events.AchievementUnlocked) error {
+		published = append(published, e)
+		return nil
+	})
+
+	newly, err := env.achSvc.EvaluateAndUnlock(env.ctx, u.ID, AchievementMetrics{CurrentStreakDays: 7, TotalCheckins: 9, TotalPoints: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(newly) != 2 || newly[0].AchievementID != streak.ID || newly[1].AchievementID != rich.ID {
+		t.Fatalf("unlocked = %+v, want %q and %q", newly, streak.Code, rich.Code)
+	}
+	if len(published) != 2 || published[0].Code != streak.Code || published[0].UserAchievementID != newly[0].ID {
+		t.Fatalf("published = %+v, want one event per unlock", published)
+	}
+
+	newly, err = env.achSvc.EvaluateAndUnlock(env.ctx, u.ID, AchievementMetrics{CurrentStreakDays: 8, TotalCheckins: 10, TotalPoints: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(newly) != 1 || newly[0].AchievementID != total.ID {
+		t.Fatalf("second evaluation unlocked %+v, want only %q", newly, total.Code)
+	}
+	owned, err := env.achSvc.ListByUser(env.ctx, u.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(owned) != 3 || len(published) != 3 {
+		t.Fatalf("user has %d achievements and %d events, want 3 each", len(owned), len(published))
+	}
+}
+
+func TestAchievementPointsConditionUsesBalance(t *testing.T) {
+	env := newTestEnv(t)
+	u := env.user(t, "alice")
+	rich := env.achievement(t, "points_5", "points", 5)
+
+	if err := env.achSvc.OnCheckinRecorded(env.ctx, events.CheckinRecorded{UserID: u.ID}); err != nil {
+		t.Fatal(err)
+	}
+	if owned, _ := env.achSvc.ListByUser(env.ctx, u.ID); len(owned) != 0 {
+		t.Fatalf("unlocked %+v without points", owned)
+	}
+	if err := env.pointsSvc.AddPoints(env.ctx, u.ID, 5, "import", nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := env.achSvc.OnCheckinRecorded(env.ctx, events.CheckinRecorded{UserID: u.ID}); err != nil {
+		t.Fatal(err)
+	}
+	owned, err := env.achSvc.ListByUser(env.ctx, u.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(owned) != 1 || owned[0].AchievementID != rich.ID {
+		t.Fatalf("unlocked = %+v, want %q", owned, rich.Code)
+	}
+}
+
+func TestAchievementOnTargetReachedOnlyForQuitHabits(t *testing.T) {
+	env := newTestEnv(t)
+	u := env.user(t, "alice")
+	env.achievement(t, "clean_3", "streak_days", 3)
+
+	build := events.TargetReached{UserID: u.ID, Polarity: models.HabitPolarityBuild, StreakDays: 3}
+	if err := env.achSvc.OnTargetReached(env.ctx, build); err != nil {
+		t.Fatal(err)
+	}
+	if owned, _ := env.achSvc.ListByUser(env.ctx, u.ID); len(owned) != 0 {
+		t.Fatalf("build habit target unlocked %+v; OnCheckinRecorded covers build habits", owned)
+	}
+
+	quit := events.TargetReached{UserID: u.ID, Polarity: models.HabitPolarityQuit, StreakDays: 3}
+	if err := env.achSvc.OnTargetReached(env.ctx, quit); err != nil {
+		t.Fatal(err)
+	}
+	if owned, _ := env.achSvc.ListByUser(env.ctx, u.ID); len(owned) != 1 {
+		t.Fatalf("quit habit clean days unlocked %d achievements, want 1", len(owned))
+	}
+}
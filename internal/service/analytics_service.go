@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"habit-tracker/internal/apperr"
+	"habit-tracker/internal/repository"
+)
+
+const maxSeriesBuckets = 366
+
+// defaultSeriesBuckets is how many buckets a series covers when no start date is given.
+var defaultSeriesBuckets = map[string]int{
+	repository.BucketDay:   30,
+	repository.BucketWeek:  12,
+	repository.BucketMonth: 12,
+}
+
+type AnalyticsService struct {
+	profiles *ProfileService
+	points   repository.PointsStore
+	checkins repository.CheckinStore
+}
+
+func NewAnalyticsService(profiles *ProfileService, points repository.PointsStore, checkins repository.CheckinStore) *AnalyticsService {
+	return &AnalyticsService{profiles: profiles, points: points, checkins: checkins}
+}
+
+// SeriesQuery selects a time series. Dates are calendar days in Timezone,
+// which defaults to the user's stored time zone.
+type SeriesQuery struct {
+	Granularity string // day, week or month; defaults to day
+	StartDate   *time.Time
+	EndDate     *time.Time
+	Timezone    string
+}
+
+// Series holds zero-filled, aligned arrays: index i of every array belongs to
+// the bucket starting on Buckets[i]. CumulativePoints is the points balance
+// at the end of each bucket.
+type Series struct {
+	Granularity      string   `json:"granularity"`
+	Timezone         string   `json:"timezone"`
+	Buckets          []string `json:"buckets"`
+	Points           []int64  `json:"points"`
+	CumulativePoints []int64  `json:"cumulative_points"`
+	Checkins         []int64  `json:"checkins"`
+}
+
+func (s *AnalyticsService) Series(ctx context.Context, userID uint64, q SeriesQuery) (*Series, error) {
+	unit := q.Granularity
+	if unit == "" {
+		unit = repository.BucketDay
+	}
+	if _, ok := defaultSeriesBuckets[unit]; !ok {
+		return nil, apperr.Invalid("granularity must be day, week or month")
+	}
+	tzName := q.Timezone
+	if tzName == "" {
+		user, err := s.profiles.Get(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		tzName = user.Timezone
+	}
+	tzName, loc, err := loadTimezone(tzName)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().In(loc)
+	end := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	if q.EndDate != nil {
+		end = inLocation(*q.EndDate, loc)
+	}
+	last := truncateBucket(end, unit)
+	first := advanceBucket(last, unit, -(defaultSeriesBuckets[unit] - 1))
+	if q.StartDate != nil {
+		first = truncateBucket(inLocation(*q.StartDate, loc), unit)
+	}
+	if first.After(last) {
+		return nil, apperr.Invalid("start_date must not be after end_date")
+	}
+
+	series := &Series{Granularity: unit, Timezone: tzName}
+	index := map[int]int{}
+	for b := first; !b.After(last); b = advanceBucket(b, unit, 1) {
+		if len(series.Buckets) == maxSeriesBuckets {
+			return nil, apperr.Invalid("too many buckets, narrow the date range")
+		}
+		index[dayKey(b)] = len(series.Buckets)
+		series.Buckets = append(series.Buckets, b.Format("2006-01-02"))
+	}
+	n := len(series.Buckets)
+	series.Points = make([]int64, n)
+	series.CumulativePoints = make([]int64, n)
+	series.Checkins = make([]int64, n)
+	until := advanceBucket(last, unit, 1)
+
+	pointRows, err := s.points.SumByBucket(ctx, userID, unit, tzName, first, until)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range pointRows {
+		if i, ok := index[dayKey(row.Start)]; ok {
+			series.Points[i] = row.Total
+		}
+	}
+	balance, err := s.points.SumBefore(ctx, userID, first)
+	if err != nil {
+		return nil, err
+	}
+	for i, p := range series.Points {
+		balance += p
+		series.CumulativePoints[i] = balance
+	}
+
+	// checkin_date 本身就是日期，不需要再做时区换算
+	checkinRows, err := s.checkins.SumCountByBucket(ctx, userID, unit, calendarDate(first), calendarDate(until))
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range checkinRows {
+		if i, ok := index[dayKey(row.Start)]; ok {
+			series.Checkins[i] = row.Total
+		}
+	}
+	return series, nil
+}
+
+// inLocation keeps the calendar date of t and moves it to midnight in loc.
+func inLocation(t time.Time, loc *time.Location) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+}
+
+// calendarDate is the same calendar date as t at midnight UTC, the form used
+// for comparisons with date columns.
+func calendarDate(t time.Time) time.Time {
+	return inLocation(t, time.UTC)
+}
+
+func truncateBucket(t time.Time, unit string) time.Time {
+	switch unit {
+	case repository.BucketWeek:
+		return startOfWeek(t)
+	case repository.BucketMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	}
+	return t
+}
+
+func advanceBucket(t time.Time, unit string, n int) time.Time {
+	switch unit {
+	case repository.BucketWeek:
+		return t.AddDate(0, 0, 7*n)
+	case repository.BucketMonth:
+		return t.AddDate(0, n, 0)
+	}
+	return t.AddDate(0, 0, n)
+}
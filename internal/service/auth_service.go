@@ -7,26 +7,33 @@ import (
 
 	"gorm.io/gorm"
 
+	"habit-tracker/internal/apperr"
 	"habit-tracker/internal/models"
 	"habit-tracker/internal/repository"
 	"habit-tracker/internal/utils"
 )
 
 var (
-	ErrUserExists         = errors.New("user already exists")
-	ErrInvalidCredentials = errors.New("invalid username or password")
+	ErrUserExists         = apperr.New(apperr.KindConflict, "user_exists", "username already exists")
+	ErrInvalidCredentials = apperr.New(apperr.KindUnauthorized, "invalid_credentials", "invalid username or password")
 )
 
 type AuthService struct {
-	userRepo   *repository.UserRepository
+	userRepo   repository.UserStore
 	jwtManager *utils.JWTManager
 }
 
-func NewAuthService(userRepo *repository.UserRepository, jwtManager *utils.JWTManager) *AuthService {
+func NewAuthService(userRepo repository.UserStore, jwtManager *utils.JWTManager) *AuthService {
 	return &AuthService{userRepo: userRepo, jwtManager: jwtManager}
 }
 
-func (s *AuthService) Register(ctx context.Context, username, password, nickname string) (*models.User, error) {
+// Register creates a user. timezone is an IANA name; empty means UTC.
+func (s *AuthService) Register(ctx context.Context, username, password, nickname, timezone string) (*models.User, error) {
+	if timezone != "" {
+		if _, _, err := loadTimezone(timezone); err != nil {
+			return nil, err
+		}
+	}
 	if _, err := s.userRepo.GetByUsername(ctx, username); err == nil { // User already exists
 		return nil, ErrUserExists
 	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
@@ -42,6 +49,7 @@ func (s *AuthService) Register(ctx context.Context, username, password, nickname
 		Username:     username,
 		PasswordHash: hashed,
 		Nickname:     nickname,
+		Timezone:     timezone,
 		CreatedAt:    time.Now(),
 	}
 
@@ -71,3 +79,20 @@ func (s *AuthService) Login(ctx context.Context, username, password string) (str
 
 	return token, user, nil
 }
+
+// ResetPassword sets a new password for username, for administrators.
+// Tokens issued before stay valid until they expire.
+func (s *AuthService) ResetPassword(ctx context.Context, username, password string) error {
+	if password == "" {
+		return apperr.Invalid("password is required")
+	}
+	user, err := s.userRepo.GetByUsername(ctx, username)
+	if err != nil {
+		return err
+	}
+	hashed, err := utils.HashPassword(password)
+	if err != nil {
+		return err
+	}
+	return s.userRepo.UpdatePasswordHash(ctx, user.ID, hashed)
+}
@@ -0,0 +1,201 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"habit-tracker/internal/apperr"
+	"habit-tracker/internal/models"
+	"habit-tracker/internal/repository"
+)
+
+// CalendarHistoryDays is how far back completed check-ins appear in the feed.
+const CalendarHistoryDays = 365
+
+var ErrCalendarFeedNotFound = apperr.New(apperr.KindNotFound, "calendar_feed_not_found", "calendar feed not found")
+
+// CalendarService serves a per-user iCalendar feed behind a secret token.
+// Only the SHA-256 of the token is stored, so a token is shown once when it
+// is created; rotating it invalidates the old URL.
+type CalendarService struct {
+	users    repository.UserStore
+	habits   repository.HabitStore
+	checkins repository.CheckinStore
+}
+
+func NewCalendarService(users repository.UserStore, habits repository.HabitStore, checkins repository.CheckinStore) *CalendarService {
+	return &CalendarService{users: users, habits: habits, checkins: checkins}
+}
+
+// CalendarFeed reports the feed state; Token is only set right after Rotate.
+type CalendarFeed struct {
+	Enabled bool   `json:"enabled"`
+	Token   string `json:"token,omitempty"`
+}
+
+func (s *CalendarService) Status(ctx context.Context, userID uint64) (*CalendarFeed, error) {
+	user, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &CalendarFeed{Enabled: user.CalendarTokenHash != nil}, nil
+}
+
+// Rotate enables the feed with a fresh token, killing any previous URL.
+func (s *CalendarService) Rotate(ctx context.Context, userID uint64) (*CalendarFeed, error) {
+	token := randomHex(24)
+	hash := hashCalendarToken(token)
+	if err := s.users.SetCalendarTokenHash(ctx, userID, &hash); err != nil {
+		return nil, err
+	}
+	return &CalendarFeed{Enabled: true, Token: token}, nil
+}
+
+func (s *CalendarService) Disable(ctx context.Context, userID uint64) error {
+	return s.users.SetCalendarTokenHash(ctx, userID, nil)
+}
+
+// Feed renders the calendar for token: one recurring all-day event per
+// tracked build habit following its schedule, and one all-day event per day
+// a habit's target was reached within CalendarHistoryDays.
+func (s *CalendarService) Feed(ctx context.Context, token string) ([]byte, error) {
+	if token == "" {
+		return nil, ErrCalendarFeedNotFound
+	}
+	user, err := s.users.GetByCalendarTokenHash(ctx, hashCalendarToken(token))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCalendarFeedNotFound
+		}
+		return nil, err
+	}
+	habits, err := s.habits.ListByUser(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	today := userDay(user, time.Now())
+	records, err := s.checkins.ListByUserAndDateRange(ctx, user.ID, today.AddDate(0, 0, -(CalendarHistoryDays-1)), today)
+	if err != nil {
+		return nil, err
+	}
+
+	name := user.Nickname
+	if name == "" {
+		name = user.Username
+	}
+	stamp := time.Now().UTC().Format(icalDateTime)
+	w := &icalWriter{}
+	w.line("BEGIN", "VCALENDAR")
+	w.line("VERSION", "2.0")
+	w.line("PRODID", "-//habit-tracker//calendar feed//EN")
+	w.line("CALSCALE", "GREGORIAN")
+	w.line("METHOD", "PUBLISH")
+	w.text("X-WR-CALNAME", name+" 的习惯")
+	if user.Timezone != "" {
+		w.text("X-WR-TIMEZONE", user.Timezone)
+	}
+	w.line("REFRESH-INTERVAL;VALUE=DURATION", "PT1H")
+
+	byID := make(map[uint64]*models.Habit, len(habits))
+	for i := range habits {
+		h := &habits[i]
+		byID[h.ID] = h
+		if h.Polarity == models.HabitPolarityQuit || !h.IsActive {
+			continue // 戒除型习惯没有计划日，暂停的习惯不再安排
+		}
+		writeScheduleEvent(w, h, stamp)
+	}
+	for _, rec := range records {
+		h, ok := byID[rec.HabitID]
+		if !ok || h.Polarity == models.HabitPolarityQuit || !goalOf(h).met(rec) {
+			continue
+		}
+		writeCompletionEvent(w, h, rec, stamp)
+	}
+	w.line("END", "VCALENDAR")
+	return w.Bytes(), nil
+}
+
+// writeScheduleEvent emits the habit as a recurring event. DTSTART is moved
+// to the first due day so it is itself an occurrence of the rule; archived
+// habits stop recurring on the archive date.
+func writeScheduleEvent(w *icalWriter, h *models.Habit, stamp string) {
+	schedule := scheduleOf(h)
+	if schedule == 0 {
+		return
+	}
+	first := h.StartDate
+	for !schedule.Has(first.Weekday()) {
+		first = first.AddDate(0, 0, 1)
+	}
+	rule := "FREQ=DAILY"
+	if schedule != models.AllWeekdays {
+		days := make([]string, 0, 7)
+		for _, d := range schedule.Days() {
+			days = append(days, icalWeekdays[d])
+		}
+		rule = "FREQ=WEEKLY;BYDAY=" + strings.Join(days, ",")
+	}
+	if h.ArchivedAt != nil {
+		if dayKey(*h.ArchivedAt) < dayKey(first) {
+			return
+		}
+		rule += ";UNTIL=" + h.ArchivedAt.Format(icalDate)
+	}
+
+	w.line("BEGIN", "VEVENT")
+	w.line("UID", fmt.Sprintf("habit-%d@habit-tracker", h.ID))
+	w.line("DTSTAMP", stamp)
+	w.date("DTSTART", first)
+	w.date("DTEND", first.AddDate(0, 0, 1))
+	w.line("RRULE", rule)
+	w.text("SUMMARY", h.Name+goalLabel(h))
+	if h.Description != "" {
+		w.text("DESCRIPTION", h.Description)
+	}
+	w.line("TRANSP", "TRANSPARENT")
+	w.line("END", "VEVENT")
+}
+
+func writeCompletionEvent(w *icalWriter, h *models.Habit, rec models.HabitCheckin, stamp string) {
+	desc := fmt.Sprintf("完成 %d 次", rec.Count)
+	if h.Kind == models.HabitKindMeasurable {
+		desc = "完成 " + strconv.FormatFloat(rec.Quantity, 'f', -1, 64) + " " + h.Unit
+	}
+	if rec.Note != "" {
+		desc += "\n" + rec.Note
+	}
+
+	w.line("BEGIN", "VEVENT")
+	w.line("UID", fmt.Sprintf("checkin-%d@habit-tracker", rec.ID))
+	w.line("DTSTAMP", stamp)
+	w.date("DTSTART", rec.CheckinDate)
+	w.date("DTEND", rec.CheckinDate.AddDate(0, 0, 1))
+	w.text("SUMMARY", "✓ "+h.Name)
+	w.text("DESCRIPTION", desc)
+	w.line("TRANSP", "TRANSPARENT")
+	w.line("END", "VEVENT")
+}
+
+func goalLabel(h *models.Habit) string {
+	if h.Kind == models.HabitKindMeasurable {
+		return " (" + strconv.FormatFloat(h.TargetQuantity, 'f', -1, 64) + " " + h.Unit + ")"
+	}
+	if h.TargetTimes > 1 {
+		return fmt.Sprintf(" (×%d)", h.TargetTimes)
+	}
+	return ""
+}
+
+func hashCalendarToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
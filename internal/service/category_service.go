@@ -0,0 +1,173 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"habit-tracker/internal/apperr"
+	"habit-tracker/internal/models"
+	"habit-tracker/internal/repository"
+)
+
+const (
+	maxTagLength = 64
+	maxHabitTags = 20
+)
+
+var (
+	ErrCategoryNotFound  = apperr.New(apperr.KindNotFound, "category_not_found", "category not found")
+	ErrCategoryForbidden = apperr.New(apperr.KindForbidden, "category_forbidden", "category does not belong to user")
+	ErrCategoryExists    = apperr.New(apperr.KindConflict, "category_exists", "category already exists")
+	ErrTagNotFound       = apperr.New(apperr.KindNotFound, "tag_not_found", "tag not found")
+	ErrTagForbidden      = apperr.New(apperr.KindForbidden, "tag_forbidden", "tag does not belong to user")
+
+	colorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+)
+
+type CategoryService struct {
+	categories repository.CategoryStore
+	tags       repository.TagStore
+}
+
+func NewCategoryService(categories repository.CategoryStore, tags repository.TagStore) *CategoryService {
+	return &CategoryService{categories: categories, tags: tags}
+}
+
+type CategoryInput struct {
+	Name      string
+	Color     string
+	SortOrder int
+}
+
+func (s *CategoryService) List(ctx context.Context, userID uint64) ([]models.HabitCategory, error) {
+	return s.categories.ListByUser(ctx, userID)
+}
+
+func (s *CategoryService) Create(ctx context.Context, userID uint64, in CategoryInput) (*models.HabitCategory, error) {
+	if err := s.validate(ctx, userID, 0, in); err != nil {
+		return nil, err
+	}
+	category := &models.HabitCategory{
+		UserID:    userID,
+		Name:      strings.TrimSpace(in.Name),
+		Color:     in.Color,
+		SortOrder: in.SortOrder,
+	}
+	if err := s.categories.Create(ctx, category); err != nil {
+		return nil, err
+	}
+	return category, nil
+}
+
+func (s *CategoryService) Update(ctx context.Context, userID, categoryID uint64, in CategoryInput) (*models.HabitCategory, error) {
+	category, err := getOwnedCategory(ctx, s.categories, userID, categoryID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.validate(ctx, userID, categoryID, in); err != nil {
+		return nil, err
+	}
+	category.Name = strings.TrimSpace(in.Name)
+	category.Color = in.Color
+	category.SortOrder = in.SortOrder
+	if err := s.categories.Update(ctx, category); err != nil {
+		return nil, err
+	}
+	return category, nil
+}
+
+// Delete removes the category; its habits become uncategorized.
+func (s *CategoryService) Delete(ctx context.Context, userID, categoryID uint64) error {
+	if _, err := getOwnedCategory(ctx, s.categories, userID, categoryID); err != nil {
+		return err
+	}
+	return s.categories.Delete(ctx, categoryID)
+}
+
+func (s *CategoryService) ListTags(ctx context.Context, userID uint64) ([]models.HabitTag, error) {
+	return s.tags.ListByUser(ctx, userID)
+}
+
+// DeleteTag removes the tag from every habit of the user.
+func (s *CategoryService) DeleteTag(ctx context.Context, userID, tagID uint64) error {
+	tag, err := s.tags.GetByID(ctx, tagID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrTagNotFound
+		}
+		return err
+	}
+	if tag.UserID != userID {
+		return ErrTagForbidden
+	}
+	return s.tags.Delete(ctx, tagID)
+}
+
+func (s *CategoryService) validate(ctx context.Context, userID, selfID uint64, in CategoryInput) error {
+	name := strings.TrimSpace(in.Name)
+	if name == "" {
+		return apperr.Invalid("name is required")
+	}
+	if err := validateColor(in.Color); err != nil {
+		return err
+	}
+	existing, err := s.categories.ListByUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for _, c := range existing {
+		if c.ID != selfID && c.Name == name {
+			return ErrCategoryExists
+		}
+	}
+	return nil
+}
+
+func getOwnedCategory(ctx context.Context, repo repository.CategoryStore, userID, categoryID uint64) (*models.HabitCategory, error) {
+	category, err := repo.GetByID(ctx, categoryID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCategoryNotFound
+		}
+		return nil, err
+	}
+	if category.UserID != userID {
+		return nil, ErrCategoryForbidden
+	}
+	return category, nil
+}
+
+func validateColor(color string) error {
+	if color != "" && !colorPattern.MatchString(color) {
+		return apperr.Invalid("color must look like #RRGGBB")
+	}
+	return nil
+}
+
+// normalizeTagNames trims names and drops blanks and duplicates, keeping order.
+func normalizeTagNames(names []string) ([]string, error) {
+	out := make([]string, 0, len(names))
+	seen := make(map[string]struct{}, len(names))
+	for _, n := range names {
+		n = strings.TrimSpace(n)
+		if n == "" {
+			continue
+		}
+		if len(n) > maxTagLength {
+			return nil, apperr.Invalid("tag is too long")
+		}
+		if _, ok := seen[n]; ok {
+			continue
+		}
+		seen[n] = struct{}{}
+		out = append(out, n)
+	}
+	if len(out) > maxHabitTags {
+		return nil, apperr.Invalid("too many tags")
+	}
+	return out, nil
+}
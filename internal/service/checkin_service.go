@@ -3,11 +3,13 @@ package service
 import (
 	"context"
 	"errors"
-	"time"
 	"log"
+	"time"
 
 	"gorm.io/gorm"
 
+	"habit-tracker/internal/apperr"
+	"habit-tracker/internal/events"
 	"habit-tracker/internal/models"
 	"habit-tracker/internal/repository"
 )
@@ -15,90 +17,170 @@ import (
 const baseCheckinPoints = 1
 
 type CheckinService struct {
-	habitRepo          *repository.HabitRepository
-	userRepo           *repository.UserRepository
-	checkinRepo        *repository.CheckinRepository
-	pointService       *PointsService
-	achievementService *AchievementService
+	habitRepo   repository.HabitStore
+	userRepo    repository.UserStore
+	checkinRepo repository.CheckinStore
+	guard       *StreakGuardService
+	bus         *events.Bus
 }
 
 var (
-	ErrCheckinForbidden = errors.New("habit does not belong to user")
-	ErrHabitMissing     = gorm.ErrRecordNotFound
+	ErrCheckinForbidden = ErrHabitForbidden
+	ErrHabitMissing     = ErrHabitNotFound
 )
 
+// CheckinInput is one check-in: count habits use CountInc, measurable habits use Quantity.
+// Note and Mood are optional journal fields and overwrite today's values when set.
+type CheckinInput struct {
+	CountInc int
+	Quantity float64
+	Note     string
+	Mood     *int
+}
+
 type CheckinResult struct {
+	CheckinID      uint64
 	TodayCount     int
+	TodayQuantity  float64
 	ReachedTarget  bool
 	StreakDays     int
 	TotalCheckins  int
 	PointsAwarded  int
+	FreezeEarned   bool
 	UnlockedAwards []models.UserAchievement
 }
 
-func NewCheckinService(habitRepo *repository.HabitRepository, users *repository.UserRepository, checkins *repository.CheckinRepository, points *PointsService, achievements *AchievementService) *CheckinService {
-	return &CheckinService{habitRepo: habitRepo, userRepo: users, checkinRepo: checkins, pointService: points, achievementService: achievements}
+func NewCheckinService(habitRepo repository.HabitStore, users repository.UserStore, checkins repository.CheckinStore, guard *StreakGuardService, bus *events.Bus) *CheckinService {
+	return &CheckinService{habitRepo: habitRepo, userRepo: users, checkinRepo: checkins, guard: guard, bus: bus}
 }
 
-func (s *CheckinService) Checkin(ctx context.Context, userID, habitID uint64, countInc int) (*CheckinResult, error) {
-	if countInc <= 0 {
-		return nil, errors.New("check-in count must be greater than 0")
-	}
-
+func (s *CheckinService) Checkin(ctx context.Context, userID, habitID uint64, in CheckinInput) (*CheckinResult, error) {
 	habit, err := s.getOwnedHabit(ctx, userID, habitID)
 	if err != nil {
 		return nil, err
 	}
+	if habit.ArchivedAt != nil {
+		return nil, ErrHabitArchived
+	}
+	if habit.Polarity == models.HabitPolarityQuit {
+		return nil, ErrQuitHabitCheckin
+	}
+	if err := validateNote(in.Note); err != nil {
+		return nil, err
+	}
+	if err := validateMood(in.Mood); err != nil {
+		return nil, err
+	}
+
+	// 计量型习惯每次打卡记一次次数，并累加数量
+	if habit.Kind == models.HabitKindMeasurable {
+		if in.Quantity <= 0 {
+			return nil, apperr.Invalid("check-in quantity must be greater than 0")
+		}
+		in.CountInc = 1
+	} else {
+		if in.CountInc <= 0 {
+			return nil, apperr.Invalid("check-in count must be greater than 0")
+		}
+		in.Quantity = 0
+	}
 
-	today := todayDate()
-	if err := s.upsertToday(ctx, userID, habitID, countInc, today); err != nil {
+	today, err := s.guard.todayOf(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	todayRec, before, err := s.upsertToday(ctx, userID, habitID, in, today)
+	if err != nil {
 		return nil, err
 	}
 
-	todayRec, err := s.checkinRepo.GetByHabitAndDate(ctx, habitID, today)
+	shield, err := s.guard.protect(ctx, habit, today)
 	if err != nil {
 		return nil, err
 	}
 
-	reached := todayRec.Count == habit.TargetTimes // 不考虑超量完成
-	var pointsAwarded int
+	reached := reachedNow(habit, before, todayRec)
 	if reached {
-		pointsAwarded, err = s.awardPoints(ctx, userID, habitID)
-		if err != nil {
+		if err := s.advanceStreak(ctx, habit, today, shield); err != nil {
 			return nil, err
 		}
-		
-		if err := s.userRepo.IncrementCheckins(ctx, userID, 1); err != nil {
-			return nil, err
-		}
-		log.Printf("用户 %d 完成习惯 %d 当日目标，奖励积分 %d", userID, habitID, pointsAwarded)
 	}
-
-	streak := s.calculateStreak(ctx, habitID, habit.TargetTimes, today)
+	streak := currentStreak(habit, today, shield)
 	totalCheckins, err := s.checkinRepo.SumCountByHabit(ctx, habitID)
 	if err != nil {
 		return nil, err
 	}
-	totalPoints, err := s.pointService.GetUserPoints(ctx, userID)
-	if err != nil {
+
+	// 积分、成就等副作用由事件订阅者完成，结果从记录的事件中汇总
+	ctx, recorded := events.WithRecorder(ctx)
+	if reached {
+		if err := s.userRepo.IncrementCheckins(ctx, userID, 1); err != nil {
+			return nil, err
+		}
+		if err := s.bus.Publish(ctx, events.TargetReached{
+			UserID:        userID,
+			HabitID:       habitID,
+			HabitName:     habit.Name,
+			Polarity:      habit.Polarity,
+			Date:          today,
+			StreakDays:    streak,
+			TotalCheckins: int(totalCheckins),
+		}); err != nil {
+			return nil, err
+		}
+	}
+	var freezeEarned bool
+	if reached && streak > 0 && streak%FreezeEarnStreak == 0 {
+		freezeEarned, err = s.guard.earn(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := s.bus.Publish(ctx, events.CheckinRecorded{
+		UserID:        userID,
+		HabitID:       habitID,
+		HabitName:     habit.Name,
+		CheckinID:     todayRec.ID,
+		Date:          today,
+		CountInc:      in.CountInc,
+		QuantityInc:   in.Quantity,
+		TodayCount:    todayRec.Count,
+		TodayQuantity: todayRec.Quantity,
+		ReachedTarget: reached,
+		StreakDays:    streak,
+		TotalCheckins: int(totalCheckins),
+	}); err != nil {
 		return nil, err
 	}
 
-	newly, err := s.achievementService.EvaluateAndUnlock(ctx, userID, AchievementMetrics{
-		CurrentStreakDays: streak,
-		TotalCheckins:     int(totalCheckins),
-		TotalPoints:       totalPoints,
-	})
-	if err != nil {
-		return nil, err
+	var pointsAwarded int
+	for _, e := range events.Recorded[events.PointsChanged](recorded) {
+		if e.HabitID != nil && *e.HabitID == habitID {
+			pointsAwarded += int(e.Delta)
+		}
+	}
+	var newly []models.UserAchievement
+	for _, e := range events.Recorded[events.AchievementUnlocked](recorded) {
+		newly = append(newly, models.UserAchievement{
+			ID:            e.UserAchievementID,
+			UserID:        e.UserID,
+			AchievementID: e.AchievementID,
+			UnlockedAt:    e.UnlockedAt,
+		})
+	}
+	if reached {
+		log.Printf("用户 %d 完成习惯 %d 当日目标，奖励积分 %d", userID, habitID, pointsAwarded)
 	}
 
 	return &CheckinResult{
 		TodayCount:     todayRec.Count,
+		TodayQuantity:  todayRec.Quantity,
+		CheckinID:      todayRec.ID,
 		ReachedTarget:  reached,
 		StreakDays:     streak,
 		TotalCheckins:  int(totalCheckins),
 		PointsAwarded:  pointsAwarded,
+		FreezeEarned:   freezeEarned,
 		UnlockedAwards: newly,
 	}, nil
 }
@@ -106,6 +188,9 @@ func (s *CheckinService) Checkin(ctx context.Context, userID, habitID uint64, co
 func (s *CheckinService) getOwnedHabit(ctx context.Context, userID, habitID uint64) (*models.Habit, error) {
 	habit, err := s.habitRepo.GetByID(ctx, habitID)
 	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrHabitMissing
+		}
 		return nil, err
 	}
 	if habit.UserID != userID {
@@ -114,63 +199,75 @@ func (s *CheckinService) getOwnedHabit(ctx context.Context, userID, habitID uint
 	return habit, nil
 }
 
-func todayDate() time.Time {
-	return time.Now().In(time.Local).Truncate(24 * time.Hour)
+// reachedNow reports whether this check-in is the one that crossed today's target,
+// so points are awarded once per day. before and todayRec come from the same
+// locked upsert, so concurrent check-ins never both see the crossing.
+func reachedNow(habit *models.Habit, before repository.CheckinTotals, todayRec *models.HabitCheckin) bool {
+	if habit.Kind == models.HabitKindMeasurable {
+		return before.Quantity < habit.TargetQuantity && todayRec.Quantity >= habit.TargetQuantity
+	}
+	return before.Count < int64(habit.TargetTimes) && todayRec.Count >= habit.TargetTimes // 不考虑超量完成
 }
 
-func (s *CheckinService) upsertToday(ctx context.Context, userID, habitID uint64, countInc int, today time.Time) error {
+// upsertToday adds the check-in to today's record and returns the stored
+// record with the totals it had before.
+func (s *CheckinService) upsertToday(ctx context.Context, userID, habitID uint64, in CheckinInput, today time.Time) (*models.HabitCheckin, repository.CheckinTotals, error) {
 	rec := &models.HabitCheckin{
 		HabitID:     habitID,
 		UserID:      userID,
 		CheckinDate: today,
-		Count:       countInc,
+		Count:       in.CountInc,
+		Quantity:    in.Quantity,
+		Note:        in.Note,
+		Mood:        in.Mood,
 		CreatedAt:   time.Now(),
 	}
-	return s.checkinRepo.Upsert(ctx, rec)
-}
-
-func (s *CheckinService) awardPoints(ctx context.Context, userID, habitID uint64) (int, error) {
-	delta := int64(baseCheckinPoints)
-	if err := s.pointService.AddPoints(ctx, userID, delta, "checkin", &habitID); err != nil {
-		return 0, err
+	before, err := s.checkinRepo.Upsert(ctx, rec)
+	if err != nil {
+		return nil, before, err
 	}
-	return int(delta), nil
+	return rec, before, nil
 }
 
+// ListHistory lists a habit's check-ins between start and end. A zero end is
+// the user's today and a zero start is 30 days before end.
 func (s *CheckinService) ListHistory(ctx context.Context, userID, habitID uint64, start, end time.Time) ([]models.HabitCheckin, error) {
-	habit, err := s.habitRepo.GetByID(ctx, habitID)
-	if err != nil {
+	if _, err := s.getOwnedHabit(ctx, userID, habitID); err != nil {
 		return nil, err
 	}
-	if habit.UserID != userID {
-		return nil, ErrCheckinForbidden
+	if end.IsZero() {
+		today, err := s.guard.todayOf(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		end = today
+	}
+	if start.IsZero() {
+		start = end.AddDate(0, 0, -30)
 	}
 	return s.checkinRepo.ListByHabitAndDateRange(ctx, habitID, start, end)
 }
 
-func (s *CheckinService) calculateStreak(ctx context.Context, habitID uint64, targetTimes int, today time.Time) int {
-	records, err := s.checkinRepo.ListByHabitDesc(ctx, habitID)
-	if err != nil {
-		return 0
+// countConsecutiveFromToday counts completed days back from today. Days the
+// shield covers are skipped without breaking the streak.
+func countConsecutiveFromToday(records []models.HabitCheckin, goal habitGoal, today time.Time, shield streakShield) int {
+	met := metDays(records, goal)
+	earliest := today
+	for _, rec := range records {
+		if rec.CheckinDate.Before(earliest) {
+			earliest = rec.CheckinDate
+		}
 	}
-	return countConsecutiveFromToday(records, targetTimes, today)
-}
 
-func countConsecutiveFromToday(records []models.HabitCheckin, targetTimes int, today time.Time) int {
 	streak := 0
-	expected := today
-	for _, rec := range records {
-		if rec.CheckinDate.After(expected) { // future date, skip
+	for day := today; dayKey(day) >= dayKey(earliest); day = day.AddDate(0, 0, -1) {
+		if _, ok := met[dayKey(day)]; ok {
+			streak++
 			continue
 		}
-		if !sameDate(rec.CheckinDate, expected) {
-			break
-		}
-		if rec.Count < targetTimes {
+		if !shield.covers(day) {
 			break
 		}
-		streak++
-		expected = expected.AddDate(0, 0, -1)
 	}
 	return streak
 }
@@ -0,0 +1,312 @@
+package service
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"habit-tracker/internal/models"
+)
+
+func TestCheckinAwardsPointsOnceTargetReached(t *testing.T) {
+	env := newTestEnv(t)
+	u := env.user(t, "alice")
+	h := env.habit(t, models.Habit{UserID: u.ID, TargetTimes: 2})
+
+	first, err := env.checkinSvc.Checkin(env.ctx, u.ID, h.ID, CheckinInput{CountInc: 1})
+	if err != nil {
+		t.Fatalf("first check-in: %v", err)
+	}
+	if first.ReachedTarget || first.PointsAwarded != 0 || first.StreakDays != 0 {
+		t.Fatalf("first check-in = %+v, want target not reached", first)
+	}
+
+	second, err := env.checkinSvc.Checkin(env.ctx, u.ID, h.ID, CheckinInput{CountInc: 1})
+	if err != nil {
+		t.Fatalf("second check-in: %v", err)
+	}
+	if !second.ReachedTarget || second.PointsAwarded != baseCheckinPoints || second.StreakDays != 1 {
+		t.Fatalf("second check-in = %+v, want target reached with %d point", second, baseCheckinPoints)
+	}
+	if second.TodayCount != 2 || second.TotalCheckins != 2 || second.CheckinID != first.CheckinID {
+		t.Fatalf("second check-in = %+v, want today's record accumulated", second)
+	}
+
+	third, err := env.checkinSvc.Checkin(env.ctx, u.ID, h.ID, CheckinInput{CountInc: 1})
+	if err != nil {
+		t.Fatalf("third check-in: %v", err)
+	}
+	if third.ReachedTarget || third.PointsAwarded != 0 {
+		t.Fatalf("over-target check-in = %+v, want no award", third)
+	}
+
+	got := env.reload(t, u)
+	if got.Points != baseCheckinPoints || got.TotalCheckins != 1 {
+		t.Fatalf("user points=%d total_checkins=%d, want %d and 1", got.Points, got.TotalCheckins, baseCheckinPoints)
+	}
+	var logs []models.UserPointsLog
+	err = env.points.EachByUser(env.ctx, u.ID, 10, func(batch []models.UserPointsLog) error {
+		logs = append(logs, batch...)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(logs) != 1 || logs[0].Reason != "checkin" || logs[0].RelatedHabitID == nil || *logs[0].RelatedHabitID != h.ID {
+		t.Fatalf("points log = %+v, want one check-in entry for habit %d", logs, h.ID)
+	}
+}
+
+func TestCheckinMeasurableHabit(t *testing.T) {
+	env := newTestEnv(t)
+	u := env.user(t, "alice")
+	h := env.habit(t, models.Habit{UserID: u.ID, Kind: models.HabitKindMeasurable, TargetQuantity: 5, Unit: "km"})
+
+	if _, err := env.checkinSvc.Checkin(env.ctx, u.ID, h.ID, CheckinInput{}); !isInvalid(err) {
+		t.Fatalf("check-in without quantity: err = %v, want invalid", err)
+	}
+	res, err := env.checkinSvc.Checkin(env.ctx, u.ID, h.ID, CheckinInput{Quantity: 3, CountInc: 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.ReachedTarget || res.TodayCount != 1 || res.TodayQuantity != 3 {
+		t.Fatalf("check-in = %+v, want 3 of 5 km in one check-in", res)
+	}
+	res, err = env.checkinSvc.Checkin(env.ctx, u.ID, h.ID, CheckinInput{Quantity: 2.5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.ReachedTarget || res.TodayQuantity != 5.5 || res.PointsAwarded != baseCheckinPoints {
+		t.Fatalf("check-in = %+v, want target crossed", res)
+	}
+}
+
+func TestConcurrentCheckinsReachTargetOnce(t *testing.T) {
+	env := newTestEnv(t)
+	u := env.user(t, "alice")
+	h := env.habit(t, models.Habit{UserID: u.ID, Kind: models.HabitKindMeasurable, TargetQuantity: 5, Unit: "km"})
+
+	const workers = 10
+	results := make(chan *CheckinResult, workers)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res, err := env.checkinSvc.Checkin(env.ctx, u.ID, h.ID, CheckinInput{Quantity: 1})
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			results <- res
+		}()
+	}
+	wg.Wait()
+	close(results)
+
+	reached := 0
+	for res := range results {
+		if res.ReachedTarget {
+			reached++
+		}
+	}
+	if got := env.reload(t, u); reached != 1 || got.Points != baseCheckinPoints || got.TotalCheckins != 1 {
+		t.Fatalf("%d check-ins reached the target, user points=%d total_checkins=%d; want exactly one award", reached, got.Points, got.TotalCheckins)
+	}
+}
+
+func TestCheckinRejects(t *testing.T) {
+	env := newTestEnv(t)
+	alice := env.user(t, "alice")
+	bob := env.user(t, "bob")
+	build := env.habit(t, models.Habit{UserID: alice.ID})
+	quit := env.habit(t, models.Habit{UserID: alice.ID, Polarity: models.HabitPolarityQuit})
+	archived := env.habit(t, models.Habit{UserID: alice.ID})
+	now := time.Now()
+	if err := env.habits.SetArchivedAt(env.ctx, archived.ID, &now); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		userID  uint64
+		habitID uint64
+		in      CheckinInput
+		want    error
+	}{
+		{"missing habit", alice.ID, 999, CheckinInput{CountInc: 1}, ErrHabitMissing},
+		{"other user's habit", bob.ID, build.ID, CheckinInput{CountInc: 1}, ErrCheckinForbidden},
+		{"archived habit", alice.ID, archived.ID, CheckinInput{CountInc: 1}, ErrHabitArchived},
+		{"quit habit", alice.ID, quit.ID, CheckinInput{CountInc: 1}, ErrQuitHabitCheckin},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := env.checkinSvc.Checkin(env.ctx, tt.userID, tt.habitID, tt.in); !errors.Is(err, tt.want) {
+				t.Fatalf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	mood := 9
+	for name, in := range map[string]CheckinInput{
+		"zero count": {},
+		"bad mood":   {CountInc: 1, Mood: &mood},
+	} {
+		if _, err := env.checkinSvc.Checkin(env.ctx, alice.ID, build.ID, in); !isInvalid(err) {
+			t.Fatalf("%s: err = %v, want invalid", name, err)
+		}
+	}
+	if total, _ := env.checkins.SumCountByHabit(env.ctx, build.ID); total != 0 {
+		t.Fatalf("rejected check-ins stored %d counts", total)
+	}
+}
+
+func TestCheckinExtendsStreak(t *testing.T) {
+	env := newTestEnv(t)
+	u := env.user(t, "alice")
+	h := env.habit(t, models.Habit{UserID: u.ID})
+	yesterday := todayDate().AddDate(0, 0, -1)
+	if err := env.habits.UpdateStreakState(env.ctx, h.ID, 3, 5, &yesterday); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := env.checkinSvc.Checkin(env.ctx, u.ID, h.ID, CheckinInput{CountInc: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.StreakDays != 4 || res.FreezeEarned {
+		t.Fatalf("check-in = %+v, want streak 4", res)
+	}
+	stored, err := env.habits.GetByID(env.ctx, h.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.CurrentStreak != 4 || stored.LongestStreak != 5 || !sameOptionalDate(stored.LastCompletedDate, ptrTime(todayDate())) {
+		t.Fatalf("stored streak = %d/%d last %v, want 4/5 today", stored.CurrentStreak, stored.LongestStreak, stored.LastCompletedDate)
+	}
+}
+
+func TestCheckinAfterMissedDayResetsStreak(t *testing.T) {
+	env := newTestEnv(t)
+	u := env.user(t, "alice")
+	h := env.habit(t, models.Habit{UserID: u.ID})
+	twoDaysAgo := todayDate().AddDate(0, 0, -2)
+	if err := env.habits.UpdateStreakState(env.ctx, h.ID, 3, 3, &twoDaysAgo); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := env.checkinSvc.Checkin(env.ctx, u.ID, h.ID, CheckinInput{CountInc: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.StreakDays != 1 {
+		t.Fatalf("streak = %d, want 1 after a missed day", res.StreakDays)
+	}
+}
+
+func TestCheckinConsumesFreezeForMissedDay(t *testing.T) {
+	env := newTestEnv(t)
+	u := env.user(t, "alice")
+	h := env.habit(t, models.Habit{UserID: u.ID})
+	today := todayDate()
+	twoDaysAgo := today.AddDate(0, 0, -2)
+	if err := env.habits.UpdateStreakState(env.ctx, h.ID, 3, 3, &twoDaysAgo); err != nil {
+		t.Fatal(err)
+	}
+	if err := env.freezes.Create(env.ctx, &models.StreakFreeze{UserID: u.ID, Source: models.StreakFreezeEarned}); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := env.checkinSvc.Checkin(env.ctx, u.ID, h.ID, CheckinInput{CountInc: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.StreakDays != 4 {
+		t.Fatalf("streak = %d, want 4 bridged by the freeze", res.StreakDays)
+	}
+	days, err := env.freezes.ListUsedDays(env.ctx, h.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(days) != 1 || dayKey(days[0]) != dayKey(today.AddDate(0, 0, -1)) {
+		t.Fatalf("frozen days = %v, want yesterday", days)
+	}
+	if n, _ := env.freezes.CountAvailable(env.ctx, u.ID); n != 0 {
+		t.Fatalf("available freezes = %d, want 0", n)
+	}
+}
+
+func TestCheckinEarnsFreezeAtStreakMilestone(t *testing.T) {
+	env := newTestEnv(t)
+	u := env.user(t, "alice")
+	h := env.habit(t, models.Habit{UserID: u.ID})
+	yesterday := todayDate().AddDate(0, 0, -1)
+	if err := env.habits.UpdateStreakState(env.ctx, h.ID, FreezeEarnStreak-1, FreezeEarnStreak-1, &yesterday); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := env.checkinSvc.Checkin(env.ctx, u.ID, h.ID, CheckinInput{CountInc: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.StreakDays != FreezeEarnStreak || !res.FreezeEarned {
+		t.Fatalf("check-in = %+v, want a freeze earned at streak %d", res, FreezeEarnStreak)
+	}
+	if n, _ := env.freezes.CountAvailable(env.ctx, u.ID); n != 1 {
+		t.Fatalf("available freezes = %d, want 1", n)
+	}
+}
+
+func TestCheckinDoesNotEarnFreezeBeyondLimit(t *testing.T) {
+	env := newTestEnv(t)
+	u := env.user(t, "alice")
+	h := env.habit(t, models.Habit{UserID: u.ID})
+	yesterday := todayDate().AddDate(0, 0, -1)
+	if err := env.habits.UpdateStreakState(env.ctx, h.ID, FreezeEarnStreak-1, FreezeEarnStreak-1, &yesterday); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < MaxHeldFreezes; i++ {
+		if err := env.freezes.Create(env.ctx, &models.StreakFreeze{UserID: u.ID, Source: models.StreakFreezeEarned}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	res, err := env.checkinSvc.Checkin(env.ctx, u.ID, h.ID, CheckinInput{CountInc: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.FreezeEarned {
+		t.Fatal("earned a freeze with a full inventory")
+	}
+	if n, _ := env.freezes.CountAvailable(env.ctx, u.ID); n != MaxHeldFreezes {
+		t.Fatalf("available freezes = %d, want %d", n, MaxHeldFreezes)
+	}
+}
+
+func TestCheckinUnlocksAchievements(t *testing.T) {
+	env := newTestEnv(t)
+	u := env.user(t, "alice")
+	h := env.habit(t, models.Habit{UserID: u.ID})
+	first := env.achievement(t, "first_checkin", "total_checkins", 1)
+	env.achievement(t, "streak_7", "streak_days", 7)
+
+	res, err := env.checkinSvc.Checkin(env.ctx, u.ID, h.ID, CheckinInput{CountInc: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.UnlockedAwards) != 1 || res.UnlockedAwards[0].AchievementID != first.ID {
+		t.Fatalf("unlocked = %+v, want only %q", res.UnlockedAwards, first.Code)
+	}
+	res, err = env.checkinSvc.Checkin(env.ctx, u.ID, h.ID, CheckinInput{CountInc: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.UnlockedAwards) != 0 {
+		t.Fatalf("unlocked again: %+v", res.UnlockedAwards)
+	}
+}
+
+func ptrTime(t time.Time) *time.Time {
+	return &t
+}
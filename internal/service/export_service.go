@@ -0,0 +1,429 @@
+package service
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"habit-tracker/internal/models"
+	"habit-tracker/internal/repository"
+)
+
+const (
+	// ExportFormat and ExportVersion identify the archive layout in manifest.json.
+	// Bump ExportVersion on any incompatible change to the files below.
+	ExportFormat  = "habit-tracker-export"
+	ExportVersion = 1
+
+	exportBatchSize = 500
+	exportDate      = "2006-01-02"
+)
+
+// ExportManifest is manifest.json, the first entry of every export archive.
+type ExportManifest struct {
+	Format     string    `json:"format"`
+	Version    int       `json:"version"`
+	ExportedAt time.Time `json:"exported_at"`
+	UserID     uint64    `json:"user_id"`
+	Files      []string  `json:"files"`
+}
+
+// The records below are the exported rows. Field names are the JSON keys and,
+// in the same order, the CSV columns. Dates are YYYY-MM-DD, timestamps RFC 3339,
+// null values are empty CSV cells and lists are joined with ";" in CSV.
+
+type ExportProfile struct {
+	ID            uint64    `json:"id"`
+	Username      string    `json:"username"`
+	Nickname      string    `json:"nickname"`
+	Timezone      string    `json:"timezone"`
+	Points        int64     `json:"points"`
+	TotalCheckins int64     `json:"total_checkins"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type ExportHabit struct {
+	ID             uint64            `json:"id"`
+	Name           string            `json:"name"`
+	Description    string            `json:"description"`
+	Kind           string            `json:"kind"`
+	Polarity       string            `json:"polarity"`
+	TargetType     string            `json:"target_type"`
+	TargetTimes    int               `json:"target_times"`
+	TargetQuantity float64           `json:"target_quantity"`
+	Unit           string            `json:"unit"`
+	ScheduleDays   models.WeekdaySet `json:"schedule_days"`
+	StartDate      string            `json:"start_date"`
+	IsActive       bool              `json:"is_active"`
+	Category       string            `json:"category"`
+	Tags           []string          `json:"tags"`
+	Color          string            `json:"color"`
+	Icon           string            `json:"icon"`
+	CurrentStreak  int               `json:"current_streak"`
+	LongestStreak  int               `json:"longest_streak"`
+	ArchivedAt     *time.Time        `json:"archived_at"`
+	DeletedAt      *time.Time        `json:"deleted_at"`
+}
+
+type ExportCheckin struct {
+	ID          uint64    `json:"id"`
+	HabitID     uint64    `json:"habit_id"`
+	CheckinDate string    `json:"checkin_date"`
+	Count       int       `json:"count"`
+	Quantity    float64   `json:"quantity"`
+	Note        string    `json:"note"`
+	Mood        *int      `json:"mood"`
+	PhotoKey    string    `json:"photo_key"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type ExportPointsLog struct {
+	ID             uint64    `json:"id"`
+	ChangeAmount   int       `json:"change_amount"`
+	Reason         string    `json:"reason"`
+	RelatedHabitID *uint64   `json:"related_habit_id"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+type ExportAchievement struct {
+	AchievementID uint64    `json:"achievement_id"`
+	Code          string    `json:"code"`
+	Name          string    `json:"name"`
+	UnlockedAt    time.Time `json:"unlocked_at"`
+}
+
+var (
+	profileColumns     = []string{"id", "username", "nickname", "timezone", "points", "total_checkins", "created_at"}
+	habitColumns       = []string{"id", "name", "description", "kind", "polarity", "target_type", "target_times", "target_quantity", "unit", "schedule_days", "start_date", "is_active", "category", "tags", "color", "icon", "current_streak", "longest_streak", "archived_at", "deleted_at"}
+	checkinColumns     = []string{"id", "habit_id", "checkin_date", "count", "quantity", "note", "mood", "photo_key", "created_at"}
+	pointsLogColumns   = []string{"id", "change_amount", "reason", "related_habit_id", "created_at"}
+	achievementColumns = []string{"achievement_id", "code", "name", "unlocked_at"}
+)
+
+// ExportService writes a user's data as a zip archive of JSON and CSV files.
+type ExportService struct {
+	profiles         *ProfileService
+	habits           repository.HabitStore
+	categories       repository.CategoryStore
+	checkins         repository.CheckinStore
+	points           repository.PointsStore
+	achievements     repository.AchievementStore
+	userAchievements repository.UserAchievementStore
+}
+
+func NewExportService(profiles *ProfileService, habits repository.HabitStore, categories repository.CategoryStore, checkins repository.CheckinStore, points repository.PointsStore, achievements repository.AchievementStore, userAchievements repository.UserAchievementStore) *ExportService {
+	return &ExportService{
+		profiles:         profiles,
+		habits:           habits,
+		categories:       categories,
+		checkins:         checkins,
+		points:           points,
+		achievements:     achievements,
+		userAchievements: userAchievements,
+	}
+}
+
+// Export is a prepared export; nothing is written until Stream is called.
+type Export struct {
+	Filename string
+	svc      *ExportService
+	user     *models.User
+}
+
+// exportTable is one dataset of the archive. each may be called more than
+// once (once per file format) and feeds rows to emit as it reads them.
+type exportTable struct {
+	name    string
+	columns []string
+	single  bool // the JSON file holds one object instead of an array
+	each    func(ctx context.Context, emit func(record interface{}, row []string) error) error
+}
+
+// Prepare checks that the user exists so errors can still be reported before
+// the response starts.
+func (s *ExportService) Prepare(ctx context.Context, userID uint64) (*Export, error) {
+	user, err := s.profiles.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	name := fmt.Sprintf("habit-tracker-%s-%s.zip", user.Username, time.Now().Format(exportDate))
+	return &Export{Filename: name, svc: s, user: user}, nil
+}
+
+// Stream writes the archive to w. Check-ins and points are read in batches
+// and each row is written as soon as it is read.
+func (e *Export) Stream(ctx context.Context, w io.Writer) error {
+	tables := e.svc.tables(e.user)
+	manifest := ExportManifest{
+		Format:     ExportFormat,
+		Version:    ExportVersion,
+		ExportedAt: time.Now().UTC(),
+		UserID:     e.user.ID,
+	}
+	for _, t := range tables {
+		manifest.Files = append(manifest.Files, t.name+".json", t.name+".csv")
+	}
+
+	zw := zip.NewWriter(w)
+	f, err := zw.Create("manifest.json")
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(manifest); err != nil {
+		return err
+	}
+	for _, t := range tables {
+		if err := writeJSONTable(ctx, zw, t); err != nil {
+			return err
+		}
+		if err := writeCSVTable(ctx, zw, t); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+func writeJSONTable(ctx context.Context, zw *zip.Writer, t exportTable) error {
+	f, err := zw.Create(t.name + ".json")
+	if err != nil {
+		return err
+	}
+	if t.single {
+		return t.each(ctx, func(record interface{}, _ []string) error {
+			return json.NewEncoder(f).Encode(record)
+		})
+	}
+	if _, err := io.WriteString(f, "["); err != nil {
+		return err
+	}
+	sep := "\n"
+	err = t.each(ctx, func(record interface{}, _ []string) error {
+		data, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(f, sep); err != nil {
+			return err
+		}
+		sep = ",\n"
+		_, err = f.Write(data)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(f, "\n]\n")
+	return err
+}
+
+func writeCSVTable(ctx context.Context, zw *zip.Writer, t exportTable) error {
+	f, err := zw.Create(t.name + ".csv")
+	if err != nil {
+		return err
+	}
+	cw := csv.NewWriter(f)
+	if err := cw.Write(t.columns); err != nil {
+		return err
+	}
+	err = t.each(ctx, func(_ interface{}, row []string) error {
+		return cw.Write(row)
+	})
+	if err != nil {
+		return err
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func (s *ExportService) tables(user *models.User) []exportTable {
+	return []exportTable{
+		{name: "profile", columns: profileColumns, single: true, each: func(_ context.Context, emit func(interface{}, []string) error) error {
+			p := ExportProfile{
+				ID:            user.ID,
+				Username:      user.Username,
+				Nickname:      user.Nickname,
+				Timezone:      user.Timezone,
+				Points:        user.Points,
+				TotalCheckins: user.TotalCheckins,
+				CreatedAt:     user.CreatedAt,
+			}
+			return emit(p, []string{
+				formatUint(p.ID), p.Username, p.Nickname, p.Timezone,
+				strconv.FormatInt(p.Points, 10), strconv.FormatInt(p.TotalCheckins, 10), formatTime(&p.CreatedAt),
+			})
+		}},
+		{name: "habits", columns: habitColumns, each: func(ctx context.Context, emit func(interface{}, []string) error) error {
+			return s.eachHabit(ctx, user.ID, func(h ExportHabit) error {
+				return emit(h, []string{
+					formatUint(h.ID), h.Name, h.Description, h.Kind, h.Polarity, h.TargetType,
+					strconv.Itoa(h.TargetTimes), formatFloat(h.TargetQuantity), h.Unit, formatWeekdays(h.ScheduleDays),
+					h.StartDate, strconv.FormatBool(h.IsActive), h.Category, strings.Join(h.Tags, ";"), h.Color, h.Icon,
+					strconv.Itoa(h.CurrentStreak), strconv.Itoa(h.LongestStreak), formatTime(h.ArchivedAt), formatTime(h.DeletedAt),
+				})
+			})
+		}},
+		{name: "checkins", columns: checkinColumns, each: func(ctx context.Context, emit func(interface{}, []string) error) error {
+			return s.checkins.EachByUser(ctx, user.ID, exportBatchSize, func(batch []models.HabitCheckin) error {
+				for _, rec := range batch {
+					c := ExportCheckin{
+						ID:          rec.ID,
+						HabitID:     rec.HabitID,
+						CheckinDate: rec.CheckinDate.Format(exportDate),
+						Count:       rec.Count,
+						Quantity:    rec.Quantity,
+						Note:        rec.Note,
+						Mood:        rec.Mood,
+						PhotoKey:    rec.PhotoKey,
+						CreatedAt:   rec.CreatedAt,
+					}
+					mood := ""
+					if c.Mood != nil {
+						mood = strconv.Itoa(*c.Mood)
+					}
+					if err := emit(c, []string{
+						formatUint(c.ID), formatUint(c.HabitID), c.CheckinDate, strconv.Itoa(c.Count), formatFloat(c.Quantity),
+						c.Note, mood, c.PhotoKey, formatTime(&c.CreatedAt),
+					}); err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+		}},
+		{name: "points_log", columns: pointsLogColumns, each: func(ctx context.Context, emit func(interface{}, []string) error) error {
+			return s.points.EachByUser(ctx, user.ID, exportBatchSize, func(batch []models.UserPointsLog) error {
+				for _, rec := range batch {
+					p := ExportPointsLog{
+						ID:             rec.ID,
+						ChangeAmount:   rec.ChangeAmount,
+						Reason:         rec.Reason,
+						RelatedHabitID: rec.RelatedHabitID,
+						CreatedAt:      rec.CreatedAt,
+					}
+					related := ""
+					if p.RelatedHabitID != nil {
+						related = formatUint(*p.RelatedHabitID)
+					}
+					if err := emit(p, []string{
+						formatUint(p.ID), strconv.Itoa(p.ChangeAmount), p.Reason, related, formatTime(&p.CreatedAt),
+					}); err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+		}},
+		{name: "achievements", columns: achievementColumns, each: func(ctx context.Context, emit func(interface{}, []string) error) error {
+			return s.eachAchievement(ctx, user.ID, func(a ExportAchievement) error {
+				return emit(a, []string{formatUint(a.AchievementID), a.Code, a.Name, formatTime(&a.UnlockedAt)})
+			})
+		}},
+	}
+}
+
+// eachHabit covers soft-deleted habits too, since their check-ins are exported.
+func (s *ExportService) eachHabit(ctx context.Context, userID uint64, fn func(ExportHabit) error) error {
+	habits, err := s.habits.ListByUserWithDeleted(ctx, userID)
+	if err != nil {
+		return err
+	}
+	categories, err := s.categories.ListByUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	categoryNames := make(map[uint64]string, len(categories))
+	for _, c := range categories {
+		categoryNames[c.ID] = c.Name
+	}
+
+	for _, h := range habits {
+		item := ExportHabit{
+			ID:             h.ID,
+			Name:           h.Name,
+			Description:    h.Description,
+			Kind:           h.Kind,
+			Polarity:       h.Polarity,
+			TargetType:     h.TargetType,
+			TargetTimes:    h.TargetTimes,
+			TargetQuantity: h.TargetQuantity,
+			Unit:           h.Unit,
+			ScheduleDays:   h.ScheduleDays,
+			StartDate:      h.StartDate.Format(exportDate),
+			IsActive:       h.IsActive,
+			Tags:           make([]string, 0, len(h.Tags)),
+			Color:          h.Color,
+			Icon:           h.Icon,
+			CurrentStreak:  h.CurrentStreak,
+			LongestStreak:  h.LongestStreak,
+			ArchivedAt:     h.ArchivedAt,
+		}
+		if h.CategoryID != nil {
+			item.Category = categoryNames[*h.CategoryID]
+		}
+		for _, tag := range h.Tags {
+			item.Tags = append(item.Tags, tag.Name)
+		}
+		if h.DeletedAt.Valid {
+			item.DeletedAt = &h.DeletedAt.Time
+		}
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *ExportService) eachAchievement(ctx context.Context, userID uint64, fn func(ExportAchievement) error) error {
+	unlocked, err := s.userAchievements.ListByUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	catalog, err := s.achievements.ListAll(ctx)
+	if err != nil {
+		return err
+	}
+	byID := make(map[uint64]models.Achievement, len(catalog))
+	for _, a := range catalog {
+		byID[a.ID] = a
+	}
+	for _, ua := range unlocked {
+		a := byID[ua.AchievementID]
+		if err := fn(ExportAchievement{
+			AchievementID: ua.AchievementID,
+			Code:          a.Code,
+			Name:          a.Name,
+			UnlockedAt:    ua.UnlockedAt,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func formatUint(v uint64) string { return strconv.FormatUint(v, 10) }
+
+func formatFloat(v float64) string { return strconv.FormatFloat(v, 'f', -1, 64) }
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+func formatWeekdays(s models.WeekdaySet) string {
+	days := s.Days()
+	nums := make([]string, len(days))
+	for i, d := range days {
+		nums[i] = strconv.Itoa(int(d))
+	}
+	return strings.Join(nums, ";")
+}
@@ -0,0 +1,32 @@
+package service
+
+import "habit-tracker/internal/models"
+
+// habitGoal is the threshold a single day's check-in record must reach to
+// count as completed.
+type habitGoal struct {
+	measurable bool
+	times      int
+	quantity   float64
+}
+
+func goalOf(h *models.Habit) habitGoal {
+	if h.Kind == models.HabitKindMeasurable {
+		return habitGoal{measurable: true, quantity: h.TargetQuantity}
+	}
+	return habitGoal{times: h.TargetTimes}
+}
+
+func (g habitGoal) valid() bool {
+	if g.measurable {
+		return g.quantity > 0
+	}
+	return g.times > 0
+}
+
+func (g habitGoal) met(rec models.HabitCheckin) bool {
+	if g.measurable {
+		return rec.Quantity >= g.quantity
+	}
+	return rec.Count >= g.times
+}
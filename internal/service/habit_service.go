@@ -3,30 +3,60 @@ package service
 import (
 	"context"
 	"errors"
+	"log"
 	"time"
 
 	"gorm.io/gorm"
 
+	"habit-tracker/internal/apperr"
 	"habit-tracker/internal/models"
 	"habit-tracker/internal/repository"
+	"habit-tracker/internal/storage"
 )
 
 var (
-	ErrHabitNotFound  = gorm.ErrRecordNotFound
-	ErrHabitForbidden = errors.New("habit does not belong to user")
-	validTargetTypes  = map[string]struct{}{
+	ErrHabitNotFound       = apperr.New(apperr.KindNotFound, "habit_not_found", "habit not found")
+	ErrHabitForbidden      = apperr.New(apperr.KindForbidden, "habit_forbidden", "habit does not belong to user")
+	ErrHabitArchived       = apperr.New(apperr.KindConflict, "habit_archived", "habit is archived")
+	ErrHabitNotInTrash     = apperr.New(apperr.KindConflict, "habit_not_deleted", "habit is not deleted")
+	ErrHabitRestoreExpired = apperr.New(apperr.KindConflict, "habit_restore_expired", "habit restore window has expired")
+	ErrInvalidPointsPolicy = apperr.New(apperr.KindInvalid, "invalid_points_policy", "invalid points_policy")
+	ErrInvalidHabitOrder   = apperr.New(apperr.KindInvalid, "invalid_habit_order", "habit_ids must list distinct habits of the user")
+	validTargetTypes       = map[string]struct{}{
 		"daily":  {},
 		"weekly": {},
 		"custom": {},
 	}
 )
 
+const (
+	maxIconLength = 32
+	maxUnitLength = 32
+)
+
+// HabitRestoreWindow is how long a soft-deleted habit can be restored before it is purged.
+const HabitRestoreWindow = 30 * 24 * time.Hour
+
+// PointsPolicy decides what happens to points log entries of a hard-deleted habit.
+type PointsPolicy string
+
+const (
+	// PointsPolicyKeep keeps the earned points; log rows are detached from the habit.
+	PointsPolicyKeep PointsPolicy = "keep"
+	// PointsPolicyRevoke deletes the log rows and takes the points back.
+	PointsPolicyRevoke PointsPolicy = "revoke"
+)
+
 type HabitService struct {
-	habitRepo *repository.HabitRepository
+	habitRepo  repository.HabitStore
+	categories repository.CategoryStore
+	tags       repository.TagStore
+	blobs      storage.BlobStore
+	guard      *StreakGuardService
 }
 
-func NewHabitService(habitRepo *repository.HabitRepository) *HabitService {
-	return &HabitService{habitRepo: habitRepo}
+func NewHabitService(habitRepo repository.HabitStore, categories repository.CategoryStore, tags repository.TagStore, blobs storage.BlobStore, guard *StreakGuardService) *HabitService {
+	return &HabitService{habitRepo: habitRepo, categories: categories, tags: tags, blobs: blobs, guard: guard}
 }
 
 type HabitInput struct {
@@ -34,11 +64,28 @@ type HabitInput struct {
 	Description string
 	TargetType  string
 	TargetTimes int
-	StartDate   time.Time
-	IsActive    *bool // optional for update
+	// ScheduleDays are the due weekdays of weekly (default: weekday of
+	// StartDate) and custom (required) habits; ignored for daily habits.
+	ScheduleDays models.WeekdaySet
+	Kind         string  // defaults to count; cannot change on update
+	Polarity     string  // defaults to build; cannot change on update
+	Unit         string  // measurable only
+	Quantity     float64 // measurable target quantity
+	StartDate    time.Time
+	IsActive     *bool    // optional for update
+	CategoryID   *uint64  // optional; 0 clears the category on update
+	Color        *string  // optional for update
+	Icon         *string  // optional for update
+	Tags         []string // nil keeps the current tags on update
 }
 
 func (s *HabitService) Create(ctx context.Context, userID uint64, in HabitInput) (*models.Habit, error) {
+	if in.Kind == "" {
+		in.Kind = models.HabitKindCount
+	}
+	if in.Polarity == "" {
+		in.Polarity = models.HabitPolarityBuild
+	}
 	if err := validateHabitInput(in, false); err != nil {
 		return nil, err
 	}
@@ -48,51 +95,171 @@ func (s *HabitService) Create(ctx context.Context, userID uint64, in HabitInput)
 		Name:        in.Name,
 		Description: in.Description,
 		TargetType:  in.TargetType,
-		TargetTimes: in.TargetTimes,
 		StartDate:   in.StartDate,
 		IsActive:    true,
 	}
+	applyGoal(habit, in)
+	if err := s.applyAppearance(ctx, userID, habit, in); err != nil {
+		return nil, err
+	}
 	if err := s.habitRepo.Create(ctx, habit); err != nil {
 		return nil, err
 	}
+	if err := s.setTags(ctx, userID, habit, in.Tags); err != nil {
+		return nil, err
+	}
 	return habit, nil
 }
 
 func (s *HabitService) Update(ctx context.Context, userID, habitID uint64, in HabitInput) (*models.Habit, error) {
-	if err := validateHabitInput(in, true); err != nil {
-		return nil, err
-	}
-
-	habit, err := s.habitRepo.GetByID(ctx, habitID)
+	habit, err := s.getOwned(ctx, userID, habitID)
 	if err != nil {
 		return nil, err
 	}
-	if habit.UserID != userID {
-		return nil, ErrHabitForbidden
+	if in.Kind == "" {
+		in.Kind = habit.Kind
+	}
+	if in.Kind != habit.Kind {
+		return nil, apperr.Invalid("kind cannot be changed")
+	}
+	if in.Polarity == "" {
+		in.Polarity = habit.Polarity
+	}
+	if in.Polarity != habit.Polarity {
+		return nil, apperr.Invalid("polarity cannot be changed")
+	}
+	if err := validateHabitInput(in, true); err != nil {
+		return nil, err
 	}
 
+	// 目标、计划或开始日期变化后，已保存的连续天数需要按新规则重算
+	goal, schedule, start := goalOf(habit), scheduleOf(habit), habit.StartDate
+
 	habit.Name = in.Name
 	habit.Description = in.Description
 	habit.TargetType = in.TargetType
-	habit.TargetTimes = in.TargetTimes
 	habit.StartDate = in.StartDate
+	applyGoal(habit, in)
 	if in.IsActive != nil {
 		habit.IsActive = *in.IsActive
 	}
+	if err := s.applyAppearance(ctx, userID, habit, in); err != nil {
+		return nil, err
+	}
 
 	if err := s.habitRepo.Update(ctx, habit); err != nil {
 		return nil, err
 	}
-	return habit, nil
+	if goalOf(habit) != goal || scheduleOf(habit) != schedule || !sameDate(habit.StartDate, start) {
+		if _, err := s.guard.RebuildStreak(ctx, habit); err != nil {
+			return nil, err
+		}
+	}
+	if in.Tags != nil {
+		if err := s.setTags(ctx, userID, habit, in.Tags); err != nil {
+			return nil, err
+		}
+	}
+	return s.habitRepo.GetByIDWithTags(ctx, habit.ID)
+}
+
+// Reorder persists the manual order; habitIDs must list habits of the user, first to last.
+func (s *HabitService) Reorder(ctx context.Context, userID uint64, habitIDs []uint64) error {
+	if len(habitIDs) == 0 {
+		return ErrInvalidHabitOrder
+	}
+	seen := make(map[uint64]struct{}, len(habitIDs))
+	for _, id := range habitIDs {
+		if _, dup := seen[id]; dup {
+			return ErrInvalidHabitOrder
+		}
+		seen[id] = struct{}{}
+	}
+	if err := s.habitRepo.Reorder(ctx, userID, habitIDs); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrInvalidHabitOrder
+		}
+		return err
+	}
+	return nil
 }
 
-func (s *HabitService) List(ctx context.Context, userID uint64, isActive *bool) ([]models.Habit, error) {
-	return s.habitRepo.ListByUserWithActive(ctx, userID, isActive)
+func (s *HabitService) applyAppearance(ctx context.Context, userID uint64, habit *models.Habit, in HabitInput) error {
+	if in.CategoryID != nil {
+		if *in.CategoryID == 0 {
+			habit.CategoryID = nil
+		} else {
+			if _, err := getOwnedCategory(ctx, s.categories, userID, *in.CategoryID); err != nil {
+				return err
+			}
+			id := *in.CategoryID
+			habit.CategoryID = &id
+		}
+	}
+	if in.Color != nil {
+		if err := validateColor(*in.Color); err != nil {
+			return err
+		}
+		habit.Color = *in.Color
+	}
+	if in.Icon != nil {
+		if len(*in.Icon) > maxIconLength {
+			return apperr.Invalid("icon is too long")
+		}
+		habit.Icon = *in.Icon
+	}
+	return nil
+}
+
+func (s *HabitService) setTags(ctx context.Context, userID uint64, habit *models.Habit, names []string) error {
+	names, err := normalizeTagNames(names)
+	if err != nil {
+		return err
+	}
+	tags, err := s.tags.EnsureByNames(ctx, userID, names)
+	if err != nil {
+		return err
+	}
+	ids := make([]uint64, 0, len(tags))
+	for _, t := range tags {
+		ids = append(ids, t.ID)
+	}
+	if err := s.tags.ReplaceForHabit(ctx, habit.ID, ids); err != nil {
+		return err
+	}
+	habit.Tags = tags
+	return nil
+}
+
+// List returns the user's habits. Archived habits are excluded unless f.Archived asks for them.
+func (s *HabitService) List(ctx context.Context, userID uint64, f repository.HabitFilter) ([]models.Habit, error) {
+	if f.Archived == nil {
+		archived := false
+		f.Archived = &archived
+	}
+	if f.Sort != "" && !repository.ValidHabitSort(f.Sort) {
+		return nil, apperr.Invalid("invalid sort")
+	}
+	return s.habitRepo.ListFiltered(ctx, userID, f)
+}
+
+func (s *HabitService) ListDeleted(ctx context.Context, userID uint64) ([]models.Habit, error) {
+	return s.habitRepo.ListDeletedByUser(ctx, userID)
 }
 
 func (s *HabitService) Get(ctx context.Context, userID, habitID uint64) (*models.Habit, error) {
+	if _, err := s.getOwned(ctx, userID, habitID); err != nil {
+		return nil, err
+	}
+	return s.habitRepo.GetByIDWithTags(ctx, habitID)
+}
+
+func (s *HabitService) getOwned(ctx context.Context, userID, habitID uint64) (*models.Habit, error) {
 	habit, err := s.habitRepo.GetByID(ctx, habitID)
 	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrHabitNotFound
+		}
 		return nil, err
 	}
 	if habit.UserID != userID {
@@ -102,13 +269,10 @@ func (s *HabitService) Get(ctx context.Context, userID, habitID uint64) (*models
 }
 
 func (s *HabitService) SetActive(ctx context.Context, userID, habitID uint64, active bool) error {
-	habit, err := s.habitRepo.GetByID(ctx, habitID)
+	habit, err := s.getOwned(ctx, userID, habitID)
 	if err != nil {
 		return err
 	}
-	if habit.UserID != userID {
-		return ErrHabitForbidden
-	}
 	habit.IsActive = active
 	if err := s.habitRepo.UpdateStatus(ctx, habitID, active); err != nil {
 		return err
@@ -116,18 +280,193 @@ func (s *HabitService) SetActive(ctx context.Context, userID, habitID uint64, ac
 	return nil
 }
 
+// applyGoal copies the kind-specific target fields; measurable and quit habits
+// keep target_times at 1 since each check-in (or relapse) counts once.
+func applyGoal(habit *models.Habit, in HabitInput) {
+	habit.Kind = in.Kind
+	habit.Polarity = in.Polarity
+	habit.ScheduleDays = 0
+	switch {
+	case in.Polarity == models.HabitPolarityQuit || in.TargetType == "daily":
+	case in.ScheduleDays != 0:
+		habit.ScheduleDays = in.ScheduleDays
+	case in.TargetType == "weekly":
+		habit.ScheduleDays = models.WeekdaySetOf(habit.StartDate.Weekday())
+	}
+	if in.Polarity == models.HabitPolarityQuit {
+		habit.TargetTimes = 1
+		habit.Unit = ""
+		habit.TargetQuantity = 0
+		return
+	}
+	if in.Kind == models.HabitKindMeasurable {
+		habit.TargetTimes = 1
+		habit.Unit = in.Unit
+		habit.TargetQuantity = in.Quantity
+		return
+	}
+	habit.TargetTimes = in.TargetTimes
+	habit.Unit = ""
+	habit.TargetQuantity = 0
+}
+
 func validateHabitInput(in HabitInput, allowZeroStart bool) error {
 	if in.Name == "" {
-		return errors.New("name is required")
+		return apperr.Invalid("name is required")
 	}
 	if _, ok := validTargetTypes[in.TargetType]; !ok {
-		return errors.New("invalid target_type")
+		return apperr.Invalid("invalid target_type")
+	}
+	if in.TargetType == "custom" && in.Polarity == models.HabitPolarityBuild && in.ScheduleDays == 0 {
+		return apperr.Invalid("schedule_days is required for custom habits")
 	}
-	if in.TargetTimes <= 0 {
-		return errors.New("target_times must be > 0")
+	switch in.Polarity {
+	case models.HabitPolarityBuild:
+	case models.HabitPolarityQuit:
+		if in.Kind != models.HabitKindCount {
+			return apperr.Invalid("quit habits cannot be measurable")
+		}
+		return validateStartDate(in, allowZeroStart)
+	default:
+		return apperr.Invalid("invalid polarity")
 	}
+	switch in.Kind {
+	case models.HabitKindCount:
+		if in.TargetTimes <= 0 {
+			return apperr.Invalid("target_times must be > 0")
+		}
+	case models.HabitKindMeasurable:
+		if in.Quantity <= 0 {
+			return apperr.Invalid("target_quantity must be > 0")
+		}
+		if in.Unit == "" || len(in.Unit) > maxUnitLength {
+			return apperr.Invalid("unit is required and must be at most 32 characters")
+		}
+	default:
+		return apperr.Invalid("invalid kind")
+	}
+	return validateStartDate(in, allowZeroStart)
+}
+
+func validateStartDate(in HabitInput, allowZeroStart bool) error {
 	if !allowZeroStart && in.StartDate.IsZero() {
-		return errors.New("start_date is required")
+		return apperr.Invalid("start_date is required")
+	}
+	return nil
+}
+
+// Archive hides the habit from the default list and blocks new check-ins.
+// Its history still counts toward stats.
+func (s *HabitService) Archive(ctx context.Context, userID, habitID uint64) (*models.Habit, error) {
+	habit, err := s.getOwned(ctx, userID, habitID)
+	if err != nil {
+		return nil, err
+	}
+	if habit.ArchivedAt != nil {
+		return habit, nil
+	}
+	now := time.Now()
+	if err := s.habitRepo.SetArchivedAt(ctx, habitID, &now); err != nil {
+		return nil, err
+	}
+	habit.ArchivedAt = &now
+	return habit, nil
+}
+
+func (s *HabitService) Unarchive(ctx context.Context, userID, habitID uint64) (*models.Habit, error) {
+	habit, err := s.getOwned(ctx, userID, habitID)
+	if err != nil {
+		return nil, err
+	}
+	if habit.ArchivedAt == nil {
+		return habit, nil
+	}
+	if err := s.habitRepo.SetArchivedAt(ctx, habitID, nil); err != nil {
+		return nil, err
+	}
+	habit.ArchivedAt = nil
+	return habit, nil
+}
+
+// Delete soft-deletes the habit; it can be restored within HabitRestoreWindow.
+func (s *HabitService) Delete(ctx context.Context, userID, habitID uint64) error {
+	if _, err := s.getOwned(ctx, userID, habitID); err != nil {
+		return err
+	}
+	return s.habitRepo.SoftDelete(ctx, habitID)
+}
+
+func (s *HabitService) Restore(ctx context.Context, userID, habitID uint64) (*models.Habit, error) {
+	habit, err := s.getOwnedUnscoped(ctx, userID, habitID)
+	if err != nil {
+		return nil, err
+	}
+	if !habit.DeletedAt.Valid {
+		return nil, ErrHabitNotInTrash
+	}
+	if time.Since(habit.DeletedAt.Time) > HabitRestoreWindow {
+		return nil, ErrHabitRestoreExpired
+	}
+	if err := s.habitRepo.Restore(ctx, habitID); err != nil {
+		return nil, err
+	}
+	habit.DeletedAt = gorm.DeletedAt{}
+	return habit, nil
+}
+
+// HardDelete permanently removes a live or soft-deleted habit together with its check-ins.
+func (s *HabitService) HardDelete(ctx context.Context, userID, habitID uint64, policy PointsPolicy) error {
+	if policy == "" {
+		policy = PointsPolicyKeep
+	}
+	if policy != PointsPolicyKeep && policy != PointsPolicyRevoke {
+		return ErrInvalidPointsPolicy
+	}
+	habit, err := s.getOwnedUnscoped(ctx, userID, habitID)
+	if err != nil {
+		return err
+	}
+	return s.hardDelete(ctx, habit, policy == PointsPolicyRevoke)
+}
+
+func (s *HabitService) hardDelete(ctx context.Context, habit *models.Habit, revokePoints bool) error {
+	photoKeys, err := s.habitRepo.HardDelete(ctx, habit, revokePoints)
+	if err != nil {
+		return err
+	}
+	// 照片删除失败不影响主流程，只记录日志
+	for _, key := range photoKeys {
+		if err := s.blobs.Delete(ctx, key); err != nil {
+			log.Printf("delete photo %s of habit %d: %v", key, habit.ID, err)
+		}
 	}
 	return nil
 }
+
+// PurgeExpired hard-deletes habits whose restore window has passed, keeping their points.
+func (s *HabitService) PurgeExpired(ctx context.Context) error {
+	habits, err := s.habitRepo.ListDeletedBefore(ctx, time.Now().Add(-HabitRestoreWindow))
+	if err != nil {
+		return err
+	}
+	for i := range habits {
+		if err := s.hardDelete(ctx, &habits[i], false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *HabitService) getOwnedUnscoped(ctx context.Context, userID, habitID uint64) (*models.Habit, error) {
+	habit, err := s.habitRepo.GetByIDUnscoped(ctx, habitID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrHabitNotFound
+		}
+		return nil, err
+	}
+	if habit.UserID != userID {
+		return nil, ErrHabitForbidden
+	}
+	return habit, nil
+}
@@ -0,0 +1,221 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+
+	"habit-tracker/internal/models"
+	"habit-tracker/internal/repository"
+)
+
+const trendWeeks = 12
+
+type HabitStatsService struct {
+	habits   repository.HabitStore
+	checkins repository.CheckinStore
+	guard    *StreakGuardService
+}
+
+func NewHabitStatsService(habits repository.HabitStore, checkins repository.CheckinStore, guard *StreakGuardService) *HabitStatsService {
+	return &HabitStatsService{habits: habits, checkins: checkins, guard: guard}
+}
+
+// HabitStats are the per-habit numbers of the detail page. Completion only
+// counts scheduled days; for quit habits a completed day is a finished day
+// without a relapse.
+type HabitStats struct {
+	HabitID        uint64        `json:"habit_id"`
+	ScheduleDays   []int         `json:"schedule_days"`
+	ScheduledDays  int           `json:"scheduled_days"`
+	CompletedDays  int           `json:"completed_days"`
+	CompletionRate float64       `json:"completion_rate"`
+	CurrentStreak  int           `json:"current_streak"`
+	LongestStreak  int           `json:"longest_streak"`
+	TotalCount     int64         `json:"total_count"`
+	TotalQuantity  float64       `json:"total_quantity"`
+	BestWeekday    *int          `json:"best_weekday"` // 0 = Sunday; null until something is completed
+	Weekdays       []WeekdayStat `json:"weekdays"`
+	WeeklyTrend    []WeekStat    `json:"weekly_trend"`
+}
+
+type WeekdayStat struct {
+	Weekday   int     `json:"weekday"`
+	Scheduled int     `json:"scheduled"`
+	Completed int     `json:"completed"`
+	Rate      float64 `json:"rate"`
+}
+
+type WeekStat struct {
+	WeekStart string  `json:"week_start"`
+	Scheduled int     `json:"scheduled"`
+	Completed int     `json:"completed"`
+	Rate      float64 `json:"rate"`
+}
+
+func (s *HabitStatsService) Stats(ctx context.Context, userID, habitID uint64) (*HabitStats, error) {
+	habit, err := s.getOwned(ctx, userID, habitID)
+	if err != nil {
+		return nil, err
+	}
+
+	today, err := s.guard.todayOf(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	quit := habit.Polarity == models.HabitPolarityQuit
+	schedule := scheduleOf(habit)
+	goal := goalOf(habit)
+	threshold := goal.threshold().OnWeekdays(weekdayNumbers(schedule))
+	if quit {
+		threshold = repository.CountAtLeast(1) // 破戒日
+	}
+
+	// 统计截止到昨天；今天只有已完成时才计入，未结束的一天不拉低完成率
+	end := today.AddDate(0, 0, -1)
+	if !quit {
+		rec, err := s.checkins.GetByHabitAndDate(ctx, habit.ID, today)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		if rec != nil && goal.met(*rec) {
+			end = today
+		}
+	}
+
+	stats := &HabitStats{
+		HabitID:       habit.ID,
+		ScheduleDays:  weekdayNumbers(schedule),
+		LongestStreak: habit.LongestStreak,
+	}
+	if quit {
+		stats.CurrentStreak = habit.CurrentStreak
+	} else {
+		shield, err := s.guard.shieldFor(ctx, habit)
+		if err != nil {
+			return nil, err
+		}
+		stats.CurrentStreak = currentStreak(habit, today, shield)
+	}
+
+	totals, err := s.checkins.TotalsByHabit(ctx, habit.ID)
+	if err != nil {
+		return nil, err
+	}
+	stats.TotalCount = totals.Count
+	stats.TotalQuantity = totals.Quantity
+
+	scheduled := countWeekdays(habit.StartDate, end, schedule)
+	matched, err := s.checkins.CountDaysByWeekday(ctx, habit.ID, threshold, habit.StartDate, end)
+	if err != nil {
+		return nil, err
+	}
+	best := -1.0
+	for d := 0; d < 7; d++ {
+		if !schedule.Has(time.Weekday(d)) {
+			continue
+		}
+		completed := completedDays(quit, scheduled[d], matched[d])
+		rate := ratio(completed, scheduled[d])
+		stats.Weekdays = append(stats.Weekdays, WeekdayStat{Weekday: d, Scheduled: scheduled[d], Completed: completed, Rate: rate})
+		stats.ScheduledDays += scheduled[d]
+		stats.CompletedDays += completed
+		if completed > 0 && rate > best {
+			best = rate
+			weekday := d
+			stats.BestWeekday = &weekday
+		}
+	}
+	stats.CompletionRate = ratio(stats.CompletedDays, stats.ScheduledDays)
+
+	stats.WeeklyTrend, err = s.weeklyTrend(ctx, habit, threshold, schedule, quit, today, end)
+	if err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+func (s *HabitStatsService) getOwned(ctx context.Context, userID, habitID uint64) (*models.Habit, error) {
+	habit, err := s.habits.GetByID(ctx, habitID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrHabitNotFound
+		}
+		return nil, err
+	}
+	if habit.UserID != userID {
+		return nil, ErrHabitForbidden
+	}
+	return habit, nil
+}
+
+// weeklyTrend covers the last trendWeeks weeks (Monday to Sunday), current week included.
+func (s *HabitStatsService) weeklyTrend(ctx context.Context, habit *models.Habit, threshold repository.DayThreshold, schedule models.WeekdaySet, quit bool, today, end time.Time) ([]WeekStat, error) {
+	first := startOfWeek(today).AddDate(0, 0, -7*(trendWeeks-1))
+	bounds := make([]time.Time, trendWeeks+1)
+	for i := range bounds {
+		bounds[i] = first.AddDate(0, 0, 7*i)
+	}
+	matched, err := s.checkins.CountDaysByRanges(ctx, habit.ID, threshold, bounds)
+	if err != nil {
+		return nil, err
+	}
+
+	trend := make([]WeekStat, trendWeeks)
+	for i := range trend {
+		from, to := bounds[i], bounds[i+1].AddDate(0, 0, -1)
+		if from.Before(habit.StartDate) {
+			from = habit.StartDate
+		}
+		if to.After(end) {
+			to = end
+		}
+		scheduled := 0
+		for _, n := range countWeekdays(from, to, schedule) {
+			scheduled += n
+		}
+		completed := completedDays(quit, scheduled, matched[i])
+		trend[i] = WeekStat{
+			WeekStart: bounds[i].Format("2006-01-02"),
+			Scheduled: scheduled,
+			Completed: completed,
+			Rate:      ratio(completed, scheduled),
+		}
+	}
+	return trend, nil
+}
+
+// completedDays turns matched days into completed days: for quit habits the
+// matched days are relapses.
+func completedDays(quit bool, scheduled int, matched int64) int {
+	if !quit {
+		return int(matched)
+	}
+	if clean := scheduled - int(matched); clean > 0 {
+		return clean
+	}
+	return 0
+}
+
+func ratio(part, whole int) float64 {
+	if whole <= 0 {
+		return 0
+	}
+	r := float64(part) / float64(whole)
+	if r > 1 {
+		r = 1
+	}
+	return math.Round(r*1000) / 1000
+}
+
+func weekdayNumbers(set models.WeekdaySet) []int {
+	days := set.Days()
+	out := make([]int, len(days))
+	for i, d := range days {
+		out[i] = int(d)
+	}
+	return out
+}
@@ -0,0 +1,182 @@
+package service
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"habit-tracker/internal/apperr"
+	"habit-tracker/internal/models"
+)
+
+const (
+	// DefaultHeatmapDays is the range returned when no dates are given.
+	DefaultHeatmapDays = 365
+	maxHeatmapDays     = 366
+)
+
+// Heatmap is a compact day→value series for a contribution-style calendar.
+// Values[i] belongs to StartDate+i days and is the completion ratio in [0, 1]
+// normalized by the habit target (averaged over the habits due that day when
+// covering all habits), or null when nothing was due.
+type Heatmap struct {
+	HabitID   *uint64    `json:"habit_id,omitempty"`
+	StartDate string     `json:"start_date"`
+	EndDate   string     `json:"end_date"`
+	Values    []*float64 `json:"values"`
+}
+
+// HeatmapRange resolves optional bounds: end defaults to the user's today and
+// start to DefaultHeatmapDays before end.
+func (s *HabitStatsService) HeatmapRange(ctx context.Context, userID uint64, start, end *time.Time) (time.Time, time.Time, error) {
+	var to time.Time
+	if end != nil {
+		to = *end
+	} else {
+		today, err := s.guard.todayOf(ctx, userID)
+		if err != nil {
+			return to, to, err
+		}
+		to = today
+	}
+	from := to.AddDate(0, 0, -(DefaultHeatmapDays - 1))
+	if start != nil {
+		from = *start
+	}
+	days := daysBetween(from, to) + 1
+	if days <= 0 {
+		return from, to, apperr.Invalid("start_date must not be after end_date")
+	}
+	if days > maxHeatmapDays {
+		return from, to, apperr.Invalid("date range must not exceed 366 days")
+	}
+	return from, to, nil
+}
+
+// HabitHeatmap covers a single habit. Quit habits score 1 for every finished
+// day without a relapse and 0 on relapse days.
+func (s *HabitStatsService) HabitHeatmap(ctx context.Context, userID, habitID uint64, from, to time.Time) (*Heatmap, error) {
+	habit, err := s.getOwned(ctx, userID, habitID)
+	if err != nil {
+		return nil, err
+	}
+
+	var values []*float64
+	if habit.Polarity == models.HabitPolarityQuit {
+		values, err = s.quitHeatmap(ctx, habit, from, to)
+	} else {
+		values, err = s.buildHeatmap(ctx, userID, &habit.ID, []models.Habit{*habit}, from, to)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return newHeatmap(&habit.ID, from, to, values), nil
+}
+
+// UserHeatmap averages the ratios of all build habits of the user.
+func (s *HabitStatsService) UserHeatmap(ctx context.Context, userID uint64, from, to time.Time) (*Heatmap, error) {
+	habits, err := s.habits.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	build := habits[:0]
+	for _, h := range habits {
+		if h.Polarity != models.HabitPolarityQuit {
+			build = append(build, h)
+		}
+	}
+	values, err := s.buildHeatmap(ctx, userID, nil, build, from, to)
+	if err != nil {
+		return nil, err
+	}
+	return newHeatmap(nil, from, to, values), nil
+}
+
+func (s *HabitStatsService) buildHeatmap(ctx context.Context, userID uint64, habitID *uint64, habits []models.Habit, from, to time.Time) ([]*float64, error) {
+	scores, err := s.checkins.SumRatiosByDay(ctx, userID, habitID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	byDay := make(map[int]float64, len(scores))
+	for _, sc := range scores {
+		byDay[dayKey(sc.Day)] = sc.Score
+	}
+
+	days := daysBetween(from, to) + 1
+	values := make([]*float64, days)
+	for i := 0; i < days; i++ {
+		day := from.AddDate(0, 0, i)
+		due := 0
+		for j := range habits {
+			if dueOn(&habits[j], day) {
+				due++
+			}
+		}
+		score, done := byDay[dayKey(day)]
+		switch {
+		case due > 0:
+			values[i] = heatValue(score / float64(due))
+		case done:
+			values[i] = heatValue(score) // 非计划日的额外打卡
+		}
+	}
+	return values, nil
+}
+
+func (s *HabitStatsService) quitHeatmap(ctx context.Context, habit *models.Habit, from, to time.Time) ([]*float64, error) {
+	records, err := s.checkins.ListByHabitAndDateRange(ctx, habit.ID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	relapsed := make(map[int]struct{}, len(records))
+	for _, rec := range records {
+		if rec.Count > 0 {
+			relapsed[dayKey(rec.CheckinDate)] = struct{}{}
+		}
+	}
+
+	today, err := s.guard.todayOf(ctx, habit.UserID)
+	if err != nil {
+		return nil, err
+	}
+	days := daysBetween(from, to) + 1
+	values := make([]*float64, days)
+	for i := 0; i < days; i++ {
+		day := from.AddDate(0, 0, i)
+		if dayKey(day) < dayKey(habit.StartDate) {
+			continue
+		}
+		if _, ok := relapsed[dayKey(day)]; ok {
+			values[i] = heatValue(0)
+		} else if dayKey(day) < dayKey(today) {
+			values[i] = heatValue(1)
+		}
+	}
+	return values, nil
+}
+
+// dueOn reports whether a build habit was scheduled on day. Paused habits
+// are never due.
+func dueOn(h *models.Habit, day time.Time) bool {
+	if !h.IsActive || dayKey(day) < dayKey(h.StartDate) {
+		return false
+	}
+	if h.ArchivedAt != nil && dayKey(day) > dayKey(*h.ArchivedAt) {
+		return false
+	}
+	return scheduleOf(h).Has(day.Weekday())
+}
+
+func heatValue(v float64) *float64 {
+	v = math.Round(math.Min(v, 1)*100) / 100
+	return &v
+}
+
+func newHeatmap(habitID *uint64, from, to time.Time, values []*float64) *Heatmap {
+	return &Heatmap{
+		HabitID:   habitID,
+		StartDate: from.Format("2006-01-02"),
+		EndDate:   to.Format("2006-01-02"),
+		Values:    values,
+	}
+}
@@ -0,0 +1,30 @@
+package service
+
+import (
+	"testing"
+
+	"habit-tracker/internal/models"
+)
+
+func TestUserHeatmapIgnoresPausedHabits(t *testing.T) {
+	env := newTestEnv(t)
+	u := env.user(t, "alice")
+	active := env.habit(t, models.Habit{UserID: u.ID, TargetType: "daily"})
+	paused := env.habit(t, models.Habit{UserID: u.ID, TargetType: "daily"})
+	if err := env.habits.UpdateStatus(env.ctx, paused.ID, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := env.checkinSvc.Checkin(env.ctx, u.ID, active.ID, CheckinInput{CountInc: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	today := todayDate()
+	svc := NewHabitStatsService(env.habits, env.checkins, env.guardSvc)
+	heatmap, err := svc.UserHeatmap(env.ctx, u.ID, today.AddDate(0, 0, -1), today)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := heatmap.Values; len(v) != 2 || v[0] == nil || *v[0] != 0 || v[1] == nil || *v[1] != 1 {
+		t.Fatalf("heatmap = %v, want [0 1] with only the active habit due", heatmap.Values)
+	}
+}
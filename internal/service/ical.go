@@ -0,0 +1,57 @@
+package service
+
+import (
+	"bytes"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	icalDate     = "20060102"
+	icalDateTime = "20060102T150405Z"
+	icalLineMax  = 75 // octets per line before folding (RFC 5545 3.1)
+)
+
+// icalWriter builds an iCalendar document with CRLF line endings and folded
+// long lines.
+type icalWriter struct {
+	buf bytes.Buffer
+}
+
+// line writes "name:value"; value must already be escaped where needed.
+func (w *icalWriter) line(name, value string) {
+	s := name + ":" + value
+	limit := icalLineMax
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		w.buf.WriteString(s[:cut])
+		w.buf.WriteString("\r\n ")
+		s = s[cut:]
+		limit = icalLineMax - 1 // continuation lines start with a space
+	}
+	w.buf.WriteString(s)
+	w.buf.WriteString("\r\n")
+}
+
+// text writes a TEXT property, escaping it.
+func (w *icalWriter) text(name, value string) {
+	w.line(name, icalEscape(value))
+}
+
+func (w *icalWriter) date(name string, day time.Time) {
+	w.line(name+";VALUE=DATE", day.Format(icalDate))
+}
+
+func (w *icalWriter) Bytes() []byte { return w.buf.Bytes() }
+
+var icalEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+func icalEscape(s string) string {
+	return icalEscaper.Replace(s)
+}
+
+var icalWeekdays = [7]string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
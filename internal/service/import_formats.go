@@ -0,0 +1,395 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"habit-tracker/internal/apperr"
+	"habit-tracker/internal/models"
+)
+
+// Import sources.
+const (
+	ImportSourceLoop   = "loop"
+	ImportSourceExport = "habit-tracker"
+)
+
+// Loop Habit Tracker check-in values; YES_AUTO marks days Loop filled in
+// from the habit frequency and is not a real check-in.
+const (
+	loopYesManual = 2
+	loopNumericX  = 1000 // numeric values are stored multiplied by 1000
+)
+
+// maxImportEntrySize bounds each decompressed archive entry, so a small zip
+// cannot expand into an arbitrary amount of data.
+const maxImportEntrySize = 4 * MaxImportSize
+
+var ErrUnknownImportFormat = apperr.New(apperr.KindInvalid, "unknown_import_format", "unrecognized import file: expected a Loop Habit Tracker CSV export or a habit-tracker export archive")
+
+// importBatch is a parsed import file, independent of its source format.
+type importBatch struct {
+	source string
+	habits []*importHabit
+}
+
+type importHabit struct {
+	input    HabitInput
+	archived bool
+	inactive bool
+	checkins []importCheckin
+}
+
+type importCheckin struct {
+	date     time.Time
+	count    int
+	quantity float64
+	note     string
+	mood     *int
+	created  time.Time
+}
+
+// parseImport detects the format of the file: a zip is either our own export
+// (manifest.json) or a Loop export (Habits.csv), a bare CSV is Loop's
+// Checkmarks.csv.
+func parseImport(r io.ReaderAt, size int64) (*importBatch, error) {
+	head := make([]byte, 4)
+	if _, err := r.ReadAt(head, 0); err != nil && err != io.EOF {
+		return nil, err
+	}
+	if !bytes.Equal(head, []byte("PK\x03\x04")) {
+		return parseLoopCheckmarks(io.NewSectionReader(r, 0, size), nil)
+	}
+
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, ErrUnknownImportFormat
+	}
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[path.Clean(f.Name)] = f
+	}
+	switch {
+	case files["manifest.json"] != nil:
+		return parseExportArchive(files)
+	case files["Habits.csv"] != nil:
+		return parseLoopArchive(files)
+	}
+	return nil, ErrUnknownImportFormat
+}
+
+func parseExportArchive(files map[string]*zip.File) (*importBatch, error) {
+	var manifest ExportManifest
+	if err := decodeZipJSON(files["manifest.json"], &manifest); err != nil {
+		return nil, err
+	}
+	if manifest.Format != ExportFormat {
+		return nil, ErrUnknownImportFormat
+	}
+	if manifest.Version < 1 || manifest.Version > ExportVersion {
+		return nil, apperr.Invalid(fmt.Sprintf("unsupported export version %d", manifest.Version))
+	}
+
+	var habits []ExportHabit
+	if err := decodeZipJSON(files["habits.json"], &habits); err != nil {
+		return nil, err
+	}
+	batch := &importBatch{source: ImportSourceExport}
+	byID := make(map[uint64]*importHabit, len(habits))
+	for _, h := range habits {
+		if h.DeletedAt != nil {
+			continue // 回收站里的习惯不导入
+		}
+		start, err := time.Parse(exportDate, h.StartDate)
+		if err != nil {
+			return nil, apperr.Invalid("invalid start_date in habits.json")
+		}
+		color, icon := h.Color, h.Icon
+		item := &importHabit{
+			input: HabitInput{
+				Name:         h.Name,
+				Description:  h.Description,
+				TargetType:   h.TargetType,
+				TargetTimes:  h.TargetTimes,
+				ScheduleDays: h.ScheduleDays,
+				Kind:         h.Kind,
+				Polarity:     h.Polarity,
+				Unit:         h.Unit,
+				Quantity:     h.TargetQuantity,
+				StartDate:    start,
+				Color:        &color,
+				Icon:         &icon,
+				Tags:         h.Tags,
+			},
+			archived: h.ArchivedAt != nil,
+			inactive: !h.IsActive,
+		}
+		byID[h.ID] = item
+		batch.habits = append(batch.habits, item)
+	}
+
+	// checkins.json 可能很大，逐条解码
+	err := eachZipJSON(files["checkins.json"], func(dec *json.Decoder) error {
+		var c ExportCheckin
+		if err := dec.Decode(&c); err != nil {
+			return err
+		}
+		item, ok := byID[c.HabitID]
+		if !ok {
+			return nil
+		}
+		day, err := time.Parse(exportDate, c.CheckinDate)
+		if err != nil {
+			return apperr.Invalid("invalid checkin_date in checkins.json")
+		}
+		item.checkins = append(item.checkins, importCheckin{
+			date:     day,
+			count:    c.Count,
+			quantity: c.Quantity,
+			note:     c.Note,
+			mood:     c.Mood,
+			created:  c.CreatedAt,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return batch, nil
+}
+
+func decodeZipJSON(f *zip.File, v interface{}) error {
+	if f == nil {
+		return apperr.Invalid("export archive is incomplete")
+	}
+	rc, err := openZipEntry(f)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	if err := json.NewDecoder(rc).Decode(v); err != nil {
+		return apperr.Invalid("invalid " + f.Name)
+	}
+	return nil
+}
+
+// eachZipJSON calls fn for every element of the JSON array in f; fn decodes it.
+func eachZipJSON(f *zip.File, fn func(*json.Decoder) error) error {
+	if f == nil {
+		return apperr.Invalid("export archive is incomplete")
+	}
+	rc, err := openZipEntry(f)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	dec := json.NewDecoder(rc)
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('[') {
+		return apperr.Invalid("invalid " + f.Name)
+	}
+	for dec.More() {
+		if err := fn(dec); err != nil {
+			var appErr *apperr.Error
+			if errors.As(err, &appErr) {
+				return err
+			}
+			return apperr.Invalid("invalid " + f.Name)
+		}
+	}
+	return nil
+}
+
+// parseLoopArchive reads Habits.csv and the combined Checkmarks.csv of a
+// Loop export; the per-habit folders hold the same data and are ignored.
+func parseLoopArchive(files map[string]*zip.File) (*importBatch, error) {
+	rc, err := openZipEntry(files["Habits.csv"])
+	if err != nil {
+		return nil, err
+	}
+	rows, err := readCSV(rc)
+	rc.Close()
+	if err != nil || len(rows) == 0 {
+		return nil, apperr.Invalid("invalid Habits.csv")
+	}
+
+	col := csvColumns(rows[0])
+	var habits []*importHabit
+	for _, row := range rows[1:] {
+		get := func(names ...string) string {
+			for _, name := range names {
+				if i, ok := col[name]; ok && i < len(row) {
+					return strings.TrimSpace(row[i])
+				}
+			}
+			return ""
+		}
+		name := get("Name")
+		if name == "" {
+			continue
+		}
+		item := &importHabit{
+			input: HabitInput{
+				Name:        name,
+				Description: firstNonEmpty(get("Description"), get("Question")),
+				TargetType:  "daily",
+				TargetTimes: 1,
+				Kind:        models.HabitKindCount,
+				Polarity:    models.HabitPolarityBuild,
+			},
+			archived: strings.EqualFold(get("Archived?"), "true"),
+		}
+		// 每周一次的习惯映射为 weekly，其余频率按每日处理
+		num, den := get("FrequencyNumerator", "NumRepetitions"), get("FrequencyDenominator", "Interval")
+		if num == "1" && den == "7" {
+			item.input.TargetType = "weekly"
+		}
+		if get("Type") == "1" {
+			target, _ := strconv.ParseFloat(get("Target Value"), 64)
+			item.input.Kind = models.HabitKindMeasurable
+			item.input.Unit = firstNonEmpty(get("Unit"), "times")
+			item.input.Quantity = math.Max(target, 0.001)
+		}
+		if color := get("Color"); validateColor(color) == nil {
+			item.input.Color = &color
+		}
+		habits = append(habits, item)
+	}
+
+	f := files["Checkmarks.csv"]
+	if f == nil {
+		return nil, apperr.Invalid("Loop export has no Checkmarks.csv")
+	}
+	rc, err = openZipEntry(f)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return parseLoopCheckmarks(rc, habits)
+}
+
+// openZipEntry opens f for reading at most maxImportEntrySize bytes. Entries
+// declaring more are rejected up front; the limit also guards against sizes
+// that lie.
+func openZipEntry(f *zip.File) (io.ReadCloser, error) {
+	if f.UncompressedSize64 > maxImportEntrySize {
+		return nil, ErrImportTooLarge
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{io.LimitReader(rc, maxImportEntrySize), rc}, nil
+}
+
+// parseLoopCheckmarks reads Loop's Checkmarks.csv: a Date column followed by
+// one column per habit. Without Habits.csv every column becomes a yes/no habit.
+func parseLoopCheckmarks(r io.Reader, habits []*importHabit) (*importBatch, error) {
+	rows, err := readCSV(r)
+	if err != nil || len(rows) == 0 {
+		return nil, ErrUnknownImportFormat
+	}
+	if _, ok := csvColumns(rows[0])["Date"]; !ok || len(rows[0]) < 2 {
+		return nil, ErrUnknownImportFormat
+	}
+
+	// 同名习惯按出现顺序与同名列一一对应
+	byName := make(map[string][]*importHabit, len(habits))
+	for _, h := range habits {
+		byName[h.input.Name] = append(byName[h.input.Name], h)
+	}
+	columns := make([]*importHabit, len(rows[0]))
+	for i, name := range rows[0][1:] {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue // Loop 的表头以逗号结尾
+		}
+		var h *importHabit
+		if same := byName[name]; len(same) > 0 {
+			h, byName[name] = same[0], same[1:]
+		} else {
+			h = &importHabit{input: HabitInput{
+				Name:        name,
+				TargetType:  "daily",
+				TargetTimes: 1,
+				Kind:        models.HabitKindCount,
+				Polarity:    models.HabitPolarityBuild,
+			}}
+			habits = append(habits, h)
+		}
+		columns[i+1] = h
+	}
+
+	for _, row := range rows[1:] {
+		day, err := time.Parse("2006-01-02", strings.TrimSpace(row[0]))
+		if err != nil {
+			return nil, apperr.Invalid("invalid date in Checkmarks.csv: " + row[0])
+		}
+		for i := 1; i < len(row) && i < len(columns); i++ {
+			h := columns[i]
+			if h == nil {
+				continue
+			}
+			if c, ok := loopCheckin(h, strings.TrimSpace(row[i])); ok {
+				c.date = day
+				h.checkins = append(h.checkins, c)
+			}
+		}
+	}
+	return &importBatch{source: ImportSourceLoop, habits: habits}, nil
+}
+
+// loopCheckin converts one Checkmarks.csv cell. Yes/no habits only keep
+// manual checks; numeric habits keep positive amounts.
+func loopCheckin(h *importHabit, cell string) (importCheckin, bool) {
+	if h.input.Kind == models.HabitKindMeasurable {
+		v, err := strconv.ParseFloat(cell, 64)
+		if err != nil || v <= 0 {
+			return importCheckin{}, false
+		}
+		if !strings.Contains(cell, ".") {
+			v /= loopNumericX
+		}
+		return importCheckin{count: 1, quantity: v}, true
+	}
+	if cell == "YES_MANUAL" || cell == strconv.Itoa(loopYesManual) {
+		return importCheckin{count: 1}, true
+	}
+	return importCheckin{}, false
+}
+
+func readCSV(r io.Reader) ([][]string, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.LazyQuotes = true
+	return cr.ReadAll()
+}
+
+func csvColumns(header []string) map[string]int {
+	col := make(map[string]int, len(header))
+	for i, name := range header {
+		col[strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))] = i
+	}
+	return col
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
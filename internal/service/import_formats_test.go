@@ -0,0 +1,67 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"hash/crc32"
+	"testing"
+)
+
+func TestParseImportRejectsOversizedEntry(t *testing.T) {
+	// a tiny manifest.json that claims to expand past the entry limit
+	data := []byte("{}")
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.CreateRaw(&zip.FileHeader{
+		Name:               "manifest.json",
+		Method:             zip.Store,
+		CRC32:              crc32.ChecksumIEEE(data),
+		CompressedSize64:   uint64(len(data)),
+		UncompressedSize64: maxImportEntrySize + 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = parseImport(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if !errors.Is(err, ErrImportTooLarge) {
+		t.Fatalf("err = %v, want %v", err, ErrImportTooLarge)
+	}
+}
+
+func TestImportSkipsDuplicateHabitNames(t *testing.T) {
+	env := newTestEnv(t)
+	u := env.user(t, "alice")
+	imports := NewImportService(env.habitSvc, env.habits, env.users, env.checkins, env.pointsSvc, env.guardSvc)
+	day := todayDate().AddDate(0, 0, -2).Format("2006-01-02")
+	next := todayDate().AddDate(0, 0, -1).Format("2006-01-02")
+	csv := []byte("Date,Read,Read,Run,\n" + day + ",2,0,2,\n" + next + ",0,2,0,\n")
+
+	for run := 0; run < 2; run++ {
+		report, err := imports.Import(env.ctx, u.ID, bytes.NewReader(csv), int64(len(csv)), ImportOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(report.Habits) != 3 || report.Habits[1].Action != ImportSkip || report.Habits[1].Reason == "" {
+			t.Fatalf("run %d: habits = %+v, want the second Read skipped", run, report.Habits)
+		}
+		// the first run creates Read and Run with one day each; a re-run adds nothing
+		if want := []int{2, 0}[run]; report.CheckinsCreated != want || report.HabitsCreated != want {
+			t.Fatalf("run %d: report = %+v, want %d habits and check-ins created", run, report, want)
+		}
+	}
+	habits, err := env.habits.ListByUser(env.ctx, u.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(habits) != 2 {
+		t.Fatalf("habits = %d, want Read and Run", len(habits))
+	}
+}
@@ -0,0 +1,256 @@
+package service
+
+import (
+	"context"
+	"io"
+	"log"
+	"time"
+
+	"habit-tracker/internal/apperr"
+	"habit-tracker/internal/models"
+	"habit-tracker/internal/repository"
+)
+
+// MaxImportSize bounds the uploaded import file.
+const MaxImportSize = 50 << 20
+
+var ErrImportTooLarge = apperr.New(apperr.KindInvalid, "import_too_large", "import file is too large")
+
+// Import actions reported per habit.
+const (
+	ImportCreate = "create"
+	ImportMatch  = "match"
+	ImportSkip   = "skip"
+)
+
+// ImportOptions tune an import. By default check-ins are added without points.
+type ImportOptions struct {
+	DryRun      bool
+	AwardPoints bool // award check-in points for imported completed days
+}
+
+type ImportReport struct {
+	Source          string              `json:"source"`
+	DryRun          bool                `json:"dry_run"`
+	HabitsCreated   int                 `json:"habits_created"`
+	HabitsMatched   int                 `json:"habits_matched"`
+	CheckinsCreated int                 `json:"checkins_created"`
+	CheckinsSkipped int                 `json:"checkins_skipped"`
+	PointsAwarded   int64               `json:"points_awarded"`
+	Habits          []ImportHabitReport `json:"habits"`
+}
+
+// ImportHabitReport describes one imported habit. HabitID is 0 for habits a
+// dry run would create; Skipped counts days that already had a check-in.
+type ImportHabitReport struct {
+	Name     string `json:"name"`
+	HabitID  uint64 `json:"habit_id,omitempty"`
+	Action   string `json:"action"`
+	Reason   string `json:"reason,omitempty"`
+	Checkins int    `json:"checkins"`
+	Skipped  int    `json:"skipped"`
+}
+
+// ImportService loads habits and check-ins exported from Loop Habit Tracker
+// or from this project. Habits are matched to existing ones by name and days
+// that already have a check-in are left alone, so re-running an import is a
+// no-op.
+type ImportService struct {
+	habitSvc *HabitService
+	habits   repository.HabitStore
+	users    repository.UserStore
+	checkins repository.CheckinStore
+	points   *PointsService
+	guard    *StreakGuardService
+}
+
+func NewImportService(habitSvc *HabitService, habits repository.HabitStore, users repository.UserStore, checkins repository.CheckinStore, points *PointsService, guard *StreakGuardService) *ImportService {
+	return &ImportService{habitSvc: habitSvc, habits: habits, users: users, checkins: checkins, points: points, guard: guard}
+}
+
+func (s *ImportService) Import(ctx context.Context, userID uint64, r io.ReaderAt, size int64, opts ImportOptions) (*ImportReport, error) {
+	batch, err := parseImport(r, size)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := s.habits.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	byName := make(map[string]*models.Habit, len(existing))
+	for i := range existing {
+		byName[existing[i].Name] = &existing[i]
+	}
+	today, err := s.guard.todayOf(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ImportReport{Source: batch.source, DryRun: opts.DryRun, Habits: []ImportHabitReport{}}
+	seen := make(map[string]struct{}, len(batch.habits))
+	for _, item := range batch.habits {
+		// 名称是匹配已有习惯的依据，同名的第二个习惯无法在重复导入时区分，直接跳过
+		if _, dup := seen[item.input.Name]; dup {
+			report.Habits = append(report.Habits, ImportHabitReport{
+				Name:   item.input.Name,
+				Action: ImportSkip,
+				Reason: "another habit in this import has the same name",
+			})
+			continue
+		}
+		seen[item.input.Name] = struct{}{}
+		hr, err := s.importHabit(ctx, userID, today, item, byName[item.input.Name], opts, report)
+		if err != nil {
+			return nil, err
+		}
+		report.Habits = append(report.Habits, hr)
+	}
+	if !opts.DryRun {
+		log.Printf("用户 %d 导入 %s 数据: 新建习惯 %d, 新增打卡 %d, 跳过 %d",
+			userID, batch.source, report.HabitsCreated, report.CheckinsCreated, report.CheckinsSkipped)
+	}
+	return report, nil
+}
+
+func (s *ImportService) importHabit(ctx context.Context, userID uint64, today time.Time, item *importHabit, habit *models.Habit, opts ImportOptions, report *ImportReport) (ImportHabitReport, error) {
+	hr := ImportHabitReport{Name: item.input.Name, Action: ImportMatch}
+	if item.input.StartDate.IsZero() {
+		item.input.StartDate = earliestImportDay(item.checkins, today)
+	}
+
+	if habit != nil {
+		if habit.Kind != item.input.Kind || habit.Polarity != item.input.Polarity {
+			hr.Action, hr.Reason = ImportSkip, "existing habit with this name has a different kind or polarity"
+			return hr, nil
+		}
+		hr.HabitID = habit.ID
+		report.HabitsMatched++
+	} else {
+		hr.Action = ImportCreate
+		if err := validateHabitInput(item.input, false); err != nil {
+			hr.Action, hr.Reason = ImportSkip, err.Error()
+			return hr, nil
+		}
+		report.HabitsCreated++
+		if !opts.DryRun {
+			created, err := s.create(ctx, userID, today, item)
+			if err != nil {
+				return hr, err
+			}
+			habit, hr.HabitID = created, created.ID
+		}
+	}
+
+	taken := map[int]struct{}{}
+	if habit != nil {
+		days, err := s.checkins.ListDaysByHabit(ctx, habit.ID)
+		if err != nil {
+			return hr, err
+		}
+		for _, day := range days {
+			taken[dayKey(day)] = struct{}{}
+		}
+	}
+	var records []models.HabitCheckin
+	for _, c := range item.checkins {
+		if _, ok := taken[dayKey(c.date)]; ok {
+			hr.Skipped++
+			continue
+		}
+		taken[dayKey(c.date)] = struct{}{}
+		created := c.created
+		if created.IsZero() {
+			created = time.Now()
+		}
+		records = append(records, models.HabitCheckin{
+			HabitID:     hr.HabitID,
+			UserID:      userID,
+			CheckinDate: c.date,
+			Count:       c.count,
+			Quantity:    c.quantity,
+			Note:        c.note,
+			Mood:        c.mood,
+			CreatedAt:   created,
+		})
+	}
+	hr.Checkins = len(records)
+	report.CheckinsCreated += hr.Checkins
+	report.CheckinsSkipped += hr.Skipped
+
+	var completed int64
+	if habit == nil {
+		habit = &models.Habit{Kind: item.input.Kind, Polarity: item.input.Polarity, TargetTimes: item.input.TargetTimes, TargetQuantity: item.input.Quantity}
+	}
+	goal := goalOf(habit)
+	for _, rec := range records {
+		if habit.Polarity != models.HabitPolarityQuit && goal.met(rec) {
+			completed++
+		}
+	}
+	if opts.AwardPoints {
+		report.PointsAwarded += completed * baseCheckinPoints
+	}
+	if opts.DryRun {
+		return hr, nil
+	}
+
+	if _, err := s.checkins.InsertMissing(ctx, records); err != nil {
+		return hr, err
+	}
+	if opts.AwardPoints && completed > 0 {
+		if err := s.points.AddPoints(ctx, userID, completed*baseCheckinPoints, "import", &habit.ID); err != nil {
+			return hr, err
+		}
+		if err := s.users.IncrementCheckins(ctx, userID, completed); err != nil {
+			return hr, err
+		}
+	}
+	// 新建的戒除型习惯即使没有破戒记录也要算出连续天数
+	if _, err := s.guard.RebuildStreak(ctx, habit); err != nil {
+		return hr, err
+	}
+	return hr, nil
+}
+
+// create adds a habit for the import. Quit habits start with their clean days
+// already settled so the award-clean-days job does not pay for the history.
+func (s *ImportService) create(ctx context.Context, userID uint64, today time.Time, item *importHabit) (*models.Habit, error) {
+	habit, err := s.habitSvc.Create(ctx, userID, item.input)
+	if err != nil {
+		return nil, err
+	}
+	if habit.Polarity == models.HabitPolarityQuit {
+		yesterday := today.AddDate(0, 0, -1)
+		if err := s.habits.UpdateLastCleanDate(ctx, habit.ID, yesterday); err != nil {
+			return nil, err
+		}
+		habit.LastCleanDate = &yesterday
+	}
+	if item.inactive {
+		if err := s.habits.UpdateStatus(ctx, habit.ID, false); err != nil {
+			return nil, err
+		}
+		habit.IsActive = false
+	}
+	if item.archived {
+		now := time.Now()
+		if err := s.habits.SetArchivedAt(ctx, habit.ID, &now); err != nil {
+			return nil, err
+		}
+		habit.ArchivedAt = &now
+	}
+	return habit, nil
+}
+
+func earliestImportDay(checkins []importCheckin, today time.Time) time.Time {
+	if len(checkins) == 0 {
+		return today
+	}
+	earliest := checkins[0].date
+	for _, c := range checkins[1:] {
+		if c.date.Before(earliest) {
+			earliest = c.date
+		}
+	}
+	return earliest
+}
@@ -0,0 +1,214 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"habit-tracker/internal/apperr"
+	"habit-tracker/internal/models"
+	"habit-tracker/internal/repository"
+	"habit-tracker/internal/storage"
+)
+
+const (
+	maxNoteLength      = 2000
+	MaxPhotoSize       = 5 << 20
+	defaultSearchLimit = 50
+)
+
+var (
+	ErrCheckinNotFound  = apperr.New(apperr.KindNotFound, "checkin_not_found", "check-in not found")
+	ErrPhotoNotFound    = apperr.New(apperr.KindNotFound, "photo_not_found", "check-in has no photo")
+	ErrUnsupportedPhoto = apperr.New(apperr.KindInvalid, "unsupported_photo", "photo must be a jpeg, png, gif or webp image")
+	ErrPhotoTooLarge    = apperr.New(apperr.KindInvalid, "photo_too_large", "photo is too large")
+
+	photoExtensions = map[string]string{
+		"image/jpeg": ".jpg",
+		"image/png":  ".png",
+		"image/gif":  ".gif",
+		"image/webp": ".webp",
+	}
+)
+
+// JournalService manages the notes, mood ratings and photos attached to check-ins.
+type JournalService struct {
+	checkins repository.CheckinStore
+	blobs    storage.BlobStore
+}
+
+func NewJournalService(checkins repository.CheckinStore, blobs storage.BlobStore) *JournalService {
+	return &JournalService{checkins: checkins, blobs: blobs}
+}
+
+// Photo is an opened check-in photo; the caller must close Body.
+type Photo struct {
+	ContentType string
+	Body        io.ReadCloser
+}
+
+// UpdateJournal edits the note and/or mood of a check-in; nil fields stay unchanged.
+func (s *JournalService) UpdateJournal(ctx context.Context, userID, checkinID uint64, note *string, mood *int) (*models.HabitCheckin, error) {
+	rec, err := s.getOwned(ctx, userID, checkinID)
+	if err != nil {
+		return nil, err
+	}
+	if note != nil {
+		if err := validateNote(*note); err != nil {
+			return nil, err
+		}
+		rec.Note = *note
+	}
+	if mood != nil {
+		if err := validateMood(mood); err != nil {
+			return nil, err
+		}
+		rec.Mood = mood
+	}
+	if err := s.checkins.UpdateJournal(ctx, checkinID, note, mood); err != nil {
+		return nil, err
+	}
+	return rec, nil
+}
+
+// UploadPhoto stores r as the check-in photo, replacing any previous one.
+// r must be limited by the caller; reading more than MaxPhotoSize fails.
+func (s *JournalService) UploadPhoto(ctx context.Context, userID, checkinID uint64, r io.Reader) (*models.HabitCheckin, error) {
+	rec, err := s.getOwned(ctx, userID, checkinID)
+	if err != nil {
+		return nil, err
+	}
+
+	br := bufio.NewReaderSize(r, 512)
+	head, err := br.Peek(512)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	ext, ok := photoExtensions[http.DetectContentType(head)]
+	if !ok {
+		return nil, ErrUnsupportedPhoto
+	}
+
+	key := fmt.Sprintf("checkins/%d/%d-%s%s", userID, checkinID, randomHex(8), ext)
+	counter := &countingReader{r: br}
+	if err := s.blobs.Put(ctx, key, counter); err != nil {
+		return nil, err
+	}
+	if counter.n > MaxPhotoSize {
+		_ = s.blobs.Delete(ctx, key)
+		return nil, ErrPhotoTooLarge
+	}
+	if err := s.checkins.SetPhotoKey(ctx, checkinID, key); err != nil {
+		_ = s.blobs.Delete(ctx, key)
+		return nil, err
+	}
+	if rec.PhotoKey != "" {
+		if err := s.blobs.Delete(ctx, rec.PhotoKey); err != nil {
+			log.Printf("delete replaced photo %s: %v", rec.PhotoKey, err)
+		}
+	}
+	rec.PhotoKey = key
+	return rec, nil
+}
+
+func (s *JournalService) OpenPhoto(ctx context.Context, userID, checkinID uint64) (*Photo, error) {
+	rec, err := s.getOwned(ctx, userID, checkinID)
+	if err != nil {
+		return nil, err
+	}
+	if rec.PhotoKey == "" {
+		return nil, ErrPhotoNotFound
+	}
+	body, err := s.blobs.Open(ctx, rec.PhotoKey)
+	if err != nil {
+		if errors.Is(err, storage.ErrBlobNotFound) {
+			return nil, ErrPhotoNotFound
+		}
+		return nil, err
+	}
+	contentType := "application/octet-stream"
+	for ct, ext := range photoExtensions {
+		if strings.HasSuffix(rec.PhotoKey, ext) {
+			contentType = ct
+			break
+		}
+	}
+	return &Photo{ContentType: contentType, Body: body}, nil
+}
+
+func (s *JournalService) DeletePhoto(ctx context.Context, userID, checkinID uint64) error {
+	rec, err := s.getOwned(ctx, userID, checkinID)
+	if err != nil {
+		return err
+	}
+	if rec.PhotoKey == "" {
+		return nil
+	}
+	if err := s.checkins.SetPhotoKey(ctx, checkinID, ""); err != nil {
+		return err
+	}
+	return s.blobs.Delete(ctx, rec.PhotoKey)
+}
+
+// SearchNotes returns the user's check-ins whose note contains query, newest first.
+func (s *JournalService) SearchNotes(ctx context.Context, userID uint64, habitID *uint64, query string) ([]models.HabitCheckin, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, apperr.Invalid("q is required")
+	}
+	return s.checkins.SearchNotes(ctx, userID, habitID, query, defaultSearchLimit)
+}
+
+func (s *JournalService) getOwned(ctx context.Context, userID, checkinID uint64) (*models.HabitCheckin, error) {
+	rec, err := s.checkins.GetByID(ctx, checkinID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCheckinNotFound
+		}
+		return nil, err
+	}
+	if rec.UserID != userID {
+		return nil, ErrCheckinForbidden
+	}
+	return rec, nil
+}
+
+func validateNote(note string) error {
+	if len(note) > maxNoteLength {
+		return apperr.Invalid("note is too long")
+	}
+	return nil
+}
+
+func validateMood(mood *int) error {
+	if mood != nil && (*mood < 1 || *mood > 5) {
+		return apperr.Invalid("mood must be between 1 and 5")
+	}
+	return nil
+}
+
+func randomHex(n int) string {
+	buf := make([]byte, n)
+	_, _ = rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
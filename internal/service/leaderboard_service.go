@@ -6,7 +6,6 @@ import (
 	"time"
 
 	"habit-tracker/internal/repository"
-	"habit-tracker/internal/utils"
 )
 
 type LeaderboardEntry struct {
@@ -17,41 +16,48 @@ type LeaderboardEntry struct {
 }
 
 type LeaderboardService struct {
-	users  *repository.UserRepository
-	points *repository.PointsRepository
+	users  repository.UserStore
+	points repository.PointsStore
 }
 
-func NewLeaderboardService(users *repository.UserRepository, points *repository.PointsRepository) *LeaderboardService {
+func NewLeaderboardService(users repository.UserStore, points repository.PointsStore) *LeaderboardService {
 	return &LeaderboardService{users: users, points: points}
 }
 
 func (s *LeaderboardService) Weekly(ctx context.Context) ([]LeaderboardEntry, error) {
-	today := utils.TruncateDate(time.Now())
-	weekday := today.Weekday()
-	// Calculate the start of the current week (Monday 00:00)
-	start := today.AddDate(0, 0, -int(weekday)+1)
-	if weekday == time.Sunday {
-		start = today.AddDate(0, 0, -6) // Special case for Sunday
-	}
-	end := start.AddDate(0, 0, 7) // End of the week (next Monday 00:00)
-	return s.build(ctx, start, end)
+	return s.build(ctx, currentWeek)
+}
+
+// currentWeek is the window of the weekly leaderboard in loc, from Monday
+// 00:00 to the next Monday.
+func currentWeek(loc *time.Location) (time.Time, time.Time) {
+	start := startOfWeek(inLocation(time.Now().In(loc), loc))
+	return start, start.AddDate(0, 0, 7)
 }
 
 func (s *LeaderboardService) Monthly(ctx context.Context) ([]LeaderboardEntry, error) {
-	today := utils.TruncateDate(time.Now())
-	start := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, today.Location())
-	end := start.AddDate(0, 1, 0)
-	return s.build(ctx, start, end)
+	return s.build(ctx, currentMonth)
+}
+
+// currentMonth is the window of the monthly leaderboard in loc.
+func currentMonth(loc *time.Location) (time.Time, time.Time) {
+	now := time.Now().In(loc)
+	start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, loc)
+	return start, start.AddDate(0, 1, 0)
 }
 
-func (s *LeaderboardService) build(ctx context.Context, start, end time.Time) ([]LeaderboardEntry, error) {
+// build ranks the users by their points within window, taken in each
+// user's own time zone.
+func (s *LeaderboardService) build(ctx context.Context, window func(*time.Location) (time.Time, time.Time)) ([]LeaderboardEntry, error) {
 	users, err := s.users.ListAll(ctx)
 	if err != nil {
 		return nil, err
 	}
 
 	entries := make([]LeaderboardEntry, 0, len(users))
-	for _, u := range users {
+	for i := range users {
+		u := &users[i]
+		start, end := window(userLocation(u))
 		sum, err := s.points.SumByUserAndRange(ctx, u.ID, start, end)
 		if err != nil {
 			return nil, err
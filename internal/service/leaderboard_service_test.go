@@ -0,0 +1,116 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"habit-tracker/internal/models"
+)
+
+func TestLeaderboardRanking(t *testing.T) {
+	env := newTestEnv(t)
+	alice := env.user(t, "alice")
+	bob := env.user(t, "bob")
+	carol := env.user(t, "carol")
+	dave := env.user(t, "dave")
+	env.user(t, "erin") // no points at all
+
+	start := date(2024, time.March, 4)
+	end := start.AddDate(0, 0, 7)
+	in := start.Add(36 * time.Hour)
+	for _, l := range []models.UserPointsLog{
+		{UserID: alice.ID, ChangeAmount: 3, CreatedAt: in},
+		{UserID: alice.ID, ChangeAmount: 50, CreatedAt: start.Add(-time.Second)}, // before the window
+		{UserID: bob.ID, ChangeAmount: 5, CreatedAt: start},
+		{UserID: carol.ID, ChangeAmount: 2, CreatedAt: in},
+		{UserID: carol.ID, ChangeAmount: 3, CreatedAt: end}, // BETWEEN includes the end
+		{UserID: dave.ID, ChangeAmount: 50, CreatedAt: in},
+		{UserID: dave.ID, ChangeAmount: -50, Reason: "streak_freeze", CreatedAt: in},
+	} {
+		l := l
+		if l.Reason == "" {
+			l.Reason = "checkin"
+		}
+		if err := env.points.AddLog(env.ctx, &l); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	entries, err := env.leaderboardSvc.build(env.ctx, func(*time.Location) (time.Time, time.Time) { return start, end })
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []LeaderboardEntry{
+		{UserID: bob.ID, Nickname: "bob", Points: 5, Rank: 1},
+		{UserID: carol.ID, Nickname: "carol", Points: 5, Rank: 2},
+		{UserID: alice.ID, Nickname: "alice", Points: 3, Rank: 3},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("entries = %+v, want %+v", entries, want)
+	}
+	for i := range want {
+		if entries[i] != want[i] {
+			t.Fatalf("entry %d = %+v, want %+v", i, entries[i], want[i])
+		}
+	}
+}
+
+func TestLeaderboardWeeklyIncludesCheckinPoints(t *testing.T) {
+	env := newTestEnv(t)
+	u := env.user(t, "alice")
+	h := env.habit(t, models.Habit{UserID: u.ID})
+	if _, err := env.checkinSvc.Checkin(env.ctx, u.ID, h.ID, CheckinInput{CountInc: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, board := range map[string]func() ([]LeaderboardEntry, error){
+		"weekly":  func() ([]LeaderboardEntry, error) { return env.leaderboardSvc.Weekly(env.ctx) },
+		"monthly": func() ([]LeaderboardEntry, error) { return env.leaderboardSvc.Monthly(env.ctx) },
+	} {
+		entries, err := board()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 1 || entries[0].UserID != u.ID || entries[0].Points != baseCheckinPoints || entries[0].Rank != 1 {
+			t.Fatalf("%s = %+v, want alice first with %d point", name, entries, baseCheckinPoints)
+		}
+	}
+}
+
+func TestWeeklyWindowsFollowTheUserTimezone(t *testing.T) {
+	env := newTestEnv(t)
+	const tz = "Pacific/Kiritimati" // UTC+14: its weeks start 14 hours before UTC ones
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+	u := env.user(t, "alice")
+	if err := env.users.UpdateTimezone(env.ctx, u.ID, tz); err != nil {
+		t.Fatal(err)
+	}
+	weekStart := startOfWeek(inLocation(time.Now().In(loc), loc))
+	for _, l := range []models.UserPointsLog{
+		{UserID: u.ID, ChangeAmount: 2, Reason: "checkin", CreatedAt: weekStart.Add(time.Minute)},
+		{UserID: u.ID, ChangeAmount: 50, Reason: "checkin", CreatedAt: weekStart.Add(-time.Minute)}, // last week for alice
+	} {
+		l := l
+		if err := env.points.AddLog(env.ctx, &l); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	entries, err := env.leaderboardSvc.Weekly(env.ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Points != 2 {
+		t.Fatalf("weekly leaderboard = %+v, want alice with the 2 points of her week", entries)
+	}
+	stats, err := NewUserStatsService(env.users, env.habits, env.checkins, env.pointsSvc).GetStats(env.ctx, u.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.WeeklyPoints != 2 {
+		t.Fatalf("weekly points = %d, want 2", stats.WeeklyPoints)
+	}
+}
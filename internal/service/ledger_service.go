@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"habit-tracker/internal/models"
+	"habit-tracker/internal/repository"
+)
+
+// Sources of ledger adjustments.
+const (
+	LedgerSourceJob = "job"
+	LedgerSourceCLI = "cli"
+)
+
+// CounterDrift is a user whose denormalized counters differ from the points log.
+type CounterDrift struct {
+	UserID           uint64 `json:"user_id"`
+	Username         string `json:"username"`
+	Points           int64  `json:"points"`
+	ExpectedPoints   int64  `json:"expected_points"`
+	TotalCheckins    int64  `json:"total_checkins"`
+	ExpectedCheckins int64  `json:"expected_checkins"`
+	// Fixed reports that the counters were corrected. A drift is left alone
+	// when fixing was not requested, or when the counters changed while they
+	// were being checked; the next run looks at it again.
+	Fixed bool `json:"fixed"`
+}
+
+// ReconcileReport is the result of one reconciliation run.
+type ReconcileReport struct {
+	Checked int            `json:"checked"`
+	Drifts  []CounterDrift `json:"drifts"`
+}
+
+// LedgerService keeps users.points and users.total_checkins in line with
+// user_points_log, which is the source of truth for both: every point change
+// and every counted day writes a log row, while the counters are updated in
+// separate statements and drift when a request fails in between.
+type LedgerService struct {
+	users  repository.UserStore
+	points repository.PointsStore
+	ledger repository.LedgerStore
+
+	mu sync.Mutex
+	// drifts seen by the previous job run
+	pending map[uint64]CounterDrift
+}
+
+func NewLedgerService(users repository.UserStore, points repository.PointsStore, ledger repository.LedgerStore) *LedgerService {
+	return &LedgerService{users: users, points: points, ledger: ledger}
+}
+
+// Reconcile compares the counters of userID, or of every user when it is 0,
+// with the points log. With fix the drifted counters are corrected and each
+// correction is recorded in ledger_adjustments with source.
+func (s *LedgerService) Reconcile(ctx context.Context, userID uint64, fix bool, source string) (*ReconcileReport, error) {
+	drifts, checked, err := s.findDrifts(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	report := &ReconcileReport{Checked: checked}
+	for _, d := range drifts {
+		if fix {
+			if d.Fixed, err = s.correct(ctx, d, source); err != nil {
+				return report, err
+			}
+		}
+		report.Drifts = append(report.Drifts, d)
+	}
+	return report, nil
+}
+
+// ReconcileJob is the periodic reconciliation. A drift observed right after
+// a counter update but before its log row is written is normal, so the job
+// only corrects drifts that show the same values in two consecutive runs.
+func (s *LedgerService) ReconcileJob(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	drifts, _, err := s.findDrifts(ctx, 0)
+	if err != nil {
+		return err
+	}
+	seen := make(map[uint64]CounterDrift, len(drifts))
+	for _, d := range drifts {
+		if prev, ok := s.pending[d.UserID]; !ok || prev != d {
+			log.Printf("用户 %d 计数与积分流水不一致: points %d (应为 %d), total_checkins %d (应为 %d)",
+				d.UserID, d.Points, d.ExpectedPoints, d.TotalCheckins, d.ExpectedCheckins)
+			seen[d.UserID] = d
+			continue
+		}
+		if _, err := s.correct(ctx, d, LedgerSourceJob); err != nil {
+			return err
+		}
+	}
+	s.pending = seen
+	return nil
+}
+
+// ListAdjustments returns the newest corrections first; userID 0 means all users.
+func (s *LedgerService) ListAdjustments(ctx context.Context, userID uint64, limit int) ([]models.LedgerAdjustment, error) {
+	return s.ledger.ListAdjustments(ctx, userID, limit)
+}
+
+func (s *LedgerService) findDrifts(ctx context.Context, userID uint64) ([]CounterDrift, int, error) {
+	var users []models.User
+	if userID != 0 {
+		user, err := s.users.GetByID(ctx, userID)
+		if err != nil {
+			return nil, 0, err
+		}
+		users = []models.User{*user}
+	} else {
+		var err error
+		if users, err = s.users.ListAll(ctx); err != nil {
+			return nil, 0, err
+		}
+	}
+	totals, err := s.points.TotalsByUser(ctx, userID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var drifts []CounterDrift
+	for _, u := range users {
+		want := totals[u.ID]
+		if u.Points == want.Points && u.TotalCheckins == want.Checkins {
+			continue
+		}
+		drifts = append(drifts, CounterDrift{
+			UserID:           u.ID,
+			Username:         u.Username,
+			Points:           u.Points,
+			ExpectedPoints:   want.Points,
+			TotalCheckins:    u.TotalCheckins,
+			ExpectedCheckins: want.Checkins,
+		})
+	}
+	return drifts, len(users), nil
+}
+
+func (s *LedgerService) correct(ctx context.Context, d CounterDrift, source string) (bool, error) {
+	fixed, err := s.ledger.Correct(ctx, repository.CounterCorrection{
+		UserID:      d.UserID,
+		OldPoints:   d.Points,
+		NewPoints:   d.ExpectedPoints,
+		OldCheckins: d.TotalCheckins,
+		NewCheckins: d.ExpectedCheckins,
+	}, source, time.Now())
+	if err != nil {
+		return false, err
+	}
+	if fixed {
+		log.Printf("用户 %d 计数已校正（%s）: points %d -> %d, total_checkins %d -> %d",
+			d.UserID, source, d.Points, d.ExpectedPoints, d.TotalCheckins, d.ExpectedCheckins)
+	}
+	return fixed, nil
+}
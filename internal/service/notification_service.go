@@ -0,0 +1,175 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"habit-tracker/internal/apperr"
+	"habit-tracker/internal/events"
+	"habit-tracker/internal/models"
+	"habit-tracker/internal/repository"
+)
+
+const (
+	defaultNotificationLimit = 50
+	maxNotificationLimit     = 100
+	// Read notifications are kept for NotificationReadRetention, unread
+	// ones for NotificationRetention.
+	NotificationReadRetention = 30 * 24 * time.Hour
+	NotificationRetention     = 90 * 24 * time.Hour
+)
+
+var ErrNotificationNotFound = apperr.New(apperr.KindNotFound, "notification_not_found", "notification not found")
+
+// NotificationService is the user's in-app inbox. Besides reminders, it
+// fills the inbox from domain events: unlocked achievements, points changes
+// that are not routine check-in rewards, and being overtaken on the weekly
+// leaderboard.
+type NotificationService struct {
+	notifications repository.NotificationStore
+	users         repository.UserStore
+	points        repository.PointsStore
+}
+
+func NewNotificationService(notifications repository.NotificationStore, users repository.UserStore, points repository.PointsStore) *NotificationService {
+	return &NotificationService{notifications: notifications, users: users, points: points}
+}
+
+type NotificationList struct {
+	Items []models.Notification `json:"items"`
+	// NextBeforeID continues the list with ?before_id=; 0 when there are no more.
+	NextBeforeID uint64 `json:"next_before_id"`
+}
+
+// List returns the user's notifications newest first, a page at a time.
+func (s *NotificationService) List(ctx context.Context, userID uint64, unreadOnly bool, beforeID uint64, limit int) (*NotificationList, error) {
+	if limit <= 0 {
+		limit = defaultNotificationLimit
+	}
+	if limit > maxNotificationLimit {
+		limit = maxNotificationLimit
+	}
+	// 多取一条用于判断是否还有下一页
+	items, err := s.notifications.ListByUser(ctx, userID, unreadOnly, beforeID, limit+1)
+	if err != nil {
+		return nil, err
+	}
+	res := &NotificationList{Items: items}
+	if len(items) > limit {
+		res.Items = items[:limit]
+		res.NextBeforeID = res.Items[limit-1].ID
+	}
+	return res, nil
+}
+
+func (s *NotificationService) UnreadCount(ctx context.Context, userID uint64) (int64, error) {
+	return s.notifications.CountUnread(ctx, userID)
+}
+
+// MarkRead marks one notification read; marking it again is a no-op.
+func (s *NotificationService) MarkRead(ctx context.Context, userID, id uint64) error {
+	found, err := s.notifications.MarkRead(ctx, userID, id, time.Now())
+	if err != nil {
+		return err
+	}
+	if !found {
+		return ErrNotificationNotFound
+	}
+	return nil
+}
+
+// MarkAllRead marks every unread notification read and returns how many changed.
+func (s *NotificationService) MarkAllRead(ctx context.Context, userID uint64) (int64, error) {
+	return s.notifications.MarkAllRead(ctx, userID, time.Now())
+}
+
+// Purge is the retention job for the inbox.
+func (s *NotificationService) Purge(ctx context.Context) error {
+	now := time.Now()
+	n, err := s.notifications.Purge(ctx, now.Add(-NotificationReadRetention), now.Add(-NotificationRetention))
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		log.Printf("清理过期站内通知 %d 条", n)
+	}
+	return nil
+}
+
+func (s *NotificationService) create(ctx context.Context, userID uint64, kind, title, body string, habitID *uint64) error {
+	return s.notifications.Create(ctx, &models.Notification{
+		UserID:    userID,
+		Kind:      kind,
+		Title:     title,
+		Body:      body,
+		HabitID:   habitID,
+		CreatedAt: time.Now(),
+	})
+}
+
+// OnAchievementUnlocked and OnPointsChanged are async bus subscribers: a
+// failure to write the inbox must not fail the check-in that caused it.
+
+func (s *NotificationService) OnAchievementUnlocked(ctx context.Context, e events.AchievementUnlocked) error {
+	return s.create(ctx, e.UserID, models.NotificationAchievement,
+		"解锁成就："+e.Title, "恭喜你解锁了成就「"+e.Title+"」", nil)
+}
+
+func (s *NotificationService) OnPointsChanged(ctx context.Context, e events.PointsChanged) error {
+	// 打卡和戒除习惯的日常积分在打卡结果里已经可见，不再逐条通知
+	if e.Reason != "checkin" && e.Reason != "clean_day" {
+		title := fmt.Sprintf("积分 %+d", e.Delta)
+		body := fmt.Sprintf("原因：%s，当前积分 %d", e.Reason, e.Balance)
+		if err := s.create(ctx, e.UserID, models.NotificationPoints, title, body, e.HabitID); err != nil {
+			return err
+		}
+	}
+	if e.Delta > 0 {
+		return s.notifyOvertaken(ctx, e)
+	}
+	return nil
+}
+
+// notifyOvertaken tells the users whose weekly points the user has just
+// passed: those whose sum lies in [before, after) of the user's own sum.
+// The user's sum is taken as of the change, so concurrent changes that are
+// handled out of order do not notify anyone twice. The week is the user's
+// own; the others' sums are taken over the same window.
+func (s *NotificationService) notifyOvertaken(ctx context.Context, e events.PointsChanged) error {
+	user, err := s.users.GetByID(ctx, e.UserID)
+	if err != nil {
+		return err
+	}
+	start, end := currentWeek(userLocation(user))
+	if e.At.Before(start) || e.At.After(end) {
+		return nil
+	}
+	after, err := s.points.SumByUserAndRange(ctx, e.UserID, start, e.At)
+	if err != nil {
+		return err
+	}
+	before := after - e.Delta
+	if before < 1 {
+		before = 1 // 没有积分变动的用户不上榜
+	}
+	if before >= after {
+		return nil
+	}
+	ids, err := s.points.ListUsersWithSumInRange(ctx, start, end, before, after, e.UserID)
+	if err != nil || len(ids) == 0 {
+		return err
+	}
+	name := user.Nickname
+	if name == "" {
+		name = user.Username
+	}
+	for _, id := range ids {
+		body := fmt.Sprintf("%s 在本周排行榜上超过了你，本周积分 %d", name, after)
+		if err := s.create(ctx, id, models.NotificationLeaderboard, "排行榜名次变化", body, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"habit-tracker/internal/apperr"
+	"habit-tracker/internal/models"
+	"habit-tracker/internal/repository"
+)
+
+const webhookTimeout = 5 * time.Second
+
+var (
+	ErrInvalidWebhookURL   = apperr.New(apperr.KindInvalid, "invalid_webhook_url", "webhook url must be an absolute http or https url")
+	ErrInternalWebhookHost = apperr.New(apperr.KindInvalid, "internal_webhook_host", "webhook url must not point to a loopback, private or link-local address")
+)
+
+var errWebhookAddress = errors.New("webhook address is not publicly routable")
+
+// Message is something to tell a user, independent of how it is delivered.
+type Message struct {
+	Kind    string
+	Title   string
+	Body    string
+	HabitID *uint64
+}
+
+// Notifier delivers a message to a user over one channel.
+type Notifier interface {
+	Notify(ctx context.Context, user *models.User, msg Message) error
+}
+
+// MultiNotifier delivers to every notifier, even if some fail.
+type MultiNotifier []Notifier
+
+func (m MultiNotifier) Notify(ctx context.Context, user *models.User, msg Message) error {
+	var errs []error
+	for _, n := range m {
+		if err := n.Notify(ctx, user, msg); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// InboxNotifier stores the message in the user's in-app inbox.
+type InboxNotifier struct {
+	notifications repository.NotificationStore
+}
+
+func NewInboxNotifier(notifications repository.NotificationStore) *InboxNotifier {
+	return &InboxNotifier{notifications: notifications}
+}
+
+func (n *InboxNotifier) Notify(ctx context.Context, user *models.User, msg Message) error {
+	return n.notifications.Create(ctx, &models.Notification{
+		UserID:    user.ID,
+		Kind:      msg.Kind,
+		Title:     msg.Title,
+		Body:      msg.Body,
+		HabitID:   msg.HabitID,
+		CreatedAt: time.Now(),
+	})
+}
+
+// WebhookNotifier POSTs the message as JSON to the user's reminder webhook
+// URL; users without one are skipped.
+type WebhookNotifier struct {
+	client *http.Client
+}
+
+func NewWebhookNotifier() *WebhookNotifier {
+	return &WebhookNotifier{client: newWebhookClient(webhookTimeout)}
+}
+
+// newWebhookClient returns a client for user supplied URLs. The address is
+// checked after DNS resolution, right before connecting, so a host name that
+// resolves (or later rebinds) to an internal address is refused as well.
+// Redirects are not followed; a 3xx response counts as a failed delivery.
+func newWebhookClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout, Control: refuseInternalAddress}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil // 经代理连接时无法校验目标地址
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+func refuseInternalAddress(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !publicIP(ip) {
+		return fmt.Errorf("%w: %s", errWebhookAddress, host)
+	}
+	return nil
+}
+
+// publicIP rejects loopback, private, link-local, multicast and unspecified addresses.
+func publicIP(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified())
+}
+
+type webhookMessage struct {
+	Kind    string    `json:"kind"`
+	Title   string    `json:"title"`
+	Body    string    `json:"body"`
+	HabitID *uint64   `json:"habit_id,omitempty"`
+	SentAt  time.Time `json:"sent_at"`
+}
+
+func (n *WebhookNotifier) Notify(ctx context.Context, user *models.User, msg Message) error {
+	if user.ReminderWebhookURL == "" {
+		return nil
+	}
+	body, err := json.Marshal(webhookMessage{
+		Kind:    msg.Kind,
+		Title:   msg.Title,
+		Body:    msg.Body,
+		HabitID: msg.HabitID,
+		SentAt:  time.Now().UTC(),
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, user.ReminderWebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook %s returned %d", user.ReminderWebhookURL, resp.StatusCode)
+	}
+	return nil
+}
+
+// validateWebhookURL rejects URLs that obviously target this network. Host
+// names are only resolved when delivering, where newWebhookClient checks the
+// address actually dialed.
+func validateWebhookURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return ErrInvalidWebhookURL
+	}
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return ErrInternalWebhookHost
+	}
+	if ip := net.ParseIP(host); ip != nil && !publicIP(ip) {
+		return ErrInternalWebhookHost
+	}
+	return nil
+}
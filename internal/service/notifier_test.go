@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"habit-tracker/internal/models"
+)
+
+func TestWebhookNotifierRefusesInternalAddresses(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+	}))
+	defer srv.Close()
+
+	n := NewWebhookNotifier()
+	// the host name only becomes loopback after resolution
+	for _, target := range []string{srv.URL, strings.Replace(srv.URL, "127.0.0.1", "localhost", 1)} {
+		user := &models.User{ReminderWebhookURL: target + "/remind"}
+		err := n.Notify(context.Background(), user, Message{Kind: "reminder", Title: "t"})
+		if !errors.Is(err, errWebhookAddress) {
+			t.Errorf("notify %s: err = %v, want %v", target, err, errWebhookAddress)
+		}
+	}
+	if hits.Load() != 0 {
+		t.Fatalf("internal server was called %d times", hits.Load())
+	}
+}
+
+func TestWebhookClientDoesNotFollowRedirects(t *testing.T) {
+	client := newWebhookClient(webhookTimeout)
+	if err := client.CheckRedirect(nil, nil); !errors.Is(err, http.ErrUseLastResponse) {
+		t.Fatalf("CheckRedirect = %v, want http.ErrUseLastResponse", err)
+	}
+}
+
+func TestPublicIP(t *testing.T) {
+	for addr, want := range map[string]bool{
+		"93.184.216.34":        true,
+		"2606:2800:220:1::248": true,
+		"127.0.0.1":            false,
+		"::1":                  false,
+		"10.1.2.3":             false,
+		"172.16.0.1":           false,
+		"192.168.1.1":          false,
+		"169.254.169.254":      false,
+		"fe80::1":              false,
+		"fd00::1":              false,
+		"0.0.0.0":              false,
+		"::":                   false,
+		"::ffff:127.0.0.1":     false,
+		"224.0.0.1":            false,
+	} {
+		if got := publicIP(net.ParseIP(addr)); got != want {
+			t.Errorf("publicIP(%s) = %v, want %v", addr, got, want)
+		}
+	}
+}
+
+func TestValidateWebhookURL(t *testing.T) {
+	for raw, want := range map[string]error{
+		"https://example.com/hook":         nil,
+		"http://93.184.216.34:8080/hook":   nil,
+		"ftp://example.com/hook":           ErrInvalidWebhookURL,
+		"https:///hook":                    ErrInvalidWebhookURL,
+		"http://localhost:8080/hook":       ErrInternalWebhookHost,
+		"http://api.localhost./hook":       ErrInternalWebhookHost,
+		"http://127.0.0.1/hook":            ErrInternalWebhookHost,
+		"http://[::1]/hook":                ErrInternalWebhookHost,
+		"http://10.0.0.5/hook":             ErrInternalWebhookHost,
+		"http://169.254.169.254/latest":    ErrInternalWebhookHost,
+		"http://[::ffff:192.168.0.1]/hook": ErrInternalWebhookHost,
+	} {
+		if err := validateWebhookURL(raw); !errors.Is(err, want) {
+			t.Errorf("validateWebhookURL(%q) = %v, want %v", raw, err, want)
+		}
+	}
+}
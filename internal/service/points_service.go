@@ -5,17 +5,19 @@ import (
 	"time"
 	"log"
 
+	"habit-tracker/internal/events"
 	"habit-tracker/internal/models"
 	"habit-tracker/internal/repository"
 )
 
 type PointsService struct {
-	users  *repository.UserRepository
-	points *repository.PointsRepository
+	users  repository.UserStore
+	points repository.PointsStore
+	bus    *events.Bus
 }
 
-func NewPointsService(users *repository.UserRepository, points *repository.PointsRepository) *PointsService {
-	return &PointsService{users: users, points: points}
+func NewPointsService(users repository.UserStore, points repository.PointsStore, bus *events.Bus) *PointsService {
+	return &PointsService{users: users, points: points, bus: bus}
 }
 
 // AddPoints applies delta to user's points and logs the change.
@@ -34,7 +36,34 @@ func (s *PointsService) AddPoints(ctx context.Context, userID uint64, delta int6
 		return err
 	}
 	log.Printf("用户 %d 积分变动: %d", userID, pointLog.ChangeAmount)
-	return nil
+	return s.publishChange(ctx, userID, delta, reason, relatedHabitID, pointLog.CreatedAt)
+}
+
+// publishChange announces a points change that has already been applied and
+// logged, with the balance after it. at is the created_at of the log entry.
+func (s *PointsService) publishChange(ctx context.Context, userID uint64, delta int64, reason string, relatedHabitID *uint64, at time.Time) error {
+	balance, err := s.GetUserPoints(ctx, userID)
+	if err != nil {
+		return err
+	}
+	return s.bus.Publish(ctx, events.PointsChanged{
+		UserID:  userID,
+		Delta:   delta,
+		Balance: balance,
+		Reason:  reason,
+		HabitID: relatedHabitID,
+		At:      at,
+	})
+}
+
+// OnTargetReached awards the points of a completed day: check-in points for
+// build habits, clean-day points for quit habits.
+func (s *PointsService) OnTargetReached(ctx context.Context, e events.TargetReached) error {
+	reason, delta := "checkin", int64(baseCheckinPoints)
+	if e.Polarity == models.HabitPolarityQuit {
+		reason, delta = "clean_day", cleanDayPoints
+	}
+	return s.AddPoints(ctx, e.UserID, delta, reason, &e.HabitID)
 }
 
 func (s *PointsService) SumByRange(ctx context.Context, userID uint64, start, end time.Time) (int64, error) {
@@ -0,0 +1,44 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+
+	"habit-tracker/internal/apperr"
+	"habit-tracker/internal/models"
+	"habit-tracker/internal/repository"
+)
+
+var ErrUserNotFound = apperr.New(apperr.KindNotFound, "user_not_found", "user not found")
+
+type ProfileService struct {
+	users repository.UserStore
+}
+
+func NewProfileService(users repository.UserStore) *ProfileService {
+	return &ProfileService{users: users}
+}
+
+func (s *ProfileService) Get(ctx context.Context, userID uint64) (*models.User, error) {
+	user, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+// SetTimezone stores the IANA time zone used for the user's analytics.
+func (s *ProfileService) SetTimezone(ctx context.Context, userID uint64, tz string) (*models.User, error) {
+	if _, _, err := loadTimezone(tz); err != nil {
+		return nil, err
+	}
+	if err := s.users.UpdateTimezone(ctx, userID, tz); err != nil {
+		return nil, err
+	}
+	return s.Get(ctx, userID)
+}
@@ -0,0 +1,218 @@
+package service
+
+import (
+	"context"
+	"log"
+	"sort"
+	"time"
+
+	"habit-tracker/internal/apperr"
+	"habit-tracker/internal/events"
+	"habit-tracker/internal/models"
+)
+
+const (
+	cleanDayPoints = baseCheckinPoints
+	// maxCleanDayCatchUp bounds how many past days one run may award, so a
+	// long outage does not dump weeks of points into the current leaderboard.
+	maxCleanDayCatchUp = 7
+)
+
+var (
+	ErrQuitHabitCheckin = apperr.New(apperr.KindConflict, "quit_habit_checkin", "quit habits log relapses instead of check-ins")
+	ErrNotQuitHabit     = apperr.New(apperr.KindConflict, "not_quit_habit", "only quit habits can log relapses")
+)
+
+type RelapseResult struct {
+	RelapsesToday  int `json:"relapses_today"`
+	PreviousStreak int `json:"previous_streak"`
+	StreakDays     int `json:"streak_days"`
+}
+
+// Relapse logs a relapse for today on a quit habit, resetting its streak.
+func (s *CheckinService) Relapse(ctx context.Context, userID, habitID uint64) (*RelapseResult, error) {
+	habit, err := s.getOwnedHabit(ctx, userID, habitID)
+	if err != nil {
+		return nil, err
+	}
+	if habit.ArchivedAt != nil {
+		return nil, ErrHabitArchived
+	}
+	if habit.Polarity != models.HabitPolarityQuit {
+		return nil, ErrNotQuitHabit
+	}
+
+	today, err := s.guard.todayOf(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	records, err := s.checkinRepo.ListByHabitDesc(ctx, habitID)
+	if err != nil {
+		return nil, err
+	}
+	previous := daysSinceRelapse(records, habit.StartDate, today)
+
+	todayRec, _, err := s.upsertToday(ctx, userID, habitID, CheckinInput{CountInc: 1}, today)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.guard.RebuildStreak(ctx, habit); err != nil {
+		return nil, err
+	}
+	log.Printf("用户 %d 习惯 %d 记录破戒，连续天数 %d 清零", userID, habitID, previous)
+
+	return &RelapseResult{
+		RelapsesToday:  todayRec.Count,
+		PreviousStreak: previous,
+		StreakDays:     0,
+	}, nil
+}
+
+// AwardCleanDays is the daily job for quit habits: every finished day without
+// a relapse earns cleanDayPoints and counts as a completed check-in. Each day
+// is claimed through habits.last_clean_date before it is awarded, so failed or
+// concurrent runs never pay a day twice and the job can run more than once a day.
+// A day is awarded once it is over in the user's time zone.
+func (s *CheckinService) AwardCleanDays(ctx context.Context) error {
+	habits, err := s.habitRepo.ListTrackedByPolarity(ctx, models.HabitPolarityQuit)
+	if err != nil {
+		return err
+	}
+	todays := map[uint64]time.Time{}
+	for i := range habits {
+		today, ok := todays[habits[i].UserID]
+		if !ok {
+			if today, err = s.guard.todayOf(ctx, habits[i].UserID); err != nil {
+				return err
+			}
+			todays[habits[i].UserID] = today
+		}
+		if err := s.awardCleanDays(ctx, &habits[i], today.AddDate(0, 0, -1)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *CheckinService) awardCleanDays(ctx context.Context, habit *models.Habit, through time.Time) error {
+	from := habit.StartDate
+	if habit.LastCleanDate != nil {
+		from = habit.LastCleanDate.AddDate(0, 0, 1)
+	}
+	if earliest := through.AddDate(0, 0, -(maxCleanDayCatchUp - 1)); from.Before(earliest) {
+		from = earliest
+	}
+	if daysBetween(from, through) < 0 {
+		return nil
+	}
+
+	records, err := s.checkinRepo.ListByHabitDesc(ctx, habit.ID)
+	if err != nil {
+		return err
+	}
+	relapsed := make(map[int]struct{}, len(records))
+	for _, rec := range records {
+		if rec.Count > 0 {
+			relapsed[dayKey(rec.CheckinDate)] = struct{}{}
+		}
+	}
+
+	// 每个无破戒日先认领再发布一次 TargetReached，由订阅者发放积分并评估成就；
+	// 发布失败的那一天已被认领，不会在下次运行时重复发放
+	awarded := 0
+	for day := from; dayKey(day) <= dayKey(through); day = day.AddDate(0, 0, 1) {
+		if _, ok := relapsed[dayKey(day)]; ok {
+			continue
+		}
+		claimed, err := s.habitRepo.ClaimCleanDay(ctx, habit.ID, habit.UserID, day)
+		if err != nil {
+			return err
+		}
+		if !claimed {
+			continue
+		}
+		if err := s.bus.Publish(ctx, events.TargetReached{
+			UserID:        habit.UserID,
+			HabitID:       habit.ID,
+			HabitName:     habit.Name,
+			Polarity:      habit.Polarity,
+			Date:          day,
+			StreakDays:    daysSinceRelapse(records, habit.StartDate, day),
+			TotalCheckins: cleanDaysThrough(records, habit.StartDate, day),
+		}); err != nil {
+			return err
+		}
+		awarded++
+	}
+	if err := s.habitRepo.UpdateLastCleanDate(ctx, habit.ID, through); err != nil {
+		return err
+	}
+	habit.LastCleanDate = &through
+	if _, err := s.guard.RebuildStreak(ctx, habit); err != nil {
+		return err
+	}
+	if awarded > 0 {
+		log.Printf("用户 %d 戒除习惯 %d 新增 %d 个无破戒日", habit.UserID, habit.ID, awarded)
+	}
+	return nil
+}
+
+// cleanDaysThrough counts the days from start through day without a relapse;
+// it is the check-in total of a quit habit for achievements.
+func cleanDaysThrough(records []models.HabitCheckin, start, day time.Time) int {
+	days := daysBetween(start, day) + 1
+	for _, rec := range records {
+		if rec.Count > 0 && dayKey(rec.CheckinDate) <= dayKey(day) && dayKey(rec.CheckinDate) >= dayKey(start) {
+			days--
+		}
+	}
+	if days < 0 {
+		return 0
+	}
+	return days
+}
+
+// daysSinceRelapse is the streak of a quit habit: days after the last relapse
+// (or since start when there is none) up to and including today.
+// records must be sorted by checkin_date desc.
+func daysSinceRelapse(records []models.HabitCheckin, start, today time.Time) int {
+	anchor := start.AddDate(0, 0, -1)
+	for _, rec := range records {
+		if rec.Count <= 0 || rec.CheckinDate.After(today) {
+			continue
+		}
+		if rec.CheckinDate.After(anchor) {
+			anchor = rec.CheckinDate
+		}
+		break
+	}
+	days := daysBetween(anchor, today)
+	if days < 0 {
+		return 0
+	}
+	return days
+}
+
+// longestCleanRun is the longest stretch of days without a relapse between
+// start and today, counted the same way as daysSinceRelapse.
+func longestCleanRun(records []models.HabitCheckin, start, today time.Time) int {
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].CheckinDate.Before(records[j].CheckinDate)
+	})
+
+	prev := start.AddDate(0, 0, -1)
+	best := 0
+	for _, rec := range records {
+		if rec.Count <= 0 || rec.CheckinDate.Before(start) || rec.CheckinDate.After(today) {
+			continue
+		}
+		if gap := daysBetween(prev, rec.CheckinDate) - 1; gap > best {
+			best = gap
+		}
+		prev = rec.CheckinDate
+	}
+	if tail := daysBetween(prev, today); tail > best {
+		best = tail
+	}
+	return best
+}
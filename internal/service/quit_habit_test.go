@@ -0,0 +1,51 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"habit-tracker/internal/events"
+	"habit-tracker/internal/models"
+)
+
+func TestAwardCleanDaysNeverPaysTwice(t *testing.T) {
+	env := newTestEnv(t)
+	u := env.user(t, "alice")
+	h := env.habit(t, models.Habit{UserID: u.ID, Polarity: models.HabitPolarityQuit, StartDate: todayDate().AddDate(0, 0, -5)})
+
+	// the third clean day fails after its points were paid, as a later
+	// subscriber or a crash would
+	failOn, published := 3, 0
+	errSubscriber := errors.New("subscriber failed")
+	events.Subscribe(env.bus, func(ctx context.Context, e events.TargetReached) error {
+		published++
+		if published == failOn {
+			return errSubscriber
+		}
+		return nil
+	})
+
+	if err := env.checkinSvc.AwardCleanDays(env.ctx); !errors.Is(err, errSubscriber) {
+		t.Fatalf("first run err = %v, want %v", err, errSubscriber)
+	}
+	// a retry and a second instance running the job both find nothing left to pay twice
+	for run := 0; run < 2; run++ {
+		if err := env.checkinSvc.AwardCleanDays(env.ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got := env.reload(t, u)
+	if published != 5 || got.Points != 5*cleanDayPoints || got.TotalCheckins != 5 {
+		t.Fatalf("published %d, points=%d total_checkins=%d; want each of the 5 clean days paid once",
+			published, got.Points, got.TotalCheckins)
+	}
+	habit, err := env.habits.GetByID(env.ctx, h.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if habit.LastCleanDate == nil || dayKey(*habit.LastCleanDate) != dayKey(todayDate().AddDate(0, 0, -1)) {
+		t.Fatalf("last_clean_date = %v, want yesterday", habit.LastCleanDate)
+	}
+}
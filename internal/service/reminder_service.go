@@ -0,0 +1,218 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+
+	"habit-tracker/internal/apperr"
+	"habit-tracker/internal/models"
+	"habit-tracker/internal/repository"
+)
+
+const (
+	maxRemindersPerHabit = 5
+	// reminderGrace is how late a reminder may still be sent. The scheduler
+	// runs every minute; after downtime, reminders of the current local day
+	// that are at most this overdue are caught up once, older ones are
+	// skipped for the day. Earlier days are never caught up.
+	reminderGrace = 2 * time.Hour
+)
+
+var ErrQuitHabitReminder = apperr.New(apperr.KindInvalid, "quit_habit_reminder", "reminders are only available for build habits")
+
+// ReminderService stores per-habit reminder times and sends the due ones.
+type ReminderService struct {
+	reminders repository.ReminderStore
+	habits    repository.HabitStore
+	users     repository.UserStore
+	checkins  repository.CheckinStore
+	vacations repository.VacationStore
+	notifier  Notifier
+}
+
+func NewReminderService(reminders repository.ReminderStore, habits repository.HabitStore, users repository.UserStore, checkins repository.CheckinStore, vacations repository.VacationStore, notifier Notifier) *ReminderService {
+	return &ReminderService{reminders: reminders, habits: habits, users: users, checkins: checkins, vacations: vacations, notifier: notifier}
+}
+
+// HabitReminders lists a habit's reminder times as HH:MM in the user's time zone.
+type HabitReminders struct {
+	HabitID uint64   `json:"habit_id"`
+	Times   []string `json:"times"`
+}
+
+func (s *ReminderService) List(ctx context.Context, userID, habitID uint64) (*HabitReminders, error) {
+	habit, err := s.getOwned(ctx, userID, habitID)
+	if err != nil {
+		return nil, err
+	}
+	return s.list(ctx, habit.ID)
+}
+
+// Set replaces the habit's reminder times; an empty list removes them all.
+func (s *ReminderService) Set(ctx context.Context, userID, habitID uint64, times []string) (*HabitReminders, error) {
+	habit, err := s.getOwned(ctx, userID, habitID)
+	if err != nil {
+		return nil, err
+	}
+	if habit.Polarity == models.HabitPolarityQuit {
+		return nil, ErrQuitHabitReminder
+	}
+	seen := make(map[int]bool, len(times))
+	minutes := make([]int, 0, len(times))
+	for _, t := range times {
+		parsed, err := time.Parse("15:04", t)
+		if err != nil {
+			return nil, apperr.Invalid("reminder times must look like HH:MM")
+		}
+		m := parsed.Hour()*60 + parsed.Minute()
+		if !seen[m] {
+			seen[m] = true
+			minutes = append(minutes, m)
+		}
+	}
+	if len(minutes) > maxRemindersPerHabit {
+		return nil, apperr.Invalid(fmt.Sprintf("at most %d reminders per habit", maxRemindersPerHabit))
+	}
+	if err := s.reminders.ReplaceForHabit(ctx, habit, minutes); err != nil {
+		return nil, err
+	}
+	return s.list(ctx, habit.ID)
+}
+
+// SetWebhook sets the URL reminders are POSTed to; empty turns it off.
+func (s *ReminderService) SetWebhook(ctx context.Context, userID uint64, url string) error {
+	if url != "" {
+		if err := validateWebhookURL(url); err != nil {
+			return err
+		}
+	}
+	return s.users.UpdateReminderWebhookURL(ctx, userID, url)
+}
+
+func (s *ReminderService) list(ctx context.Context, habitID uint64) (*HabitReminders, error) {
+	items, err := s.reminders.ListByHabit(ctx, habitID)
+	if err != nil {
+		return nil, err
+	}
+	out := &HabitReminders{HabitID: habitID, Times: make([]string, 0, len(items))}
+	for _, item := range items {
+		out.Times = append(out.Times, fmt.Sprintf("%02d:%02d", item.MinuteOfDay/60, item.MinuteOfDay%60))
+	}
+	sort.Strings(out.Times)
+	return out, nil
+}
+
+func (s *ReminderService) getOwned(ctx context.Context, userID, habitID uint64) (*models.Habit, error) {
+	habit, err := s.habits.GetByID(ctx, habitID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrHabitNotFound
+		}
+		return nil, err
+	}
+	if habit.UserID != userID {
+		return nil, ErrHabitForbidden
+	}
+	return habit, nil
+}
+
+// reminderOwner caches a user and their time zone for one scheduler run.
+type reminderOwner struct {
+	user *models.User
+	loc  *time.Location
+}
+
+// SendDue is the scheduler job. Each reminder is handled at most once per
+// local day: it is claimed with MarkFired before sending, so a failed
+// delivery is logged and not retried. Reminders are skipped on days the habit
+// is not scheduled, during vacations and once the day's target is reached.
+func (s *ReminderService) SendDue(ctx context.Context) error {
+	items, err := s.reminders.ListTracked(ctx)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	owners := map[uint64]*reminderOwner{}
+	for i := range items {
+		item := &items[i]
+		owner, ok := owners[item.UserID]
+		if !ok {
+			owner, err = s.owner(ctx, item.UserID)
+			if err != nil {
+				return err
+			}
+			owners[item.UserID] = owner
+		}
+
+		local := now.In(owner.loc)
+		day := userDay(owner.user, now) // 与打卡记录使用同一个日期
+		if item.LastFiredOn != nil && dayKey(*item.LastFiredOn) >= dayKey(day) {
+			continue
+		}
+		y, m, d := local.Date()
+		due := time.Date(y, m, d, item.MinuteOfDay/60, item.MinuteOfDay%60, 0, 0, owner.loc)
+		if local.Before(due) {
+			continue
+		}
+		claimed, err := s.reminders.MarkFired(ctx, item.ID, day)
+		if err != nil {
+			return err
+		}
+		if !claimed {
+			continue
+		}
+		if now.Sub(due) > reminderGrace {
+			log.Printf("提醒 %d 已过期 %s，今天跳过", item.ID, now.Sub(due).Round(time.Minute))
+			continue
+		}
+		if err := s.send(ctx, owner.user, item, day); err != nil {
+			log.Printf("提醒 %d 发送失败: %v", item.ID, err)
+		}
+	}
+	return nil
+}
+
+func (s *ReminderService) owner(ctx context.Context, userID uint64) (*reminderOwner, error) {
+	user, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &reminderOwner{user: user, loc: userLocation(user)}, nil
+}
+
+func (s *ReminderService) send(ctx context.Context, user *models.User, item *models.HabitReminder, day time.Time) error {
+	habit, err := s.habits.GetByID(ctx, item.HabitID)
+	if err != nil {
+		return err
+	}
+	if habit.Polarity == models.HabitPolarityQuit || !dueOn(habit, day) {
+		return nil
+	}
+	vacations, err := s.vacations.ListByUser(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	if (streakShield{vacations: vacations}).covers(day) {
+		return nil
+	}
+	rec, err := s.checkins.GetByHabitAndDate(ctx, habit.ID, day)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	if rec != nil && goalOf(habit).met(*rec) {
+		return nil
+	}
+
+	return s.notifier.Notify(ctx, user, Message{
+		Kind:    models.NotificationReminder,
+		Title:   "该打卡了：" + habit.Name,
+		Body:    fmt.Sprintf("今天的「%s」还没有完成%s", habit.Name, goalLabel(habit)),
+		HabitID: &habit.ID,
+	})
+}
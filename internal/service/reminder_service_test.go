@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"habit-tracker/internal/models"
+)
+
+type recordingNotifier struct {
+	sent []Message
+}
+
+func (n *recordingNotifier) Notify(ctx context.Context, user *models.User, msg Message) error {
+	n.sent = append(n.sent, msg)
+	return nil
+}
+
+// offUTCZone returns a zone whose current day differs from the UTC day:
+// UTC+14 from 10:00 UTC on, UTC-11 before 11:00 UTC.
+func offUTCZone(t *testing.T) string {
+	t.Helper()
+	for _, name := range []string{"Pacific/Kiritimati", "Pacific/Pago_Pago"} {
+		loc, err := time.LoadLocation(name)
+		if err != nil {
+			t.Skipf("time zone data unavailable: %v", err)
+		}
+		if dayKey(time.Now().In(loc)) != dayKey(time.Now().UTC()) {
+			return name
+		}
+	}
+	t.Fatal("no zone is on another day than UTC")
+	return ""
+}
+
+func TestSendDueUsesTheDayCheckinsAreStoredUnder(t *testing.T) {
+	for _, done := range []bool{false, true} {
+		env := newTestEnv(t)
+		tz := offUTCZone(t)
+		u := env.user(t, "alice")
+		if err := env.users.UpdateTimezone(env.ctx, u.ID, tz); err != nil {
+			t.Fatal(err)
+		}
+		u = env.reload(t, u)
+		h := env.habit(t, models.Habit{UserID: u.ID})
+		if done {
+			if _, err := env.checkinSvc.Checkin(env.ctx, u.ID, h.ID, CheckinInput{CountInc: 1}); err != nil {
+				t.Fatal(err)
+			}
+			rec, err := env.checkins.GetByHabitAndDate(env.ctx, h.ID, userDay(u, time.Now()))
+			if err != nil || dayKey(rec.CheckinDate) == dayKey(time.Now().UTC()) {
+				t.Fatalf("check-in of a %s user not stored on their local day (%v)", tz, err)
+			}
+		}
+		// due this very minute of the user's day
+		local := time.Now().In(userLocation(u))
+		if err := env.reminders.ReplaceForHabit(env.ctx, h, []int{local.Hour()*60 + local.Minute()}); err != nil {
+			t.Fatal(err)
+		}
+
+		notifier := &recordingNotifier{}
+		svc := NewReminderService(env.reminders, env.habits, env.users, env.checkins, env.vacations, notifier)
+		if err := svc.SendDue(env.ctx); err != nil {
+			t.Fatal(err)
+		}
+		if want := map[bool]int{false: 1, true: 0}[done]; len(notifier.sent) != want {
+			t.Fatalf("done today = %v: sent %d reminders, want %d", done, len(notifier.sent), want)
+		}
+	}
+}
+
+func TestHardDeleteRemovesReminders(t *testing.T) {
+	env := newTestEnv(t)
+	u := env.user(t, "alice")
+	h := env.habit(t, models.Habit{UserID: u.ID})
+	if err := env.reminders.ReplaceForHabit(env.ctx, h, []int{8 * 60, 20 * 60}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := env.habitSvc.HardDelete(env.ctx, u.ID, h.ID, PointsPolicyKeep); err != nil {
+		t.Fatal(err)
+	}
+	left, err := env.reminders.ListByHabit(env.ctx, h.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(left) != 0 {
+		t.Fatalf("reminders left after hard delete = %+v, want none", left)
+	}
+}
@@ -0,0 +1,54 @@
+package service
+
+import (
+	"time"
+
+	"habit-tracker/internal/models"
+	"habit-tracker/internal/repository"
+)
+
+// scheduleOf returns the weekdays a habit is due. Daily and quit habits are due
+// every day; weekly habits created before schedules existed fall back to the
+// weekday of StartDate.
+func scheduleOf(h *models.Habit) models.WeekdaySet {
+	if h.Polarity == models.HabitPolarityQuit || h.TargetType == "daily" {
+		return models.AllWeekdays
+	}
+	if h.ScheduleDays != 0 {
+		return h.ScheduleDays
+	}
+	if h.TargetType == "weekly" {
+		return models.WeekdaySetOf(h.StartDate.Weekday())
+	}
+	return models.AllWeekdays
+}
+
+// countWeekdays counts the days in [from, to] per weekday, keeping only those in set.
+func countWeekdays(from, to time.Time, set models.WeekdaySet) [7]int {
+	var out [7]int
+	days := daysBetween(from, to) + 1
+	if days <= 0 {
+		return out
+	}
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if set.Has(d) {
+			out[d] = days / 7
+		}
+	}
+	first := from.Weekday()
+	for i := 0; i < days%7; i++ {
+		d := (first + time.Weekday(i)) % 7
+		if set.Has(d) {
+			out[d]++
+		}
+	}
+	return out
+}
+
+// threshold is the SQL form of the goal for aggregate queries.
+func (g habitGoal) threshold() repository.DayThreshold {
+	if g.measurable {
+		return repository.QuantityAtLeast(g.quantity)
+	}
+	return repository.CountAtLeast(g.times)
+}
@@ -0,0 +1,331 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+
+	"habit-tracker/internal/apperr"
+	"habit-tracker/internal/models"
+	"habit-tracker/internal/repository"
+)
+
+const (
+	// MaxHeldFreezes caps the unused freezes a user can hold at once.
+	MaxHeldFreezes = 3
+	// StreakFreezePrice is the points cost of buying one freeze.
+	StreakFreezePrice = 50
+	// FreezeEarnStreak earns a free freeze every time a habit's streak reaches a multiple of it.
+	FreezeEarnStreak = 7
+	// maxFreezeCatchUp bounds how many past days are checked for missed days to freeze.
+	maxFreezeCatchUp = 7
+	maxVacationDays  = 60
+	recentFreezes    = 20
+)
+
+var (
+	ErrFreezeLimit        = apperr.New(apperr.KindConflict, "streak_freeze_limit", "streak freeze inventory is full")
+	ErrInsufficientPoints = apperr.New(apperr.KindConflict, "insufficient_points", "not enough points")
+	ErrVacationNotFound   = apperr.New(apperr.KindNotFound, "vacation_not_found", "vacation not found")
+	ErrVacationForbidden  = apperr.New(apperr.KindForbidden, "vacation_forbidden", "vacation does not belong to user")
+	ErrVacationOverlap    = apperr.New(apperr.KindConflict, "vacation_overlap", "vacation overlaps an existing one")
+	ErrVacationEnded      = apperr.New(apperr.KindConflict, "vacation_ended", "vacation has already ended")
+)
+
+// StreakGuardService keeps build streaks alive across missed days: streak
+// freezes cover single missed days and are consumed automatically, vacations
+// pause every habit of the user for a date range.
+type StreakGuardService struct {
+	habits    repository.HabitStore
+	users     repository.UserStore
+	checkins  repository.CheckinStore
+	freezes   repository.StreakFreezeStore
+	vacations repository.VacationStore
+	points    *PointsService
+}
+
+func NewStreakGuardService(habits repository.HabitStore, users repository.UserStore, checkins repository.CheckinStore, freezes repository.StreakFreezeStore, vacations repository.VacationStore, points *PointsService) *StreakGuardService {
+	return &StreakGuardService{habits: habits, users: users, checkins: checkins, freezes: freezes, vacations: vacations, points: points}
+}
+
+// todayOf is the user's current day, the day their check-ins are stored under.
+func (s *StreakGuardService) todayOf(ctx context.Context, userID uint64) (time.Time, error) {
+	user, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return userDay(user, time.Now()), nil
+}
+
+type FreezeSummary struct {
+	Available  int64                 `json:"available"`
+	MaxHeld    int                   `json:"max_held"`
+	Price      int                   `json:"price"`
+	EarnStreak int                   `json:"earn_streak"`
+	RecentUsed []models.StreakFreeze `json:"recent_used"`
+}
+
+func (s *StreakGuardService) Summary(ctx context.Context, userID uint64) (*FreezeSummary, error) {
+	available, err := s.freezes.CountAvailable(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	used, err := s.freezes.ListUsedByUser(ctx, userID, recentFreezes)
+	if err != nil {
+		return nil, err
+	}
+	return &FreezeSummary{
+		Available:  available,
+		MaxHeld:    MaxHeldFreezes,
+		Price:      StreakFreezePrice,
+		EarnStreak: FreezeEarnStreak,
+		RecentUsed: used,
+	}, nil
+}
+
+// Purchase buys one freeze for StreakFreezePrice points.
+func (s *StreakGuardService) Purchase(ctx context.Context, userID uint64) (*FreezeSummary, error) {
+	available, err := s.freezes.CountAvailable(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if available >= MaxHeldFreezes {
+		return nil, ErrFreezeLimit
+	}
+	freeze, bought, err := s.freezes.Purchase(ctx, userID, StreakFreezePrice)
+	if err != nil {
+		return nil, err
+	}
+	if !bought {
+		return nil, ErrInsufficientPoints
+	}
+	log.Printf("用户 %d 花费 %d 积分购买连续打卡冻结卡", userID, StreakFreezePrice)
+	// 扣分与记账已在仓储事务中提交，这里只补发积分变动事件
+	if err := s.points.publishChange(ctx, userID, -StreakFreezePrice, "streak_freeze", nil, freeze.CreatedAt); err != nil {
+		return nil, err
+	}
+	return s.Summary(ctx, userID)
+}
+
+// earn grants a free freeze unless the inventory is full.
+func (s *StreakGuardService) earn(ctx context.Context, userID uint64) (bool, error) {
+	available, err := s.freezes.CountAvailable(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	if available >= MaxHeldFreezes {
+		return false, nil
+	}
+	err = s.freezes.Create(ctx, &models.StreakFreeze{
+		UserID:    userID,
+		Source:    models.StreakFreezeEarned,
+		CreatedAt: time.Now(),
+	})
+	return err == nil, err
+}
+
+func (s *StreakGuardService) ListVacations(ctx context.Context, userID uint64) ([]models.Vacation, error) {
+	return s.vacations.ListByUser(ctx, userID)
+}
+
+// CreateVacation schedules a vacation. It cannot start in the past, so it
+// never repairs a streak that is already broken.
+func (s *StreakGuardService) CreateVacation(ctx context.Context, userID uint64, start, end time.Time) (*models.Vacation, error) {
+	today, err := s.todayOf(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if dayKey(start) < dayKey(today) {
+		return nil, apperr.Invalid("vacation cannot start in the past")
+	}
+	if dayKey(end) < dayKey(start) {
+		return nil, apperr.Invalid("end_date must not be before start_date")
+	}
+	if daysBetween(start, end)+1 > maxVacationDays {
+		return nil, apperr.Invalid("vacation is too long")
+	}
+	overlaps, err := s.vacations.Overlaps(ctx, userID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	if overlaps {
+		return nil, ErrVacationOverlap
+	}
+
+	item := &models.Vacation{UserID: userID, StartDate: start, EndDate: end, CreatedAt: time.Now()}
+	if err := s.vacations.Create(ctx, item); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// CancelVacation deletes a vacation that has not started yet, or ends a
+// running one yesterday so that today counts again.
+func (s *StreakGuardService) CancelVacation(ctx context.Context, userID, vacationID uint64) error {
+	item, err := s.vacations.GetByID(ctx, vacationID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrVacationNotFound
+		}
+		return err
+	}
+	if item.UserID != userID {
+		return ErrVacationForbidden
+	}
+
+	today, err := s.todayOf(ctx, userID)
+	if err != nil {
+		return err
+	}
+	switch {
+	case dayKey(item.EndDate) < dayKey(today):
+		return ErrVacationEnded
+	case dayKey(item.StartDate) >= dayKey(today):
+		return s.vacations.Delete(ctx, item.ID)
+	default:
+		return s.vacations.UpdateEndDate(ctx, item.ID, today.AddDate(0, 0, -1))
+	}
+}
+
+// ProtectStreaks is the daily job that spends freezes on yesterday's missed
+// days (catching up at most maxFreezeCatchUp days) for users holding any.
+// Yesterday is the user's: a day is only frozen once it is over for them.
+func (s *StreakGuardService) ProtectStreaks(ctx context.Context) error {
+	userIDs, err := s.freezes.ListUserIDsWithAvailable(ctx)
+	if err != nil {
+		return err
+	}
+	for _, userID := range userIDs {
+		today, err := s.todayOf(ctx, userID)
+		if err != nil {
+			return err
+		}
+		habits, err := s.habits.ListTrackedByUser(ctx, userID, models.HabitPolarityBuild)
+		if err != nil {
+			return err
+		}
+		for i := range habits {
+			if _, err := s.protect(ctx, &habits[i], today); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// shieldFor loads the days protected for habit without consuming anything.
+func (s *StreakGuardService) shieldFor(ctx context.Context, habit *models.Habit) (streakShield, error) {
+	shield := streakShield{frozen: map[int]struct{}{}, schedule: scheduleOf(habit)}
+	if habit.Polarity == models.HabitPolarityQuit {
+		return shield, nil
+	}
+	days, err := s.freezes.ListUsedDays(ctx, habit.ID)
+	if err != nil {
+		return shield, err
+	}
+	for _, day := range days {
+		shield.freeze(day)
+	}
+	shield.vacations, err = s.vacations.ListByUser(ctx, habit.UserID)
+	return shield, err
+}
+
+// protect is shieldFor plus consuming freezes for the missed days between the
+// habit's last completed day and today, oldest first. Freezes are only spent
+// while the persisted streak is still alive and never on days the habit is not
+// due; today is never frozen because it is not over yet.
+func (s *StreakGuardService) protect(ctx context.Context, habit *models.Habit, today time.Time) (streakShield, error) {
+	shield, err := s.shieldFor(ctx, habit)
+	if err != nil || habit.Polarity == models.HabitPolarityQuit {
+		return shield, err
+	}
+	if habit.LastCompletedDate == nil || habit.CurrentStreak == 0 {
+		return shield, nil
+	}
+
+	last := *habit.LastCompletedDate
+	from := today.AddDate(0, 0, -maxFreezeCatchUp)
+	if dayKey(from) <= dayKey(last) {
+		from = last.AddDate(0, 0, 1)
+	} else if !shield.coversBetween(last, from) {
+		return shield, nil // 超出回溯范围的漏打卡已中断连续
+	}
+	for day := from; dayKey(day) < dayKey(today); day = day.AddDate(0, 0, 1) {
+		if shield.covers(day) {
+			continue
+		}
+		consumed, err := s.freezes.Consume(ctx, habit.UserID, habit.ID, day)
+		if err != nil {
+			return shield, err
+		}
+		if !consumed {
+			break
+		}
+		shield.freeze(day)
+		log.Printf("用户 %d 习惯 %d 使用冻结卡保护 %s 的连续打卡", habit.UserID, habit.ID, day.Format("2006-01-02"))
+	}
+	return shield, nil
+}
+
+// streakShield holds the days that neither extend nor break a build streak:
+// days the habit is not scheduled, days covered by a consumed freeze and the
+// user's vacation days. The zero value protects nothing.
+type streakShield struct {
+	frozen    map[int]struct{}
+	vacations []models.Vacation
+	// schedule is the habit's due weekdays; zero means every day is due.
+	schedule models.WeekdaySet
+}
+
+func (s *streakShield) freeze(day time.Time) {
+	if s.frozen == nil {
+		s.frozen = map[int]struct{}{}
+	}
+	s.frozen[dayKey(day)] = struct{}{}
+}
+
+func (s streakShield) covers(day time.Time) bool {
+	if s.schedule != 0 && !s.schedule.Has(day.Weekday()) {
+		return true
+	}
+	key := dayKey(day)
+	if _, ok := s.frozen[key]; ok {
+		return true
+	}
+	for _, v := range s.vacations {
+		if dayKey(v.StartDate) <= key && key <= dayKey(v.EndDate) {
+			return true
+		}
+	}
+	return false
+}
+
+// coversBetween reports whether every day strictly between a and b is covered.
+func (s streakShield) coversBetween(a, b time.Time) bool {
+	for day := a.AddDate(0, 0, 1); dayKey(day) < dayKey(b); day = day.AddDate(0, 0, 1) {
+		if !s.covers(day) {
+			return false
+		}
+	}
+	return true
+}
+
+// dayKey maps a date to yyyymmdd so dates from different locations compare by calendar day.
+func dayKey(t time.Time) int {
+	y, m, d := t.Date()
+	return y*10000 + int(m)*100 + d
+}
+
+// metDays returns the calendar days whose record reached the goal.
+func metDays(records []models.HabitCheckin, goal habitGoal) map[int]struct{} {
+	met := make(map[int]struct{}, len(records))
+	for _, rec := range records {
+		if goal.met(rec) {
+			met[dayKey(rec.CheckinDate)] = struct{}{}
+		}
+	}
+	return met
+}
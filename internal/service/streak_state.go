@@ -0,0 +1,172 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"habit-tracker/internal/models"
+)
+
+// streakState mirrors the persisted streak columns of a habit. For build
+// habits current is the streak as of lastCompleted; for quit habits it is the
+// run of finished clean days up to yesterday.
+type streakState struct {
+	current       int
+	longest       int
+	lastCompleted *time.Time
+}
+
+// currentStreak is the build streak as of today from the persisted state:
+// it survives until the first missed day the shield does not cover.
+func currentStreak(h *models.Habit, today time.Time, shield streakShield) int {
+	if h.LastCompletedDate == nil {
+		return 0
+	}
+	if !shield.coversBetween(*h.LastCompletedDate, today.AddDate(0, 0, 1)) {
+		return 0
+	}
+	return h.CurrentStreak
+}
+
+// advanceStreak records today's completion of a build habit incrementally.
+func (s *CheckinService) advanceStreak(ctx context.Context, habit *models.Habit, today time.Time, shield streakShield) error {
+	last := habit.LastCompletedDate
+	if last != nil && sameDate(*last, today) {
+		return nil
+	}
+	current := 1
+	if last != nil && habit.CurrentStreak > 0 && shield.coversBetween(*last, today) {
+		current = habit.CurrentStreak + 1
+	}
+	longest := habit.LongestStreak
+	if current > longest {
+		longest = current
+	}
+	if err := s.habitRepo.UpdateStreakState(ctx, habit.ID, current, longest, &today); err != nil {
+		return err
+	}
+	habit.CurrentStreak, habit.LongestStreak, habit.LastCompletedDate = current, longest, &today
+	return nil
+}
+
+// RebuildStreaks recomputes the persisted streak state of every habit from its
+// full check-in history and returns how many habits changed.
+func (s *StreakGuardService) RebuildStreaks(ctx context.Context) (int, error) {
+	habits, err := s.habits.ListAll(ctx)
+	if err != nil {
+		return 0, err
+	}
+	changed := 0
+	for i := range habits {
+		updated, err := s.RebuildStreak(ctx, &habits[i])
+		if err != nil {
+			return changed, err
+		}
+		if updated {
+			changed++
+		}
+	}
+	return changed, nil
+}
+
+// BackfillStreaks rebuilds only the habits whose streak state was never
+// persisted. It is cheap once everything is backfilled and runs at startup.
+func (s *StreakGuardService) BackfillStreaks(ctx context.Context) (int, error) {
+	habits, err := s.habits.ListMissingStreakState(ctx)
+	if err != nil {
+		return 0, err
+	}
+	changed := 0
+	for i := range habits {
+		updated, err := s.RebuildStreak(ctx, &habits[i])
+		if err != nil {
+			return changed, err
+		}
+		if updated {
+			changed++
+		}
+	}
+	return changed, nil
+}
+
+// RebuildStreak recomputes one habit's streak state from history, reporting
+// whether the stored values differed.
+func (s *StreakGuardService) RebuildStreak(ctx context.Context, habit *models.Habit) (bool, error) {
+	records, err := s.checkins.ListByHabitDesc(ctx, habit.ID)
+	if err != nil {
+		return false, err
+	}
+	var state streakState
+	if habit.Polarity == models.HabitPolarityQuit {
+		today, err := s.todayOf(ctx, habit.UserID)
+		if err != nil {
+			return false, err
+		}
+		state = quitStreakState(habit, records, today)
+	} else {
+		shield, err := s.shieldFor(ctx, habit)
+		if err != nil {
+			return false, err
+		}
+		state = buildStreakState(records, goalOf(habit), shield)
+	}
+
+	if state.current == habit.CurrentStreak && state.longest == habit.LongestStreak &&
+		sameOptionalDate(state.lastCompleted, habit.LastCompletedDate) {
+		return false, nil
+	}
+	if err := s.habits.UpdateStreakState(ctx, habit.ID, state.current, state.longest, state.lastCompleted); err != nil {
+		return false, err
+	}
+	log.Printf("习惯 %d 连续天数重建: 当前 %d -> %d, 最长 %d -> %d",
+		habit.ID, habit.CurrentStreak, state.current, habit.LongestStreak, state.longest)
+	habit.CurrentStreak, habit.LongestStreak, habit.LastCompletedDate = state.current, state.longest, state.lastCompleted
+	return true, nil
+}
+
+func buildStreakState(records []models.HabitCheckin, goal habitGoal, shield streakShield) streakState {
+	var last *time.Time
+	for i := range records {
+		if !goal.met(records[i]) {
+			continue
+		}
+		if last == nil || records[i].CheckinDate.After(*last) {
+			day := records[i].CheckinDate
+			last = &day
+		}
+	}
+	if last == nil {
+		return streakState{}
+	}
+	return streakState{
+		current:       countConsecutiveFromToday(records, goal, *last, shield),
+		longest:       longestStreakForHabit(records, goal, shield),
+		lastCompleted: last,
+	}
+}
+
+// quitStreakState counts finished days only: today joins the streak once the
+// clean-day job has awarded it, but a relapse today resets it at once.
+func quitStreakState(h *models.Habit, records []models.HabitCheckin, today time.Time) streakState {
+	yesterday := today.AddDate(0, 0, -1)
+	current := daysSinceRelapse(records, h.StartDate, today)
+	if current > 0 {
+		current--
+	}
+	longest := 0
+	if dayKey(yesterday) >= dayKey(h.StartDate) {
+		longest = longestCleanRun(records, h.StartDate, yesterday)
+	}
+	if current > longest {
+		longest = current
+	}
+	return streakState{current: current, longest: longest, lastCompleted: h.LastCleanDate}
+}
+
+func sameOptionalDate(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return sameDate(*a, *b)
+}
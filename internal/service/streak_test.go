@@ -0,0 +1,428 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"habit-tracker/internal/events"
+	"habit-tracker/internal/models"
+)
+
+// streakToday anchors the pure streak functions to a fixed day.
+var streakToday = date(2024, time.March, 10)
+
+func daysAgo(n int) time.Time {
+	return streakToday.AddDate(0, 0, -n)
+}
+
+// doneOn returns one completed record per day, n days before streakToday.
+func doneOn(ago ...int) []models.HabitCheckin {
+	records := make([]models.HabitCheckin, 0, len(ago))
+	for _, n := range ago {
+		records = append(records, checkinOn(daysAgo(n), 1))
+	}
+	return records
+}
+
+// weekly is the shield of a habit due on Mondays and Thursdays; streakToday
+// is a Sunday, so the due days are 3, 6, 10 and 13 days ago.
+var weekly = streakShield{schedule: models.WeekdaySetOf(time.Monday, time.Thursday)}
+
+func frozen(ago ...int) streakShield {
+	var s streakShield
+	for _, n := range ago {
+		s.freeze(daysAgo(n))
+	}
+	return s
+}
+
+func TestStreakShield(t *testing.T) {
+	var zero streakShield
+	if zero.covers(streakToday) {
+		t.Fatal("zero shield covers a day")
+	}
+	s := frozen(3)
+	s.vacations = []models.Vacation{{StartDate: daysAgo(7), EndDate: daysAgo(5)}}
+	for ago, want := range map[int]bool{2: false, 3: true, 4: false, 5: true, 6: true, 7: true, 8: false} {
+		if got := s.covers(daysAgo(ago)); got != want {
+			t.Errorf("covers(%d days ago) = %v, want %v", ago, got, want)
+		}
+	}
+	if !s.coversBetween(daysAgo(4), daysAgo(3)) {
+		t.Error("adjacent days have nothing between them to cover")
+	}
+	if !s.coversBetween(daysAgo(8), daysAgo(4)) {
+		t.Error("vacation does not bridge the days between")
+	}
+	if s.coversBetween(daysAgo(8), daysAgo(2)) {
+		t.Error("uncovered day 4 days ago is bridged")
+	}
+	if !weekly.covers(streakToday) || weekly.covers(daysAgo(3)) {
+		t.Error("schedule does not cover exactly the days the habit is not due")
+	}
+	if !weekly.coversBetween(daysAgo(6), daysAgo(3)) || weekly.coversBetween(daysAgo(10), daysAgo(3)) {
+		t.Error("schedule bridges a due day or does not bridge the days between")
+	}
+}
+
+func TestCountConsecutiveFromToday(t *testing.T) {
+	tests := []struct {
+		name    string
+		records []models.HabitCheckin
+		goal    habitGoal
+		shield  streakShield
+		want    int
+	}{
+		{"no records", nil, habitGoal{times: 1}, streakShield{}, 0},
+		{"run ending today", doneOn(0, 1, 2, 4), habitGoal{times: 1}, streakShield{}, 3},
+		{"today not done", doneOn(1, 2), habitGoal{times: 1}, streakShield{}, 0},
+		{"gap frozen", doneOn(0, 1, 2, 4), habitGoal{times: 1}, frozen(3), 4},
+		{"today frozen", doneOn(1, 2), habitGoal{times: 1}, frozen(0), 2},
+		{"under target", doneOn(0), habitGoal{times: 2}, streakShield{}, 0},
+		{"weekly schedule", doneOn(3, 6, 10), habitGoal{times: 1}, weekly, 3},
+		{"weekly missed due day", doneOn(3, 10), habitGoal{times: 1}, weekly, 1},
+		{"measurable", []models.HabitCheckin{{CheckinDate: daysAgo(0), Quantity: 5}, {CheckinDate: daysAgo(1), Quantity: 4.9}},
+			habitGoal{measurable: true, quantity: 5}, streakShield{}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := countConsecutiveFromToday(tt.records, tt.goal, streakToday, tt.shield); got != tt.want {
+				t.Fatalf("got %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLongestStreakForHabit(t *testing.T) {
+	records := doneOn(10, 9, 8, 5, 4, 3, 2, 1)
+	tests := []struct {
+		name    string
+		records []models.HabitCheckin
+		goal    habitGoal
+		shield  streakShield
+		want    int
+	}{
+		{"longest run", records, habitGoal{times: 1}, streakShield{}, 5},
+		{"gap frozen", records, habitGoal{times: 1}, frozen(7, 6), 8},
+		{"gap partly frozen", records, habitGoal{times: 1}, frozen(7), 5},
+		{"weekly schedule", doneOn(13, 10, 3), habitGoal{times: 1}, weekly, 2},
+		{"weekly schedule unbroken", doneOn(13, 10, 6, 3), habitGoal{times: 1}, weekly, 4},
+		{"invalid goal", records, habitGoal{}, streakShield{}, 0},
+		{"never met", records, habitGoal{times: 2}, streakShield{}, 0},
+		{"no records", nil, habitGoal{times: 1}, streakShield{}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := longestStreakForHabit(tt.records, tt.goal, tt.shield); got != tt.want {
+				t.Fatalf("got %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildStreakState(t *testing.T) {
+	records := doneOn(10, 9, 8, 7, 6, 5, 3, 2)
+	records = append(records, checkinOn(daysAgo(1), 0)) // logged but not met
+
+	state := buildStreakState(records, habitGoal{times: 1}, streakShield{})
+	if state.current != 2 || state.longest != 6 || state.lastCompleted == nil || !sameDate(*state.lastCompleted, daysAgo(2)) {
+		t.Fatalf("state = %d/%d last %v, want 2/6 two days ago", state.current, state.longest, state.lastCompleted)
+	}
+	state = buildStreakState(records, habitGoal{times: 1}, frozen(4))
+	if state.current != 8 || state.longest != 8 {
+		t.Fatalf("state with freeze = %d/%d, want 8/8", state.current, state.longest)
+	}
+	if state := buildStreakState(nil, habitGoal{times: 1}, streakShield{}); state != (streakState{}) {
+		t.Fatalf("state without records = %+v, want zero", state)
+	}
+}
+
+func TestCurrentStreak(t *testing.T) {
+	vacation := streakShield{vacations: []models.Vacation{{StartDate: daysAgo(1), EndDate: daysAgo(0)}}}
+	tests := []struct {
+		name   string
+		last   *time.Time
+		shield streakShield
+		want   int
+	}{
+		{"never completed", nil, streakShield{}, 0},
+		{"completed today", ptrTime(daysAgo(0)), streakShield{}, 5},
+		{"missed day", ptrTime(daysAgo(2)), streakShield{}, 0},
+		{"missed days on vacation", ptrTime(daysAgo(2)), vacation, 5},
+		{"missed day frozen but today open", ptrTime(daysAgo(2)), frozen(1), 0},
+		{"weekly not due since", ptrTime(daysAgo(3)), weekly, 5},
+		{"weekly missed due day", ptrTime(daysAgo(6)), weekly, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &models.Habit{CurrentStreak: 5, LastCompletedDate: tt.last}
+			if got := currentStreak(h, streakToday, tt.shield); got != tt.want {
+				t.Fatalf("got %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuitStreakFunctions(t *testing.T) {
+	start := daysAgo(10)
+	relapses := doneOn(8, 3)
+	if got := daysSinceRelapse(nil, daysAgo(2), streakToday); got != 3 {
+		t.Errorf("daysSinceRelapse without relapse = %d, want 3", got)
+	}
+	if got := daysSinceRelapse(doneOn(3, 8), start, streakToday); got != 3 {
+		t.Errorf("daysSinceRelapse = %d, want 3", got)
+	}
+	if got := daysSinceRelapse(doneOn(0), start, streakToday); got != 0 {
+		t.Errorf("daysSinceRelapse after relapse today = %d, want 0", got)
+	}
+	future := []models.HabitCheckin{checkinOn(streakToday.AddDate(0, 0, 1), 1), checkinOn(daysAgo(1), 0), checkinOn(daysAgo(5), 1)}
+	if got := daysSinceRelapse(future, start, streakToday); got != 5 {
+		t.Errorf("daysSinceRelapse skipping future and empty records = %d, want 5", got)
+	}
+	if got := longestCleanRun(relapses, start, streakToday); got != 4 {
+		t.Errorf("longestCleanRun = %d, want 4", got)
+	}
+	if got := cleanDaysThrough(relapses, start, streakToday); got != 9 {
+		t.Errorf("cleanDaysThrough = %d, want 9", got)
+	}
+}
+
+func TestQuitStreakState(t *testing.T) {
+	lastClean := daysAgo(1)
+	tests := []struct {
+		name    string
+		start   time.Time
+		records []models.HabitCheckin
+		current int
+		longest int
+	}{
+		{"clean since start", daysAgo(10), nil, 10, 10},
+		{"relapse", daysAgo(10), doneOn(4), 3, 6},
+		{"relapse today", daysAgo(10), doneOn(0), 0, 10},
+		{"started today", daysAgo(0), nil, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &models.Habit{Polarity: models.HabitPolarityQuit, StartDate: tt.start, LastCleanDate: &lastClean}
+			state := quitStreakState(h, tt.records, streakToday)
+			if state.current != tt.current || state.longest != tt.longest || state.lastCompleted != h.LastCleanDate {
+				t.Fatalf("state = %d/%d last %v, want %d/%d", state.current, state.longest, state.lastCompleted, tt.current, tt.longest)
+			}
+		})
+	}
+}
+
+func TestRebuildStreaks(t *testing.T) {
+	env := newTestEnv(t)
+	u := env.user(t, "alice")
+	today := todayDate()
+	build := env.habit(t, models.Habit{UserID: u.ID})
+	quit := env.habit(t, models.Habit{UserID: u.ID, Polarity: models.HabitPolarityQuit, StartDate: today.AddDate(0, 0, -10)})
+
+	var records []models.HabitCheckin
+	for _, ago := range []int{6, 3, 2, 1} {
+		records = append(records, models.HabitCheckin{HabitID: build.ID, UserID: u.ID, CheckinDate: today.AddDate(0, 0, -ago), Count: 1})
+	}
+	records = append(records, models.HabitCheckin{HabitID: quit.ID, UserID: u.ID, CheckinDate: today.AddDate(0, 0, -4), Count: 1})
+	if _, err := env.checkins.InsertMissing(env.ctx, records); err != nil {
+		t.Fatal(err)
+	}
+	for _, ago := range []int{5, 4} {
+		day := today.AddDate(0, 0, -ago)
+		if err := env.freezes.Create(env.ctx, &models.StreakFreeze{UserID: u.ID, Source: models.StreakFreezeEarned, HabitID: &build.ID, UsedOn: &day}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	changed, err := env.guardSvc.RebuildStreaks(env.ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changed != 2 {
+		t.Fatalf("changed = %d, want 2", changed)
+	}
+	stored, err := env.habits.GetByID(env.ctx, build.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.CurrentStreak != 4 || stored.LongestStreak != 4 || !sameOptionalDate(stored.LastCompletedDate, ptrTime(today.AddDate(0, 0, -1))) {
+		t.Fatalf("build habit = %d/%d last %v, want 4/4 yesterday", stored.CurrentStreak, stored.LongestStreak, stored.LastCompletedDate)
+	}
+	if stored, err = env.habits.GetByID(env.ctx, quit.ID); err != nil {
+		t.Fatal(err)
+	}
+	if stored.CurrentStreak != 3 || stored.LongestStreak != 6 {
+		t.Fatalf("quit habit = %d/%d, want 3/6", stored.CurrentStreak, stored.LongestStreak)
+	}
+
+	if changed, err = env.guardSvc.RebuildStreaks(env.ctx); err != nil || changed != 0 {
+		t.Fatalf("second rebuild changed %d (err %v), want 0", changed, err)
+	}
+}
+
+func TestBackfillStreaks(t *testing.T) {
+	env := newTestEnv(t)
+	u := env.user(t, "alice")
+	today := todayDate()
+	missing := env.habit(t, models.Habit{UserID: u.ID})
+	// already persisted, even if stale: left to the regular updates
+	persisted := env.habit(t, models.Habit{UserID: u.ID, CurrentStreak: 9, LongestStreak: 9, LastCompletedDate: ptrTime(today.AddDate(0, 0, -5))})
+	env.habit(t, models.Habit{UserID: u.ID})
+
+	var records []models.HabitCheckin
+	for _, h := range []*models.Habit{missing, persisted} {
+		for _, ago := range []int{2, 1} {
+			records = append(records, models.HabitCheckin{HabitID: h.ID, UserID: u.ID, CheckinDate: today.AddDate(0, 0, -ago), Count: 1})
+		}
+	}
+	if _, err := env.checkins.InsertMissing(env.ctx, records); err != nil {
+		t.Fatal(err)
+	}
+
+	changed, err := env.guardSvc.BackfillStreaks(env.ctx)
+	if err != nil || changed != 1 {
+		t.Fatalf("backfill changed %d (err %v), want 1", changed, err)
+	}
+	stored, err := env.habits.GetByID(env.ctx, missing.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.CurrentStreak != 2 || stored.LongestStreak != 2 || !sameOptionalDate(stored.LastCompletedDate, ptrTime(today.AddDate(0, 0, -1))) {
+		t.Fatalf("backfilled habit = %d/%d last %v, want 2/2 yesterday", stored.CurrentStreak, stored.LongestStreak, stored.LastCompletedDate)
+	}
+	if stored, err = env.habits.GetByID(env.ctx, persisted.ID); err != nil {
+		t.Fatal(err)
+	}
+	if stored.CurrentStreak != 9 {
+		t.Fatalf("persisted habit current = %d, want it untouched", stored.CurrentStreak)
+	}
+
+	if changed, err = env.guardSvc.BackfillStreaks(env.ctx); err != nil || changed != 0 {
+		t.Fatalf("second backfill changed %d (err %v), want 0", changed, err)
+	}
+}
+
+func TestUpdateGoalRebuildsStreak(t *testing.T) {
+	env := newTestEnv(t)
+	u := env.user(t, "alice")
+	h := env.habit(t, models.Habit{UserID: u.ID, TargetType: "daily", TargetTimes: 1})
+	yesterday := checkinOn(todayDate().AddDate(0, 0, -1), 1)
+	yesterday.HabitID, yesterday.UserID = h.ID, u.ID
+	if _, err := env.checkins.Upsert(env.ctx, &yesterday); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := env.guardSvc.RebuildStreak(env.ctx, h); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := env.checkinSvc.Checkin(env.ctx, u.ID, h.ID, CheckinInput{CountInc: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		targetTimes      int
+		current, longest int
+	}{
+		// one check-in a day no longer meets a target of two
+		{2, 0, 0},
+		{1, 2, 2},
+	} {
+		in := HabitInput{Name: h.Name, TargetType: "daily", TargetTimes: tt.targetTimes, StartDate: h.StartDate}
+		got, err := env.habitSvc.Update(env.ctx, u.ID, h.ID, in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.CurrentStreak != tt.current || got.LongestStreak != tt.longest {
+			t.Fatalf("target %d: streak %d/%d, want %d/%d", tt.targetTimes, got.CurrentStreak, got.LongestStreak, tt.current, tt.longest)
+		}
+	}
+}
+
+func TestProtectStreaksWeeklyHabit(t *testing.T) {
+	today := todayDate()
+	lastDue := today.AddDate(0, 0, -7)
+	missed := today.AddDate(0, 0, -2)
+	tests := []struct {
+		name     string
+		schedule models.WeekdaySet
+		frozen   []time.Time
+		streak   int
+	}{
+		// only today and a week ago are due: nothing to freeze in between
+		{"no due day missed", models.WeekdaySetOf(today.Weekday()), nil, 3},
+		{"due day missed", models.WeekdaySetOf(today.Weekday(), missed.Weekday()), []time.Time{missed}, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			u := env.user(t, "alice")
+			h := env.habit(t, models.Habit{UserID: u.ID, TargetType: "weekly", ScheduleDays: tt.schedule})
+			if err := env.habits.UpdateStreakState(env.ctx, h.ID, 2, 2, &lastDue); err != nil {
+				t.Fatal(err)
+			}
+			for i := 0; i < 2; i++ {
+				if err := env.freezes.Create(env.ctx, &models.StreakFreeze{UserID: u.ID, Source: models.StreakFreezeEarned}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := env.guardSvc.ProtectStreaks(env.ctx); err != nil {
+				t.Fatal(err)
+			}
+			days, err := env.freezes.ListUsedDays(env.ctx, h.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(days) != len(tt.frozen) || (len(days) == 1 && dayKey(days[0]) != dayKey(tt.frozen[0])) {
+				t.Fatalf("frozen days = %v, want %v", days, tt.frozen)
+			}
+			if n, _ := env.freezes.CountAvailable(env.ctx, u.ID); n != int64(2-len(tt.frozen)) {
+				t.Fatalf("available freezes = %d, want %d", n, 2-len(tt.frozen))
+			}
+
+			res, err := env.checkinSvc.Checkin(env.ctx, u.ID, h.ID, CheckinInput{CountInc: 1})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.StreakDays != tt.streak {
+				t.Fatalf("streak = %d, want %d", res.StreakDays, tt.streak)
+			}
+		})
+	}
+}
+
+func TestPurchaseFreezePublishesPointsChanged(t *testing.T) {
+	env := newTestEnv(t)
+	u := env.user(t, "alice")
+	var published []events.PointsChanged
+	events.Subscribe(env.bus, func(ctx context.Context, e events.PointsChanged) error {
+		published = append(published, e)
+		return nil
+	})
+
+	if _, err := env.guardSvc.Purchase(env.ctx, u.ID); !errors.Is(err, ErrInsufficientPoints) {
+		t.Fatalf("purchase without points: err = %v, want %v", err, ErrInsufficientPoints)
+	}
+	if len(published) != 0 {
+		t.Fatalf("failed purchase published %+v", published)
+	}
+
+	if err := env.users.UpdatePoints(env.ctx, u.ID, StreakFreezePrice+10); err != nil {
+		t.Fatal(err)
+	}
+	summary, err := env.guardSvc.Purchase(env.ctx, u.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Available != 1 {
+		t.Fatalf("available = %d, want 1", summary.Available)
+	}
+	if len(published) != 1 {
+		t.Fatalf("published %d points events, want 1", len(published))
+	}
+	e := published[0]
+	if e.UserID != u.ID || e.Delta != -StreakFreezePrice || e.Balance != 10 || e.Reason != "streak_freeze" || e.HabitID != nil || e.At.IsZero() {
+		t.Fatalf("event = %+v, want the spend of %d with balance 10", e, StreakFreezePrice)
+	}
+}
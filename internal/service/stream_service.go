@@ -0,0 +1,329 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"habit-tracker/internal/apperr"
+	"habit-tracker/internal/events"
+)
+
+const (
+	// StreamUserBuffer and StreamBroadcastBuffer bound how many recent events
+	// are kept for Last-Event-ID replay, per user and for broadcasts.
+	StreamUserBuffer      = 100
+	StreamBroadcastBuffer = 20
+	// StreamBufferIdle is how long the buffer of a user without connections
+	// is kept after its last event or disconnect.
+	StreamBufferIdle = 10 * time.Minute
+	// StreamTicketTTL bounds how long a stream ticket can wait to be redeemed.
+	StreamTicketTTL       = 30 * time.Second
+	streamSubscriberQueue = 64
+	streamLeaderboardTop  = 10
+)
+
+var ErrInvalidStreamTicket = apperr.New(apperr.KindUnauthorized, "stream_ticket_invalid", "invalid or expired stream ticket")
+
+// Event names on the stream.
+const (
+	StreamCheckin     = "checkin"
+	StreamPoints      = "points"
+	StreamAchievement = "achievement"
+	StreamLeaderboard = "leaderboard"
+	// StreamReset tells the client that events it missed are no longer
+	// buffered, so it should reload its data instead of relying on replay.
+	StreamReset = "reset"
+)
+
+// StreamEvent is one server-sent event.
+type StreamEvent struct {
+	ID   uint64
+	Type string
+	Data interface{}
+}
+
+// StreamSubscription receives live events until it is closed by
+// Unsubscribe, or by the service when the subscriber falls too far behind.
+type StreamSubscription struct {
+	userID uint64
+	C      chan StreamEvent
+	closed bool
+}
+
+// streamBuffer is a bounded list of recent events; evicted is the id of the
+// newest event that no longer fits. active is when it was last used.
+type streamBuffer struct {
+	events  []StreamEvent
+	evicted uint64
+	active  time.Time
+}
+
+func (b *streamBuffer) add(e StreamEvent, limit int) {
+	b.active = time.Now()
+	b.events = append(b.events, e)
+	if len(b.events) > limit {
+		b.evicted = b.events[0].ID
+		b.events = append(b.events[:0], b.events[1:]...)
+	}
+}
+
+// StreamService fans domain events out to the live SSE connections of each
+// user and keeps a short buffer for reconnects. It is in-memory, so every
+// server instance only streams the events it produced itself.
+type StreamService struct {
+	leaderboard *LeaderboardService
+
+	mu      sync.Mutex
+	seq     uint64
+	startID uint64
+	users   map[uint64]*streamBuffer
+	// purged is the newest event id of any buffer dropped by Purge; older
+	// ids of users without a buffer may have been lost.
+	purged    uint64
+	broadcast streamBuffer
+	subs      map[*StreamSubscription]struct{}
+	tickets   map[string]streamTicket
+
+	leaderboardDirty atomic.Bool
+	lastLeaderboard  []byte
+}
+
+func NewStreamService(leaderboard *LeaderboardService) *StreamService {
+	// 事件 ID 从当前时间起递增，重启后客户端带来的旧 ID 一定小于 startID
+	start := uint64(time.Now().UnixMicro())
+	s := &StreamService{
+		leaderboard: leaderboard,
+		seq:         start,
+		startID:     start + 1,
+		users:       map[uint64]*streamBuffer{},
+		subs:        map[*StreamSubscription]struct{}{},
+		tickets:     map[string]streamTicket{},
+	}
+	s.leaderboardDirty.Store(true)
+	return s
+}
+
+// Subscribe registers a live connection. Events after lastID that are still
+// buffered are returned for replay; reset reports that some are gone.
+// lastID 0 means a fresh connection without replay.
+func (s *StreamService) Subscribe(userID, lastID uint64) (sub *StreamSubscription, replay []StreamEvent, reset bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sub = &StreamSubscription{userID: userID, C: make(chan StreamEvent, streamSubscriberQueue)}
+	s.subs[sub] = struct{}{}
+	if lastID == 0 {
+		return sub, nil, false
+	}
+
+	buf := s.users[userID]
+	if buf == nil {
+		buf = &streamBuffer{evicted: s.purged}
+	}
+	reset = lastID < s.startID-1 || lastID < buf.evicted || lastID < s.broadcast.evicted || lastID > s.seq
+	for _, list := range [][]StreamEvent{buf.events, s.broadcast.events} {
+		for _, e := range list {
+			if e.ID > lastID {
+				replay = append(replay, e)
+			}
+		}
+	}
+	sort.Slice(replay, func(i, j int) bool { return replay[i].ID < replay[j].ID })
+	return sub, replay, reset
+}
+
+func (s *StreamService) Unsubscribe(sub *StreamSubscription) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.drop(sub)
+}
+
+func (s *StreamService) drop(sub *StreamSubscription) {
+	if sub.closed {
+		return
+	}
+	sub.closed = true
+	delete(s.subs, sub)
+	close(sub.C)
+	if buf := s.users[sub.userID]; buf != nil {
+		buf.active = time.Now() // 断线后保留一段时间，供重连补发
+	}
+}
+
+type streamTicket struct {
+	userID  uint64
+	expires time.Time
+}
+
+// IssueTicket returns a single-use ticket that opens one stream for userID
+// within StreamTicketTTL. Browsers' EventSource cannot send the Authorization
+// header, and a ticket in the URL is harmless once used or expired, unlike
+// the access token itself.
+func (s *StreamService) IssueTicket(userID uint64) (string, time.Time) {
+	ticket := randomHex(24)
+	expires := time.Now().Add(StreamTicketTTL)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tickets[ticket] = streamTicket{userID: userID, expires: expires}
+	return ticket, expires
+}
+
+// RedeemTicket consumes ticket and returns the user it was issued to.
+func (s *StreamService) RedeemTicket(ticket string) (uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.tickets[ticket]
+	if !ok {
+		return 0, ErrInvalidStreamTicket
+	}
+	delete(s.tickets, ticket)
+	if time.Now().After(t.expires) {
+		return 0, ErrInvalidStreamTicket
+	}
+	return t.userID, nil
+}
+
+// Purge is a job that drops expired tickets and the buffers of users who have
+// had no connection and no event for StreamBufferIdle. A client reconnecting
+// later with an older Last-Event-ID gets a reset instead of a silent gap.
+func (s *StreamService) Purge(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	for ticket, t := range s.tickets {
+		if now.After(t.expires) {
+			delete(s.tickets, ticket)
+		}
+	}
+	connected := map[uint64]bool{}
+	for sub := range s.subs {
+		connected[sub.userID] = true
+	}
+	for userID, buf := range s.users {
+		if connected[userID] || now.Sub(buf.active) < StreamBufferIdle {
+			continue
+		}
+		if n := len(buf.events); n > 0 && buf.events[n-1].ID > s.purged {
+			s.purged = buf.events[n-1].ID
+		}
+		delete(s.users, userID)
+	}
+	return nil
+}
+
+// send never blocks: a subscriber whose queue is full is disconnected and
+// catches up through Last-Event-ID when it reconnects.
+func (s *StreamService) send(sub *StreamSubscription, e StreamEvent) {
+	select {
+	case sub.C <- e:
+	default:
+		s.drop(sub)
+	}
+}
+
+func (s *StreamService) publish(userID uint64, typ string, data interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seq++
+	e := StreamEvent{ID: s.seq, Type: typ, Data: data}
+	buf := s.users[userID]
+	if buf == nil {
+		buf = &streamBuffer{evicted: s.purged}
+		s.users[userID] = buf
+	}
+	buf.add(e, StreamUserBuffer)
+	for sub := range s.subs {
+		if sub.userID == userID {
+			s.send(sub, e)
+		}
+	}
+}
+
+func (s *StreamService) broadcastEvent(typ string, data interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seq++
+	e := StreamEvent{ID: s.seq, Type: typ, Data: data}
+	s.broadcast.add(e, StreamBroadcastBuffer)
+	for sub := range s.subs {
+		s.send(sub, e)
+	}
+}
+
+// OnCheckinRecorded, OnPointsChanged and OnAchievementUnlocked are bus
+// subscribers; they only touch memory and never block the publisher.
+
+func (s *StreamService) OnCheckinRecorded(ctx context.Context, e events.CheckinRecorded) error {
+	s.publish(e.UserID, StreamCheckin, CheckinCreatedData{
+		CheckinID:     e.CheckinID,
+		HabitID:       e.HabitID,
+		HabitName:     e.HabitName,
+		Date:          e.Date.Format("2006-01-02"),
+		CountInc:      e.CountInc,
+		QuantityInc:   e.QuantityInc,
+		TodayCount:    e.TodayCount,
+		TodayQuantity: e.TodayQuantity,
+	})
+	return nil
+}
+
+func (s *StreamService) OnPointsChanged(ctx context.Context, e events.PointsChanged) error {
+	s.publish(e.UserID, StreamPoints, PointsChangedData{
+		Delta:   e.Delta,
+		Balance: e.Balance,
+		Reason:  e.Reason,
+		HabitID: e.HabitID,
+	})
+	s.leaderboardDirty.Store(true)
+	return nil
+}
+
+func (s *StreamService) OnAchievementUnlocked(ctx context.Context, e events.AchievementUnlocked) error {
+	s.publish(e.UserID, StreamAchievement, AchievementUnlockedData{
+		AchievementID: e.AchievementID,
+		Code:          e.Code,
+		Name:          e.Title,
+		UnlockedAt:    e.UnlockedAt,
+	})
+	return nil
+}
+
+// LeaderboardUpdate is the weekly top list pushed to every connection.
+type LeaderboardUpdate struct {
+	Period  string             `json:"period"`
+	Entries []LeaderboardEntry `json:"entries"`
+}
+
+// PushLeaderboard is a job: after points changed it recomputes the weekly
+// leaderboard and broadcasts it when the top entries differ from the last push.
+// Changes are coalesced, so a burst of check-ins costs one recomputation.
+func (s *StreamService) PushLeaderboard(ctx context.Context) error {
+	if !s.leaderboardDirty.Swap(false) {
+		return nil
+	}
+	entries, err := s.leaderboard.Weekly(ctx)
+	if err != nil {
+		s.leaderboardDirty.Store(true)
+		return err
+	}
+	if len(entries) > streamLeaderboardTop {
+		entries = entries[:streamLeaderboardTop]
+	}
+	data := LeaderboardUpdate{Period: "weekly", Entries: entries}
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	unchanged := string(encoded) == string(s.lastLeaderboard)
+	s.lastLeaderboard = encoded
+	s.mu.Unlock()
+	if !unchanged {
+		s.broadcastEvent(StreamLeaderboard, data)
+	}
+	return nil
+}
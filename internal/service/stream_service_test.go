@@ -0,0 +1,66 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestStreamTicketIsSingleUse(t *testing.T) {
+	s := NewStreamService(nil)
+	ticket, expires := s.IssueTicket(7)
+	if time.Until(expires) > StreamTicketTTL {
+		t.Fatalf("ticket expires at %v, after the TTL", expires)
+	}
+	if userID, err := s.RedeemTicket(ticket); err != nil || userID != 7 {
+		t.Fatalf("redeem = %d, %v; want user 7", userID, err)
+	}
+	if _, err := s.RedeemTicket(ticket); !errors.Is(err, ErrInvalidStreamTicket) {
+		t.Fatalf("second redeem err = %v, want %v", err, ErrInvalidStreamTicket)
+	}
+
+	expired, _ := s.IssueTicket(7)
+	s.tickets[expired] = streamTicket{userID: 7, expires: time.Now().Add(-time.Second)}
+	if _, err := s.RedeemTicket(expired); !errors.Is(err, ErrInvalidStreamTicket) {
+		t.Fatalf("expired redeem err = %v, want %v", err, ErrInvalidStreamTicket)
+	}
+}
+
+func TestStreamPurgeDropsIdleBuffers(t *testing.T) {
+	s := NewStreamService(nil)
+	s.publish(1, StreamPoints, nil)
+	s.publish(2, StreamPoints, nil)
+	missed := s.seq
+	connected, _, _ := s.Subscribe(2, 0)
+	defer s.Unsubscribe(connected)
+	stale, _ := s.IssueTicket(1)
+	s.tickets[stale] = streamTicket{userID: 1, expires: time.Now().Add(-time.Second)}
+
+	// only the buffers idle for long enough go, and never one with a connection
+	s.users[1].active = time.Now().Add(-StreamBufferIdle)
+	s.users[2].active = time.Now().Add(-StreamBufferIdle)
+	if err := s.Purge(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if s.users[1] != nil || s.users[2] == nil {
+		t.Fatalf("buffers after purge: user 1 %v, user 2 %v; want only user 1 dropped", s.users[1] != nil, s.users[2] != nil)
+	}
+	if len(s.tickets) != 0 {
+		t.Fatalf("%d tickets left, want expired ones purged", len(s.tickets))
+	}
+
+	// user 1 reconnecting from before its last event cannot be replayed
+	sub, replay, reset := s.Subscribe(1, missed-2)
+	defer s.Unsubscribe(sub)
+	if !reset || len(replay) != 0 {
+		t.Fatalf("reconnect after purge: replay %v reset %v, want a reset", replay, reset)
+	}
+	// a new event does not hide the gap either
+	s.publish(1, StreamPoints, nil)
+	sub2, _, reset := s.Subscribe(1, missed-2)
+	defer s.Unsubscribe(sub2)
+	if !reset {
+		t.Fatal("reconnect after purge and a new event: want a reset")
+	}
+}
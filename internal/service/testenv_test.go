@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"habit-tracker/internal/apperr"
+	"habit-tracker/internal/events"
+	"habit-tracker/internal/models"
+	"habit-tracker/internal/repository/memrepo"
+)
+
+// testEnv wires the services under test to in-memory repositories and
+// subscribes them to the bus the way app.New does.
+type testEnv struct {
+	ctx context.Context
+	bus *events.Bus
+
+	users        *memrepo.UserRepository
+	habits       *memrepo.HabitRepository
+	checkins     *memrepo.CheckinRepository
+	points       *memrepo.PointsRepository
+	achievements *memrepo.AchievementRepository
+	userAch      *memrepo.UserAchievementRepository
+	freezes      *memrepo.StreakFreezeRepository
+	vacations    *memrepo.VacationRepository
+	reminders    *memrepo.ReminderRepository
+
+	pointsSvc      *PointsService
+	achSvc         *AchievementService
+	guardSvc       *StreakGuardService
+	checkinSvc     *CheckinService
+	habitSvc       *HabitService
+	leaderboardSvc *LeaderboardService
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+	db := memrepo.New()
+	env := &testEnv{
+		ctx:          context.Background(),
+		bus:          events.NewBus(),
+		users:        memrepo.NewUserRepository(db),
+		habits:       memrepo.NewHabitRepository(db),
+		checkins:     memrepo.NewCheckinRepository(db),
+		points:       memrepo.NewPointsRepository(db),
+		achievements: memrepo.NewAchievementRepository(db),
+		userAch:      memrepo.NewUserAchievementRepository(db),
+		freezes:      memrepo.NewStreakFreezeRepository(db),
+		vacations:    memrepo.NewVacationRepository(db),
+		reminders:    memrepo.NewReminderRepository(db),
+	}
+	env.pointsSvc = NewPointsService(env.users, env.points, env.bus)
+	env.achSvc = NewAchievementService(env.achievements, env.userAch, env.users, env.bus)
+	env.guardSvc = NewStreakGuardService(env.habits, env.users, env.checkins, env.freezes, env.vacations, env.pointsSvc)
+	env.checkinSvc = NewCheckinService(env.habits, env.users, env.checkins, env.guardSvc, env.bus)
+	env.habitSvc = NewHabitService(env.habits, memrepo.NewCategoryRepository(db), memrepo.NewTagRepository(db), nil, env.guardSvc)
+	env.leaderboardSvc = NewLeaderboardService(env.users, env.points)
+
+	events.Subscribe(env.bus, env.pointsSvc.OnTargetReached)
+	events.Subscribe(env.bus, env.achSvc.OnTargetReached)
+	events.Subscribe(env.bus, env.achSvc.OnCheckinRecorded)
+	return env
+}
+
+func (env *testEnv) user(t *testing.T, username string) *models.User {
+	t.Helper()
+	u := &models.User{Username: username, Nickname: username, Timezone: "UTC"}
+	if err := env.users.Create(env.ctx, u); err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	return u
+}
+
+func (env *testEnv) habit(t *testing.T, h models.Habit) *models.Habit {
+	t.Helper()
+	if h.Name == "" {
+		h.Name = "habit"
+	}
+	if h.StartDate.IsZero() {
+		h.StartDate = todayDate().AddDate(0, 0, -30)
+	}
+	if err := env.habits.Create(env.ctx, &h); err != nil {
+		t.Fatalf("create habit: %v", err)
+	}
+	return &h
+}
+
+func (env *testEnv) achievement(t *testing.T, code, conditionType string, value int) *models.Achievement {
+	t.Helper()
+	a := &models.Achievement{Code: code, Name: code, ConditionType: conditionType, ConditionValue: value}
+	if err := env.achievements.Create(env.ctx, a); err != nil {
+		t.Fatalf("create achievement: %v", err)
+	}
+	return a
+}
+
+func (env *testEnv) reload(t *testing.T, u *models.User) *models.User {
+	t.Helper()
+	got, err := env.users.GetByID(env.ctx, u.ID)
+	if err != nil {
+		t.Fatalf("reload user: %v", err)
+	}
+	return got
+}
+
+// todayDate is the current day of the test users, who are all on UTC.
+func todayDate() time.Time {
+	return userDay(&models.User{Timezone: "UTC"}, time.Now())
+}
+
+// date is midnight UTC of the given day, the shape of a date column.
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func checkinOn(day time.Time, count int) models.HabitCheckin {
+	return models.HabitCheckin{CheckinDate: day, Count: count}
+}
+
+func isInvalid(err error) bool {
+	return err != nil && apperr.From(err).Kind == apperr.KindInvalid
+}
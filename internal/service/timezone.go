@@ -0,0 +1,40 @@
+package service
+
+import (
+	"time"
+
+	"habit-tracker/internal/apperr"
+	"habit-tracker/internal/models"
+)
+
+// ErrInvalidTimezone rejects names time.LoadLocation does not know.
+var ErrInvalidTimezone = apperr.New(apperr.KindInvalid, "invalid_timezone", "invalid timezone")
+
+// loadTimezone resolves a stored IANA time zone name; empty means UTC.
+func loadTimezone(name string) (string, *time.Location, error) {
+	if name == "" {
+		return "UTC", time.UTC, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil || name == "Local" {
+		return "", nil, ErrInvalidTimezone
+	}
+	return name, loc, nil
+}
+
+// userLocation is the user's time zone; invalid stored names fall back to UTC.
+func userLocation(user *models.User) *time.Location {
+	_, loc, err := loadTimezone(user.Timezone)
+	if err != nil {
+		return time.UTC // 历史数据中的无效时区按 UTC 处理
+	}
+	return loc
+}
+
+// userDay is the calendar day of t in the user's time zone, as midnight UTC
+// like a date column. Check-ins are stored under userDay(user, time.Now()),
+// and streaks, freezes and reminders count the user's days the same way.
+func userDay(user *models.User, t time.Time) time.Time {
+	y, m, d := t.In(userLocation(user)).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
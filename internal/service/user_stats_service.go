@@ -2,12 +2,10 @@ package service
 
 import (
 	"context"
-	"sort"
 	"time"
 
 	"habit-tracker/internal/models"
 	"habit-tracker/internal/repository"
-	"habit-tracker/internal/utils"
 )
 
 type UserStats struct {
@@ -20,36 +18,38 @@ type UserStats struct {
 }
 
 type UserStatsService struct {
-	users    *repository.UserRepository
-	habits   *repository.HabitRepository
-	checkins *repository.CheckinRepository
+	users    repository.UserStore
+	habits   repository.HabitStore
+	checkins repository.CheckinStore
 	points   *PointsService
 }
 
-func NewUserStatsService(users *repository.UserRepository, habits *repository.HabitRepository, checkins *repository.CheckinRepository, points *PointsService) *UserStatsService {
+func NewUserStatsService(users repository.UserStore, habits repository.HabitStore, checkins repository.CheckinStore, points *PointsService) *UserStatsService {
 	return &UserStatsService{users: users, habits: habits, checkins: checkins, points: points}
 }
 
+// GetStats counts the week and month in the user's time zone, the days
+// their check-ins are stored under.
 func (s *UserStatsService) GetStats(ctx context.Context, userID uint64) (*UserStats, error) {
-	today := utils.TruncateDate(time.Now())
-
-	weekStart := startOfWeek(today)
-	weekEnd := weekStart.AddDate(0, 0, 7)
-
-	monthStart := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, today.Location())
-	monthEnd := monthStart.AddDate(0, 1, 0)
+	user, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	weekStart, weekEnd := currentWeek(userLocation(user))
+	monthStart, monthEnd := currentMonth(userLocation(user))
 
 	totalCheckins, err := s.users.GetTotalCheckins(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
 
-	weeklyCheckins, err := s.checkins.SumCountByUserAndRange(ctx, userID, weekStart, weekEnd)
+	// checkin_date 是日期列，按同一日历日比较；积分按时间点比较
+	weeklyCheckins, err := s.checkins.SumCountByUserAndRange(ctx, userID, calendarDate(weekStart), calendarDate(weekEnd))
 	if err != nil {
 		return nil, err
 	}
 
-	monthlyCheckins, err := s.checkins.SumCountByUserAndRange(ctx, userID, monthStart, monthEnd)
+	monthlyCheckins, err := s.checkins.SumCountByUserAndRange(ctx, userID, calendarDate(monthStart), calendarDate(monthEnd))
 	if err != nil {
 		return nil, err
 	}
@@ -79,6 +79,7 @@ func (s *UserStatsService) GetStats(ctx context.Context, userID uint64) (*UserSt
 	}, nil
 }
 
+// longestStreak reads the persisted per-habit streak state instead of replaying history.
 func (s *UserStatsService) longestStreak(ctx context.Context, userID uint64) (int, error) {
 	habits, err := s.habits.ListByUser(ctx, userID)
 	if err != nil {
@@ -87,53 +88,51 @@ func (s *UserStatsService) longestStreak(ctx context.Context, userID uint64) (in
 
 	maxStreak := 0
 	for _, h := range habits {
-		records, err := s.checkins.ListByHabitDesc(ctx, h.ID)
-		if err != nil {
-			return 0, err
-		}
-		streak := longestStreakForHabit(records, h.TargetTimes)
-		if streak > maxStreak {
-			maxStreak = streak
+		if h.LongestStreak > maxStreak {
+			maxStreak = h.LongestStreak
 		}
 	}
 	return maxStreak, nil
 }
 
-func longestStreakForHabit(records []models.HabitCheckin, target int) int {
-	if target <= 0 {
+// longestStreakForHabit is the longest run of completed days. Days the shield
+// covers neither extend nor break a run.
+func longestStreakForHabit(records []models.HabitCheckin, goal habitGoal, shield streakShield) int {
+	if !goal.valid() {
 		return 0
 	}
 	if len(records) == 0 {
 		return 0
 	}
 
-	sort.Slice(records, func(i, j int) bool {
-		return records[i].CheckinDate.Before(records[j].CheckinDate)
-	})
-
-	best, current := 0, 0
-	var prevDate time.Time
-
+	met := metDays(records, goal)
+	var first, last time.Time
 	for _, rec := range records {
-		if rec.Count < target {
-			current = 0
+		if _, ok := met[dayKey(rec.CheckinDate)]; !ok {
 			continue
 		}
+		if first.IsZero() || rec.CheckinDate.Before(first) {
+			first = rec.CheckinDate
+		}
+		if rec.CheckinDate.After(last) {
+			last = rec.CheckinDate
+		}
+	}
+	if first.IsZero() {
+		return 0
+	}
 
-		if current == 0 {
-			current = 1
-		} else {
-			delta := daysBetween(prevDate, rec.CheckinDate)
-			if delta == 1 {
-				current++
-			} else if delta > 1 {
-				current = 1
+	best, current := 0, 0
+	for day := first; dayKey(day) <= dayKey(last); day = day.AddDate(0, 0, 1) {
+		if _, ok := met[dayKey(day)]; ok {
+			current++
+			if current > best {
+				best = current
 			}
+			continue
 		}
-
-		prevDate = rec.CheckinDate
-		if current > best {
-			best = current
+		if !shield.covers(day) {
+			current = 0
 		}
 	}
 
@@ -0,0 +1,436 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"habit-tracker/internal/apperr"
+	"habit-tracker/internal/events"
+	"habit-tracker/internal/models"
+	"habit-tracker/internal/repository"
+)
+
+const (
+	maxWebhookEndpoints = 5
+	// WebhookMaxAttempts is how many times a delivery is tried before it is
+	// marked failed; with the backoff below the retries span about 14 hours.
+	WebhookMaxAttempts = 12
+	webhookRetryBase   = 30 * time.Second
+	webhookRetryMax    = 6 * time.Hour
+	webhookSendTimeout = 10 * time.Second
+	webhookBatchSize   = 20
+	// webhookLease must outlast webhookSendTimeout so a claimed delivery is
+	// never picked up by another worker while it is still being sent.
+	webhookLease = time.Minute
+	// WebhookLogRetention is how long finished deliveries and their log are kept.
+	WebhookLogRetention = 30 * 24 * time.Hour
+)
+
+var webhookEvents = []string{
+	models.WebhookCheckinCreated,
+	models.WebhookTargetReached,
+	models.WebhookAchievementUnlocked,
+	models.WebhookPointsChanged,
+}
+
+var (
+	ErrWebhookEndpointNotFound = apperr.New(apperr.KindNotFound, "webhook_not_found", "webhook endpoint not found")
+	ErrWebhookEndpointLimit    = apperr.New(apperr.KindConflict, "webhook_limit", fmt.Sprintf("at most %d webhook endpoints per user", maxWebhookEndpoints))
+	ErrInvalidWebhookEvent     = apperr.New(apperr.KindInvalid, "invalid_webhook_event", "unknown webhook event, expected one of "+strings.Join(webhookEvents, ", "))
+)
+
+// WebhookService manages user webhook endpoints and delivers events to them.
+//
+// Events only write outbox rows, which costs a DB insert inside the request;
+// the HTTP calls happen in Deliver, a background job, so a slow or broken
+// receiver never delays the request that produced the event.
+type WebhookService struct {
+	webhooks repository.WebhookStore
+	client   *http.Client
+}
+
+func NewWebhookService(webhooks repository.WebhookStore) *WebhookService {
+	return &WebhookService{webhooks: webhooks, client: newWebhookClient(webhookSendTimeout)}
+}
+
+// WebhookEndpointView is an endpoint as shown to its owner; Secret is only
+// set in the response that creates it.
+type WebhookEndpointView struct {
+	models.WebhookEndpoint
+	Secret string `json:"secret,omitempty"`
+}
+
+// WebhookDeliveryView is a delivery with its attempt log.
+type WebhookDeliveryView struct {
+	models.WebhookDelivery
+	Log []models.WebhookAttempt `json:"log"`
+}
+
+// webhookEnvelope is the JSON body POSTed to endpoints.
+type webhookEnvelope struct {
+	ID        string      `json:"id"`
+	Type      string      `json:"type"`
+	CreatedAt time.Time   `json:"created_at"`
+	UserID    uint64      `json:"user_id"`
+	Data      interface{} `json:"data"`
+}
+
+// Event payloads, the "data" of the envelope.
+type (
+	CheckinCreatedData struct {
+		CheckinID     uint64  `json:"checkin_id"`
+		HabitID       uint64  `json:"habit_id"`
+		HabitName     string  `json:"habit_name"`
+		Date          string  `json:"date"`
+		CountInc      int     `json:"count_inc"`
+		QuantityInc   float64 `json:"quantity_inc"`
+		TodayCount    int     `json:"today_count"`
+		TodayQuantity float64 `json:"today_quantity"`
+	}
+	TargetReachedData struct {
+		HabitID    uint64 `json:"habit_id"`
+		HabitName  string `json:"habit_name"`
+		Date       string `json:"date"`
+		StreakDays int    `json:"streak_days"`
+	}
+	AchievementUnlockedData struct {
+		AchievementID uint64    `json:"achievement_id"`
+		Code          string    `json:"code"`
+		Name          string    `json:"name"`
+		UnlockedAt    time.Time `json:"unlocked_at"`
+	}
+	PointsChangedData struct {
+		Delta   int64   `json:"delta"`
+		Balance int64   `json:"balance"`
+		Reason  string  `json:"reason"`
+		HabitID *uint64 `json:"habit_id,omitempty"`
+	}
+)
+
+func (s *WebhookService) CreateEndpoint(ctx context.Context, userID uint64, url string, events []string) (*WebhookEndpointView, error) {
+	if err := validateWebhookURL(url); err != nil {
+		return nil, err
+	}
+	for _, ev := range events {
+		if !containsString(webhookEvents, ev) {
+			return nil, ErrInvalidWebhookEvent
+		}
+	}
+	existing, err := s.webhooks.ListEndpoints(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) >= maxWebhookEndpoints {
+		return nil, ErrWebhookEndpointLimit
+	}
+	ep := &models.WebhookEndpoint{
+		UserID:    userID,
+		URL:       url,
+		Secret:    randomHex(24),
+		Events:    strings.Join(events, ","),
+		CreatedAt: time.Now(),
+	}
+	if err := s.webhooks.CreateEndpoint(ctx, ep); err != nil {
+		return nil, err
+	}
+	return &WebhookEndpointView{WebhookEndpoint: *ep, Secret: ep.Secret}, nil
+}
+
+func (s *WebhookService) ListEndpoints(ctx context.Context, userID uint64) ([]models.WebhookEndpoint, error) {
+	return s.webhooks.ListEndpoints(ctx, userID)
+}
+
+func (s *WebhookService) DeleteEndpoint(ctx context.Context, userID, id uint64) error {
+	if _, err := s.getOwned(ctx, userID, id); err != nil {
+		return err
+	}
+	return s.webhooks.DeleteEndpoint(ctx, id)
+}
+
+// ListDeliveries returns the endpoint's latest deliveries with their attempt log.
+func (s *WebhookService) ListDeliveries(ctx context.Context, userID, id uint64, limit int) ([]WebhookDeliveryView, error) {
+	if _, err := s.getOwned(ctx, userID, id); err != nil {
+		return nil, err
+	}
+	if limit <= 0 || limit > 100 {
+		limit = 100
+	}
+	deliveries, err := s.webhooks.ListDeliveries(ctx, id, limit)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]uint64, 0, len(deliveries))
+	for _, d := range deliveries {
+		ids = append(ids, d.ID)
+	}
+	attempts, err := s.webhooks.ListAttempts(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	byDelivery := make(map[uint64][]models.WebhookAttempt, len(deliveries))
+	for _, a := range attempts {
+		byDelivery[a.DeliveryID] = append(byDelivery[a.DeliveryID], a)
+	}
+	out := make([]WebhookDeliveryView, 0, len(deliveries))
+	for _, d := range deliveries {
+		out = append(out, WebhookDeliveryView{WebhookDelivery: d, Log: append([]models.WebhookAttempt{}, byDelivery[d.ID]...)})
+	}
+	return out, nil
+}
+
+func (s *WebhookService) getOwned(ctx context.Context, userID, id uint64) (*models.WebhookEndpoint, error) {
+	ep, err := s.webhooks.GetEndpoint(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrWebhookEndpointNotFound
+		}
+		return nil, err
+	}
+	if ep.UserID != userID {
+		return nil, ErrWebhookEndpointNotFound
+	}
+	return ep, nil
+}
+
+// OnCheckinRecorded, OnTargetReached, OnPointsChanged and OnAchievementUnlocked
+// are sync bus subscribers: the outbox rows are written within the request,
+// so an event is queued durably once the request succeeds. Failing to queue
+// is logged and never fails the request.
+
+func (s *WebhookService) OnCheckinRecorded(ctx context.Context, e events.CheckinRecorded) error {
+	s.enqueue(ctx, e.UserID, models.WebhookCheckinCreated, CheckinCreatedData{
+		CheckinID:     e.CheckinID,
+		HabitID:       e.HabitID,
+		HabitName:     e.HabitName,
+		Date:          e.Date.Format("2006-01-02"),
+		CountInc:      e.CountInc,
+		QuantityInc:   e.QuantityInc,
+		TodayCount:    e.TodayCount,
+		TodayQuantity: e.TodayQuantity,
+	})
+	return nil
+}
+
+func (s *WebhookService) OnTargetReached(ctx context.Context, e events.TargetReached) error {
+	s.enqueue(ctx, e.UserID, models.WebhookTargetReached, TargetReachedData{
+		HabitID:    e.HabitID,
+		HabitName:  e.HabitName,
+		Date:       e.Date.Format("2006-01-02"),
+		StreakDays: e.StreakDays,
+	})
+	return nil
+}
+
+func (s *WebhookService) OnPointsChanged(ctx context.Context, e events.PointsChanged) error {
+	s.enqueue(ctx, e.UserID, models.WebhookPointsChanged, PointsChangedData{
+		Delta:   e.Delta,
+		Balance: e.Balance,
+		Reason:  e.Reason,
+		HabitID: e.HabitID,
+	})
+	return nil
+}
+
+func (s *WebhookService) OnAchievementUnlocked(ctx context.Context, e events.AchievementUnlocked) error {
+	s.enqueue(ctx, e.UserID, models.WebhookAchievementUnlocked, AchievementUnlockedData{
+		AchievementID: e.AchievementID,
+		Code:          e.Code,
+		Name:          e.Title,
+		UnlockedAt:    e.UnlockedAt,
+	})
+	return nil
+}
+
+func (s *WebhookService) enqueue(ctx context.Context, userID uint64, event string, data interface{}) {
+	if err := s.emit(ctx, userID, event, data); err != nil {
+		log.Printf("webhook 事件 %s 入队失败 (用户 %d): %v", event, userID, err)
+	}
+}
+
+func (s *WebhookService) emit(ctx context.Context, userID uint64, event string, data interface{}) error {
+	endpoints, err := s.webhooks.ListEndpoints(ctx, userID)
+	if err != nil {
+		return err
+	}
+	var targets []models.WebhookEndpoint
+	for _, ep := range endpoints {
+		if ep.Events == "" || containsString(strings.Split(ep.Events, ","), event) {
+			targets = append(targets, ep)
+		}
+	}
+	if len(targets) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	env := webhookEnvelope{ID: "evt_" + randomHex(12), Type: event, CreatedAt: now.UTC(), UserID: userID, Data: data}
+	payload, err := json.Marshal(env)
+	if err != nil {
+		return err
+	}
+	deliveries := make([]models.WebhookDelivery, 0, len(targets))
+	for _, ep := range targets {
+		deliveries = append(deliveries, models.WebhookDelivery{
+			EndpointID:    ep.ID,
+			EventID:       env.ID,
+			Event:         event,
+			Payload:       string(payload),
+			Status:        models.WebhookDeliveryPending,
+			NextAttemptAt: now,
+			CreatedAt:     now,
+		})
+	}
+	return s.webhooks.Enqueue(ctx, deliveries)
+}
+
+// Deliver is the outbox worker job: it sends due deliveries in parallel and
+// reschedules failures with exponential backoff.
+func (s *WebhookService) Deliver(ctx context.Context) error {
+	for {
+		batch, err := s.webhooks.ClaimDue(ctx, time.Now(), webhookLease, webhookBatchSize)
+		if err != nil {
+			return err
+		}
+		if len(batch) == 0 {
+			return nil
+		}
+		var wg sync.WaitGroup
+		for i := range batch {
+			wg.Add(1)
+			go func(d *models.WebhookDelivery) {
+				defer wg.Done()
+				if err := s.deliver(ctx, d); err != nil {
+					log.Printf("webhook 投递 %d 处理失败: %v", d.ID, err)
+				}
+			}(&batch[i])
+		}
+		wg.Wait()
+		if len(batch) < webhookBatchSize {
+			return nil
+		}
+	}
+}
+
+func (s *WebhookService) deliver(ctx context.Context, d *models.WebhookDelivery) error {
+	ep, err := s.webhooks.GetEndpoint(ctx, d.EndpointID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil // 端点已删除，投递记录随之删除
+		}
+		return err
+	}
+
+	started := time.Now()
+	status, sendErr := s.send(ctx, ep, d)
+	attempt := &models.WebhookAttempt{
+		DeliveryID: d.ID,
+		StatusCode: status,
+		DurationMs: time.Since(started).Milliseconds(),
+		CreatedAt:  started,
+	}
+	d.Attempts++
+	switch {
+	case sendErr == nil:
+		now := time.Now()
+		d.Status = models.WebhookDeliveryDelivered
+		d.DeliveredAt = &now
+	case d.Attempts >= WebhookMaxAttempts:
+		d.Status = models.WebhookDeliveryFailed
+	default:
+		d.NextAttemptAt = time.Now().Add(webhookBackoff(d.Attempts))
+	}
+	if sendErr != nil {
+		attempt.Error = truncate(sendErr.Error(), 512)
+	}
+	return s.webhooks.Finish(ctx, d, attempt)
+}
+
+// send POSTs the payload and reports the response status (0 without a response).
+func (s *WebhookService) send(ctx context.Context, ep *models.WebhookEndpoint, d *models.WebhookDelivery) (int, error) {
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ep.URL, strings.NewReader(d.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "habit-tracker-webhooks/1")
+	req.Header.Set("X-Webhook-Id", d.EventID)
+	req.Header.Set("X-Webhook-Event", d.Event)
+	req.Header.Set("X-Webhook-Timestamp", ts)
+	req.Header.Set("X-Webhook-Signature", "sha256="+SignWebhook(ep.Secret, ts, []byte(d.Payload)))
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("receiver returned %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// PurgeDeliveries is the retention job for the delivery log.
+func (s *WebhookService) PurgeDeliveries(ctx context.Context) error {
+	n, err := s.webhooks.PurgeFinishedBefore(ctx, time.Now().Add(-WebhookLogRetention))
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		log.Printf("清理过期 webhook 投递记录 %d 条", n)
+	}
+	return nil
+}
+
+// SignWebhook computes the hex HMAC-SHA256 receivers verify: the key is the
+// endpoint secret and the message is "<timestamp>.<raw body>".
+func SignWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// webhookBackoff is the wait after the given number of failed attempts:
+// 30s, 1m, 2m, ... capped at webhookRetryMax.
+func webhookBackoff(attempts int) time.Duration {
+	d := webhookRetryBase
+	for i := 1; i < attempts && d < webhookRetryMax; i++ {
+		d *= 2
+	}
+	if d > webhookRetryMax {
+		d = webhookRetryMax
+	}
+	return d
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
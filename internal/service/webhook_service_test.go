@@ -0,0 +1,32 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"habit-tracker/internal/models"
+	"habit-tracker/internal/repository/memrepo"
+)
+
+func TestWebhookSendRefusesInternalAddresses(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+	}))
+	defer srv.Close()
+
+	svc := NewWebhookService(memrepo.NewWebhookRepository(memrepo.New()))
+	ep := &models.WebhookEndpoint{URL: srv.URL + "/hook", Secret: "s"}
+	d := &models.WebhookDelivery{EventID: "e1", Event: "checkin.created", Payload: "{}"}
+	status, err := svc.send(context.Background(), ep, d)
+	if status != 0 || !errors.Is(err, errWebhookAddress) {
+		t.Fatalf("send = %d, %v; want 0, %v", status, err, errWebhookAddress)
+	}
+	if hits.Load() != 0 {
+		t.Fatalf("internal receiver was called %d times", hits.Load())
+	}
+}
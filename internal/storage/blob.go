@@ -0,0 +1,19 @@
+// 文件存储（打卡照片等）
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+var ErrBlobNotFound = errors.New("blob not found")
+
+// BlobStore persists binary objects such as check-in photos under opaque keys.
+type BlobStore interface {
+	Put(ctx context.Context, key string, r io.Reader) error
+	// Open returns ErrBlobNotFound when the key does not exist.
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete is a no-op for missing keys.
+	Delete(ctx context.Context, key string) error
+}
@@ -0,0 +1,68 @@
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+type loginRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// Register creates an account. nickname and timezone may be empty.
+func (c *Client) Register(ctx context.Context, username, password, nickname, timezone string) (*Registration, error) {
+	var res Registration
+	err := c.do(ctx, request{
+		method: http.MethodPost,
+		path:   "/auth/register",
+		body: map[string]string{
+			"username": username,
+			"password": password,
+			"nickname": nickname,
+			"timezone": timezone,
+		},
+		public: true,
+	}, &res)
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// Login authenticates the client. The credentials are kept in memory so the
+// client can log in again once the token expires or is rejected.
+func (c *Client) Login(ctx context.Context, username, password string) (*Session, error) {
+	var res Session
+	err := c.do(ctx, request{
+		method: http.MethodPost,
+		path:   "/auth/login",
+		body:   loginRequest{Username: username, Password: password},
+		public: true,
+	}, &res)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	c.username, c.password = username, password
+	c.setToken(res.Token)
+	c.mu.Unlock()
+	return &res, nil
+}
+
+// Logout forgets the token and the credentials.
+func (c *Client) Logout() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.username, c.password = "", ""
+	c.setToken("")
+}
+
+// Profile returns the authenticated user.
+func (c *Client) Profile(ctx context.Context) (*User, error) {
+	var res User
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/user/profile"}, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
@@ -0,0 +1,46 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// Checkin records progress on a habit for today.
+func (c *Client) Checkin(ctx context.Context, in CheckinInput) (*CheckinResult, error) {
+	var res CheckinResult
+	if err := c.do(ctx, request{method: http.MethodPost, path: "/checkins", body: in}, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// ListCheckins returns the check-ins of a habit, by default of the last 30 days.
+func (c *Client) ListCheckins(ctx context.Context, habitID uint64, r DateRange) ([]Checkin, error) {
+	var res []Checkin
+	err := c.do(ctx, request{method: http.MethodGet, path: habitPath(habitID) + "/checkins", query: r.query()}, &res)
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// LogRelapse records a relapse of a quit habit, which resets its streak.
+func (c *Client) LogRelapse(ctx context.Context, habitID uint64) (*RelapseResult, error) {
+	var res RelapseResult
+	if err := c.do(ctx, request{method: http.MethodPost, path: habitPath(habitID) + "/relapses"}, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (r DateRange) query() url.Values {
+	q := url.Values{}
+	if !r.Start.IsZero() {
+		q.Set("start_date", formatDate(r.Start))
+	}
+	if !r.End.IsZero() {
+		q.Set("end_date", formatDate(r.End))
+	}
+	return q
+}
@@ -0,0 +1,338 @@
+// Package client is a Go SDK for the habit-tracker REST API.
+//
+// It unwraps the {code, message, data} envelope into typed results and
+// *APIError values, authenticates requests, logs in again when the access
+// token expires, and retries requests that failed with 429 or, for
+// idempotent methods, with a 5xx status or a network error. Every method
+// honours context cancellation, including while waiting to retry.
+//
+//	c := client.New("http://localhost:8080")
+//	if _, err := c.Login(ctx, "alice", "secret"); err != nil { ... }
+//	habits, err := c.ListHabits(ctx, nil)
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	apiPrefix = "/api/v1"
+
+	DefaultMaxRetries = 3
+	DefaultRetryDelay = 200 * time.Millisecond
+	maxRetryDelay     = 10 * time.Second
+	// tokens are renewed this long before they expire
+	refreshBefore = time.Minute
+)
+
+// Client calls the API. It is safe for concurrent use.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	maxRetries int
+	retryDelay time.Duration
+
+	mu       sync.Mutex
+	token    string
+	expires  time.Time
+	username string
+	password string
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithHTTPClient sets the HTTP client used for requests.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) { c.httpClient = hc }
+}
+
+// WithToken authenticates with an existing access token. Without Login the
+// client has no credentials to renew it with.
+func WithToken(token string) Option {
+	return func(c *Client) { c.setToken(token) }
+}
+
+// WithRetries sets how often a failed request is retried and the delay
+// before the first retry, which doubles on every further attempt.
+// maxRetries 0 disables retries.
+func WithRetries(maxRetries int, delay time.Duration) Option {
+	return func(c *Client) {
+		c.maxRetries = maxRetries
+		c.retryDelay = delay
+	}
+}
+
+// New returns a client for the server at baseURL, e.g. "http://localhost:8080".
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: http.DefaultClient,
+		maxRetries: DefaultMaxRetries,
+		retryDelay: DefaultRetryDelay,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Token returns the current access token.
+func (c *Client) Token() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.token
+}
+
+// APIError is a request the server answered with an error.
+type APIError struct {
+	StatusCode int
+	// Code is the machine-readable error code, e.g. "habit_not_found";
+	// empty when the response was not an API envelope.
+	Code    string
+	Message string
+}
+
+func (e *APIError) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("habit-tracker: HTTP %d: %s", e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("habit-tracker: HTTP %d %s: %s", e.StatusCode, e.Code, e.Message)
+}
+
+// IsNotFound reports whether err is an APIError with status 404.
+func IsNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+type envelope struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Error   string          `json:"error"`
+	Data    json.RawMessage `json:"data"`
+}
+
+type request struct {
+	method string
+	path   string
+	query  url.Values
+	body   interface{}
+	// public requests are sent without a token
+	public bool
+}
+
+// do sends req, retrying and re-authenticating as needed, and decodes the
+// data of the response into out when out is not nil.
+func (c *Client) do(ctx context.Context, req request, out interface{}) error {
+	var payload []byte
+	if req.body != nil {
+		var err error
+		if payload, err = json.Marshal(req.body); err != nil {
+			return err
+		}
+	}
+	reauthenticated := false
+	for attempt := 0; ; attempt++ {
+		token := ""
+		if !req.public {
+			var err error
+			if token, err = c.validToken(ctx); err != nil {
+				return err
+			}
+		}
+		resp, err := c.send(ctx, req, payload, token)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if attempt < c.maxRetries && idempotent(req.method) {
+				if err := c.wait(ctx, attempt, nil); err != nil {
+					return err
+				}
+				continue
+			}
+			return err
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case resp.StatusCode == http.StatusUnauthorized && !req.public && !reauthenticated && c.hasCredentials():
+			reauthenticated = true
+			if err := c.reauthenticate(ctx, token); err != nil {
+				return err
+			}
+			attempt--
+			continue
+		case resp.StatusCode == http.StatusTooManyRequests,
+			resp.StatusCode >= 500 && idempotent(req.method):
+			if attempt < c.maxRetries {
+				if err := c.wait(ctx, attempt, resp); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		return decode(resp.StatusCode, body, out)
+	}
+}
+
+func (c *Client) send(ctx context.Context, req request, payload []byte, token string) (*http.Response, error) {
+	u := c.baseURL + apiPrefix + req.path
+	if len(req.query) > 0 {
+		u += "?" + req.query.Encode()
+	}
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, req.method, u, body)
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Accept", "application/json")
+	if payload != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+token)
+	}
+	return c.httpClient.Do(httpReq)
+}
+
+func decode(status int, body []byte, out interface{}) error {
+	var env envelope
+	if err := json.Unmarshal(body, &env); err != nil {
+		if status >= 400 {
+			return &APIError{StatusCode: status, Message: strings.TrimSpace(string(body))}
+		}
+		return fmt.Errorf("habit-tracker: decode response: %w", err)
+	}
+	if status >= 400 || env.Code != 0 {
+		return &APIError{StatusCode: status, Code: env.Error, Message: env.Message}
+	}
+	if out == nil || len(env.Data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(env.Data, out)
+}
+
+// idempotent methods are retried after server errors; other requests may
+// already have taken effect and are only retried after 429.
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// wait sleeps before retry attempt+1: Retry-After when the server sent it,
+// otherwise an exponential backoff with jitter.
+func (c *Client) wait(ctx context.Context, attempt int, resp *http.Response) error {
+	delay := c.retryDelay << attempt
+	if delay <= 0 || delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	delay = delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+	if resp != nil {
+		if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && secs >= 0 {
+			delay = time.Duration(secs) * time.Second
+		}
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func (c *Client) setToken(token string) {
+	c.token = token
+	c.expires = tokenExpiry(token)
+}
+
+func (c *Client) hasCredentials() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.username != ""
+}
+
+// validToken returns the token to send, logging in again first when it is
+// about to expire and the credentials are known.
+func (c *Client) validToken(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.username != "" && (c.token == "" || (!c.expires.IsZero() && time.Until(c.expires) < refreshBefore)) {
+		if err := c.loginLocked(ctx); err != nil {
+			return "", err
+		}
+	}
+	return c.token, nil
+}
+
+// reauthenticate logs in again after the server rejected rejected, unless
+// another request already replaced that token.
+func (c *Client) reauthenticate(ctx context.Context, rejected string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.token != rejected {
+		return nil
+	}
+	return c.loginLocked(ctx)
+}
+
+func (c *Client) loginLocked(ctx context.Context) error {
+	var res Session
+	err := c.do(ctx, request{
+		method: http.MethodPost,
+		path:   "/auth/login",
+		body:   loginRequest{Username: c.username, Password: c.password},
+		public: true,
+	}, &res)
+	if err != nil {
+		return err
+	}
+	c.setToken(res.Token)
+	return nil
+}
+
+// tokenExpiry reads the exp claim of a JWT without verifying it; the zero
+// time means unknown.
+func tokenExpiry(token string) time.Time {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return time.Time{}
+	}
+	var claims struct {
+		Exp int64 `json:"exp"`
+	}
+	if err := json.Unmarshal(raw, &claims); err != nil || claims.Exp == 0 {
+		return time.Time{}
+	}
+	return time.Unix(claims.Exp, 0)
+}
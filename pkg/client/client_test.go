@@ -0,0 +1,290 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"habit-tracker/internal/app/apptest"
+	"habit-tracker/internal/models"
+	"habit-tracker/internal/utils"
+)
+
+// faultyServer serves the real router but can answer the next requests to a
+// route with an error status instead.
+type faultyServer struct {
+	URL string
+
+	mu     sync.Mutex
+	faults map[string][]int
+	hits   map[string]int
+}
+
+func newFaultyServer(t *testing.T) *faultyServer {
+	srv := apptest.New(t)
+	first := models.Achievement{Code: "first_checkin", Name: "First step", ConditionType: "total_checkins", ConditionValue: 1}
+	if err := srv.DB.Create(&first).Error; err != nil {
+		t.Fatalf("seed achievement: %v", err)
+	}
+	f := &faultyServer{faults: map[string][]int{}, hits: map[string]int{}}
+	hs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Method + " " + r.URL.Path
+		f.mu.Lock()
+		f.hits[key]++
+		var status int
+		if queue := f.faults[key]; len(queue) > 0 {
+			status, f.faults[key] = queue[0], queue[1:]
+		}
+		f.mu.Unlock()
+		if status != 0 {
+			if status == http.StatusTooManyRequests {
+				w.Header().Set("Retry-After", "0")
+			}
+			http.Error(w, http.StatusText(status), status)
+			return
+		}
+		srv.Router.ServeHTTP(w, r)
+	}))
+	t.Cleanup(hs.Close)
+	f.URL = hs.URL
+	return f
+}
+
+// fail makes the next requests to route fail with the given statuses.
+func (f *faultyServer) fail(route string, statuses ...int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.faults[route] = append(f.faults[route], statuses...)
+}
+
+func (f *faultyServer) hitCount(route string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.hits[route]
+}
+
+func newTestClient(t *testing.T, f *faultyServer, username string) *Client {
+	t.Helper()
+	ctx := context.Background()
+	c := New(f.URL, WithRetries(3, time.Millisecond))
+	if _, err := c.Register(ctx, username, "secret123", "", ""); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	if _, err := c.Login(ctx, username, "secret123"); err != nil {
+		t.Fatalf("login: %v", err)
+	}
+	return c
+}
+
+func newHabit(t *testing.T, c *Client) *Habit {
+	t.Helper()
+	h, err := c.CreateHabit(context.Background(), HabitInput{
+		Name:        "Read",
+		TargetType:  TargetDaily,
+		TargetTimes: 1,
+		StartDate:   time.Now(),
+		Tags:        []string{"mind"},
+	})
+	if err != nil {
+		t.Fatalf("create habit: %v", err)
+	}
+	return h
+}
+
+func TestClientFlow(t *testing.T) {
+	f := newFaultyServer(t)
+	c := newTestClient(t, f, "alice")
+	ctx := context.Background()
+
+	profile, err := c.Profile(ctx)
+	if err != nil {
+		t.Fatalf("profile: %v", err)
+	}
+	if profile.Username != "alice" {
+		t.Fatalf("profile username = %q", profile.Username)
+	}
+
+	h := newHabit(t, c)
+	if h.Name != "Read" || len(h.Tags) != 1 || h.Tags[0].Name != "mind" {
+		t.Fatalf("created habit = %+v", h)
+	}
+	habits, err := c.ListHabits(ctx, &HabitFilter{Tag: "mind"})
+	if err != nil || len(habits) != 1 || habits[0].ID != h.ID {
+		t.Fatalf("list habits = %+v, %v", habits, err)
+	}
+
+	res, err := c.Checkin(ctx, CheckinInput{HabitID: h.ID, Note: "chapter 1"})
+	if err != nil {
+		t.Fatalf("checkin: %v", err)
+	}
+	if !res.ReachedTarget || res.TodayCount != 1 || res.PointsAwarded <= 0 {
+		t.Fatalf("checkin result = %+v", res)
+	}
+	checkins, err := c.ListCheckins(ctx, h.ID, DateRange{})
+	if err != nil || len(checkins) != 1 || checkins[0].Note != "chapter 1" {
+		t.Fatalf("list checkins = %+v, %v", checkins, err)
+	}
+
+	board, err := c.WeeklyLeaderboard(ctx)
+	if err != nil {
+		t.Fatalf("weekly leaderboard: %v", err)
+	}
+	if len(board) != 1 || board[0].UserID != profile.ID || board[0].Points != int64(res.PointsAwarded) {
+		t.Fatalf("weekly leaderboard = %+v", board)
+	}
+
+	catalog, err := c.Achievements(ctx)
+	if err != nil || len(catalog) != 1 || catalog[0].Code != "first_checkin" {
+		t.Fatalf("achievements = %+v, %v", catalog, err)
+	}
+	unlocked, err := c.UserAchievements(ctx)
+	if err != nil || len(unlocked) != 1 || len(res.UnlockedAwards) != 1 || unlocked[0].AchievementID != catalog[0].ID {
+		t.Fatalf("user achievements = %+v, %v; unlocked by checkin %+v", unlocked, err, res.UnlockedAwards)
+	}
+
+	stats, err := c.UserStats(ctx)
+	if err != nil || stats.TotalCheckins != 1 {
+		t.Fatalf("user stats = %+v, %v", stats, err)
+	}
+	heatmap, err := c.HabitHeatmap(ctx, h.ID, DateRange{Start: time.Now().AddDate(0, 0, -6), End: time.Now()})
+	if err != nil || len(heatmap.Values) != 7 {
+		t.Fatalf("habit heatmap = %+v, %v", heatmap, err)
+	}
+
+	if err := c.DeleteHabit(ctx, h.ID); err != nil {
+		t.Fatalf("delete habit: %v", err)
+	}
+	_, err = c.GetHabit(ctx, h.ID)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound || apiErr.Code == "" || !IsNotFound(err) {
+		t.Fatalf("get deleted habit: %v", err)
+	}
+}
+
+func TestClientLogsInAgainAfter401(t *testing.T) {
+	f := newFaultyServer(t)
+	c := newTestClient(t, f, "bob")
+
+	c.mu.Lock()
+	c.setToken("garbage")
+	c.mu.Unlock()
+
+	if _, err := c.Profile(context.Background()); err != nil {
+		t.Fatalf("profile: %v", err)
+	}
+	if n := f.hitCount("POST /api/v1/auth/login"); n != 2 {
+		t.Fatalf("logins = %d, want 2", n)
+	}
+	if c.Token() == "garbage" {
+		t.Fatal("token was not replaced")
+	}
+}
+
+func TestClientWithoutCredentialsReturns401(t *testing.T) {
+	f := newFaultyServer(t)
+	newTestClient(t, f, "carol")
+
+	c := New(f.URL, WithToken("garbage"))
+	_, err := c.Profile(context.Background())
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("profile with bad token: %v", err)
+	}
+}
+
+func TestClientRefreshesTokenBeforeExpiry(t *testing.T) {
+	f := newFaultyServer(t)
+	c := newTestClient(t, f, "dave")
+	ctx := context.Background()
+	profile, err := c.Profile(ctx)
+	if err != nil {
+		t.Fatalf("profile: %v", err)
+	}
+
+	expiring, err := utils.NewJWTManager("apptest", refreshBefore/2).GenerateToken(profile.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.mu.Lock()
+	c.setToken(expiring)
+	c.mu.Unlock()
+
+	if _, err := c.Profile(ctx); err != nil {
+		t.Fatalf("profile: %v", err)
+	}
+	if n := f.hitCount("POST /api/v1/auth/login"); n != 2 {
+		t.Fatalf("logins = %d, want 2", n)
+	}
+	if c.Token() == expiring {
+		t.Fatal("expiring token was not renewed")
+	}
+}
+
+func TestClientRetries(t *testing.T) {
+	f := newFaultyServer(t)
+	c := newTestClient(t, f, "erin")
+	ctx := context.Background()
+	h := newHabit(t, c)
+
+	f.fail("GET /api/v1/habits", http.StatusServiceUnavailable, http.StatusBadGateway)
+	habits, err := c.ListHabits(ctx, nil)
+	if err != nil || len(habits) != 1 {
+		t.Fatalf("list habits = %+v, %v", habits, err)
+	}
+	if n := f.hitCount("GET /api/v1/habits"); n != 3 {
+		t.Fatalf("list attempts = %d, want 3", n)
+	}
+
+	// 429 means the request was not processed, so even a POST is retried
+	f.fail("POST /api/v1/checkins", http.StatusTooManyRequests)
+	res, err := c.Checkin(ctx, CheckinInput{HabitID: h.ID})
+	if err != nil || res.TodayCount != 1 {
+		t.Fatalf("checkin = %+v, %v", res, err)
+	}
+
+	// a POST that failed with 5xx may have been applied and is not repeated
+	f.fail("POST /api/v1/checkins", http.StatusInternalServerError)
+	_, err = c.Checkin(ctx, CheckinInput{HabitID: h.ID})
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("checkin after 500: %v", err)
+	}
+	if n := f.hitCount("POST /api/v1/checkins"); n != 3 {
+		t.Fatalf("checkin attempts = %d, want 3", n)
+	}
+
+	f.fail("GET /api/v1/habits", http.StatusServiceUnavailable, http.StatusServiceUnavailable,
+		http.StatusServiceUnavailable, http.StatusServiceUnavailable)
+	_, err = c.ListHabits(ctx, nil)
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("list habits after exhausting retries: %v", err)
+	}
+}
+
+func TestClientCancelDuringBackoff(t *testing.T) {
+	f := newFaultyServer(t)
+	newTestClient(t, f, "frank")
+	c := New(f.URL, WithRetries(5, time.Hour))
+	if _, err := c.Login(context.Background(), "frank", "secret123"); err != nil {
+		t.Fatalf("login: %v", err)
+	}
+
+	f.fail("GET /api/v1/user/stats", http.StatusServiceUnavailable)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := c.UserStats(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("user stats: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("cancellation took %v", elapsed)
+	}
+	if n := f.hitCount("GET /api/v1/user/stats"); n != 1 {
+		t.Fatalf("stats attempts = %d, want 1", n)
+	}
+}
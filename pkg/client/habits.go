@@ -0,0 +1,117 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+func habitPath(id uint64) string {
+	return "/habits/" + strconv.FormatUint(id, 10)
+}
+
+// ListHabits returns the habits of the user; filter may be nil.
+func (c *Client) ListHabits(ctx context.Context, filter *HabitFilter) ([]Habit, error) {
+	q := url.Values{}
+	if filter != nil {
+		if filter.IsActive != nil {
+			q.Set("is_active", strconv.FormatBool(*filter.IsActive))
+		}
+		if filter.Archived {
+			q.Set("archived", "true")
+		}
+		if filter.CategoryID != 0 {
+			q.Set("category_id", strconv.FormatUint(filter.CategoryID, 10))
+		}
+		if filter.Tag != "" {
+			q.Set("tag", filter.Tag)
+		}
+		if filter.Sort != "" {
+			q.Set("sort", filter.Sort)
+		}
+	}
+	var res []Habit
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/habits", query: q}, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (c *Client) GetHabit(ctx context.Context, id uint64) (*Habit, error) {
+	return c.habit(ctx, request{method: http.MethodGet, path: habitPath(id)})
+}
+
+func (c *Client) CreateHabit(ctx context.Context, in HabitInput) (*Habit, error) {
+	return c.habit(ctx, request{method: http.MethodPost, path: "/habits", body: in.body()})
+}
+
+// UpdateHabit replaces the settings of a habit.
+func (c *Client) UpdateHabit(ctx context.Context, id uint64, in HabitInput) (*Habit, error) {
+	body := in.body()
+	body.IsActive = in.IsActive
+	return c.habit(ctx, request{method: http.MethodPut, path: habitPath(id), body: body})
+}
+
+// SetHabitActive pauses or resumes a habit.
+func (c *Client) SetHabitActive(ctx context.Context, id uint64, active bool) error {
+	return c.do(ctx, request{
+		method: http.MethodPatch,
+		path:   habitPath(id) + "/status",
+		body:   map[string]bool{"is_active": active},
+	}, nil)
+}
+
+// DeleteHabit moves a habit to the trash.
+func (c *Client) DeleteHabit(ctx context.Context, id uint64) error {
+	return c.do(ctx, request{method: http.MethodDelete, path: habitPath(id)}, nil)
+}
+
+// PurgeHabit deletes a habit permanently. With revokePoints the points it
+// earned are taken back, otherwise the user keeps them.
+func (c *Client) PurgeHabit(ctx context.Context, id uint64, revokePoints bool) error {
+	policy := "keep"
+	if revokePoints {
+		policy = "revoke"
+	}
+	q := url.Values{"hard": {"true"}, "points_policy": {policy}}
+	return c.do(ctx, request{method: http.MethodDelete, path: habitPath(id), query: q}, nil)
+}
+
+// ListDeletedHabits returns the habits in the trash.
+func (c *Client) ListDeletedHabits(ctx context.Context) ([]Habit, error) {
+	var res []Habit
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/habits/trash"}, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (c *Client) RestoreHabit(ctx context.Context, id uint64) (*Habit, error) {
+	return c.habit(ctx, request{method: http.MethodPost, path: habitPath(id) + "/restore"})
+}
+
+func (c *Client) ArchiveHabit(ctx context.Context, id uint64) (*Habit, error) {
+	return c.habit(ctx, request{method: http.MethodPost, path: habitPath(id) + "/archive"})
+}
+
+func (c *Client) UnarchiveHabit(ctx context.Context, id uint64) (*Habit, error) {
+	return c.habit(ctx, request{method: http.MethodPost, path: habitPath(id) + "/unarchive"})
+}
+
+// ReorderHabits sets the manual order of the habits to ids.
+func (c *Client) ReorderHabits(ctx context.Context, ids []uint64) error {
+	return c.do(ctx, request{
+		method: http.MethodPut,
+		path:   "/habits/order",
+		body:   map[string][]uint64{"habit_ids": ids},
+	}, nil)
+}
+
+func (c *Client) habit(ctx context.Context, req request) (*Habit, error) {
+	var res Habit
+	if err := c.do(ctx, req, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
@@ -0,0 +1,42 @@
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+// WeeklyLeaderboard ranks users by the points earned this week.
+func (c *Client) WeeklyLeaderboard(ctx context.Context) ([]LeaderboardEntry, error) {
+	return c.leaderboard(ctx, "/leaderboard/weekly")
+}
+
+// MonthlyLeaderboard ranks users by the points earned this month.
+func (c *Client) MonthlyLeaderboard(ctx context.Context) ([]LeaderboardEntry, error) {
+	return c.leaderboard(ctx, "/leaderboard/monthly")
+}
+
+func (c *Client) leaderboard(ctx context.Context, path string) ([]LeaderboardEntry, error) {
+	var res []LeaderboardEntry
+	if err := c.do(ctx, request{method: http.MethodGet, path: path}, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// Achievements returns the achievement catalog.
+func (c *Client) Achievements(ctx context.Context) ([]Achievement, error) {
+	var res []Achievement
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/achievements"}, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// UserAchievements returns the achievements the user has unlocked.
+func (c *Client) UserAchievements(ctx context.Context) ([]UserAchievement, error) {
+	var res []UserAchievement
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/achievements/user"}, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
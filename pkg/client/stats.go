@@ -0,0 +1,56 @@
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+func (c *Client) UserStats(ctx context.Context) (*UserStats, error) {
+	var res UserStats
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/user/stats"}, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (c *Client) HabitStats(ctx context.Context, habitID uint64) (*HabitStats, error) {
+	var res HabitStats
+	if err := c.do(ctx, request{method: http.MethodGet, path: habitPath(habitID) + "/stats"}, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// HabitHeatmap returns the daily progress of a habit, by default of the last year.
+func (c *Client) HabitHeatmap(ctx context.Context, habitID uint64, r DateRange) (*Heatmap, error) {
+	return c.heatmap(ctx, request{method: http.MethodGet, path: habitPath(habitID) + "/heatmap", query: r.query()})
+}
+
+// UserHeatmap returns the daily completion over all habits of the user.
+func (c *Client) UserHeatmap(ctx context.Context, r DateRange) (*Heatmap, error) {
+	return c.heatmap(ctx, request{method: http.MethodGet, path: "/user/heatmap", query: r.query()})
+}
+
+func (c *Client) heatmap(ctx context.Context, req request) (*Heatmap, error) {
+	var res Heatmap
+	if err := c.do(ctx, req, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// UserSeries returns points and check-ins per day, week or month.
+func (c *Client) UserSeries(ctx context.Context, q SeriesQuery) (*Series, error) {
+	query := DateRange{Start: q.Start, End: q.End}.query()
+	if q.Granularity != "" {
+		query.Set("granularity", q.Granularity)
+	}
+	if q.Timezone != "" {
+		query.Set("tz", q.Timezone)
+	}
+	var res Series
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/user/series", query: query}, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
@@ -0,0 +1,299 @@
+package client
+
+import "time"
+
+// User is the profile of the authenticated user.
+type User struct {
+	ID                 uint64    `json:"id"`
+	Username           string    `json:"username"`
+	Nickname           string    `json:"nickname"`
+	Points             int64     `json:"points"`
+	TotalCheckins      int64     `json:"total_checkins"`
+	Timezone           string    `json:"timezone"`
+	ReminderWebhookURL string    `json:"reminder_webhook_url"`
+	CreatedAt          time.Time `json:"created_at"`
+}
+
+// Registration is a newly created account.
+type Registration struct {
+	ID       uint64 `json:"id"`
+	Username string `json:"username"`
+	Nickname string `json:"nickname"`
+	Timezone string `json:"timezone"`
+}
+
+// Session is the result of a login.
+type Session struct {
+	Token    string `json:"token"`
+	UserID   uint64 `json:"user_id"`
+	Username string `json:"username"`
+	Nickname string `json:"nickname"`
+}
+
+// Tag is a label attached to habits.
+type Tag struct {
+	ID     uint64 `json:"id"`
+	UserID uint64 `json:"user_id"`
+	Name   string `json:"name"`
+}
+
+// Target types, kinds and polarities of a habit.
+const (
+	TargetDaily  = "daily"
+	TargetWeekly = "weekly"
+	TargetCustom = "custom"
+
+	KindCount      = "count"
+	KindMeasurable = "measurable"
+
+	PolarityBuild = "build"
+	PolarityQuit  = "quit"
+)
+
+// Habit is a habit as returned by the server.
+type Habit struct {
+	ID                uint64     `json:"id"`
+	UserID            uint64     `json:"user_id"`
+	Name              string     `json:"name"`
+	Description       string     `json:"description"`
+	TargetType        string     `json:"target_type"`
+	TargetTimes       int        `json:"target_times"`
+	ScheduleDays      []int      `json:"schedule_days"`
+	Kind              string     `json:"kind"`
+	Unit              string     `json:"unit"`
+	TargetQuantity    float64    `json:"target_quantity"`
+	Polarity          string     `json:"polarity"`
+	LastCleanDate     *time.Time `json:"last_clean_date"`
+	StartDate         time.Time  `json:"start_date"`
+	IsActive          bool       `json:"is_active"`
+	CategoryID        *uint64    `json:"category_id"`
+	Color             string     `json:"color"`
+	Icon              string     `json:"icon"`
+	SortOrder         int        `json:"sort_order"`
+	Tags              []Tag      `json:"tags"`
+	ArchivedAt        *time.Time `json:"archived_at"`
+	DeletedAt         *time.Time `json:"deleted_at"`
+	CurrentStreak     int        `json:"current_streak"`
+	LongestStreak     int        `json:"longest_streak"`
+	LastCompletedDate *time.Time `json:"last_completed_date"`
+}
+
+// HabitInput creates or replaces a habit. Name, TargetType and StartDate
+// are required; only the date part of StartDate is used.
+type HabitInput struct {
+	Name           string
+	Description    string
+	TargetType     string
+	TargetTimes    int
+	ScheduleDays   []int
+	Kind           string
+	Unit           string
+	TargetQuantity float64
+	Polarity       string
+	StartDate      time.Time
+	CategoryID     *uint64
+	Color          *string
+	Icon           *string
+	Tags           []string
+	// IsActive is only used by UpdateHabit; nil keeps the current state.
+	IsActive *bool
+}
+
+type habitBody struct {
+	Name           string   `json:"name"`
+	Description    string   `json:"description,omitempty"`
+	TargetType     string   `json:"target_type"`
+	TargetTimes    int      `json:"target_times,omitempty"`
+	ScheduleDays   []int    `json:"schedule_days,omitempty"`
+	Kind           string   `json:"kind,omitempty"`
+	Unit           string   `json:"unit,omitempty"`
+	TargetQuantity float64  `json:"target_quantity,omitempty"`
+	Polarity       string   `json:"polarity,omitempty"`
+	StartDate      string   `json:"start_date"`
+	CategoryID     *uint64  `json:"category_id,omitempty"`
+	Color          *string  `json:"color,omitempty"`
+	Icon           *string  `json:"icon,omitempty"`
+	Tags           []string `json:"tags,omitempty"`
+	IsActive       *bool    `json:"is_active,omitempty"`
+}
+
+func (in HabitInput) body() habitBody {
+	return habitBody{
+		Name:           in.Name,
+		Description:    in.Description,
+		TargetType:     in.TargetType,
+		TargetTimes:    in.TargetTimes,
+		ScheduleDays:   in.ScheduleDays,
+		Kind:           in.Kind,
+		Unit:           in.Unit,
+		TargetQuantity: in.TargetQuantity,
+		Polarity:       in.Polarity,
+		StartDate:      formatDate(in.StartDate),
+		CategoryID:     in.CategoryID,
+		Color:          in.Color,
+		Icon:           in.Icon,
+		Tags:           in.Tags,
+	}
+}
+
+// HabitFilter narrows ListHabits; zero fields are not filtered on.
+type HabitFilter struct {
+	IsActive   *bool
+	Archived   bool
+	CategoryID uint64
+	Tag        string
+	// Sort is one of "manual", "name", "start_date" and "created".
+	Sort string
+}
+
+// Checkin is the check-in record of one habit on one day.
+type Checkin struct {
+	ID          uint64    `json:"id"`
+	HabitID     uint64    `json:"habit_id"`
+	UserID      uint64    `json:"user_id"`
+	CheckinDate time.Time `json:"checkin_date"`
+	Count       int       `json:"count"`
+	Quantity    float64   `json:"quantity"`
+	Note        string    `json:"note"`
+	Mood        *int      `json:"mood"`
+	PhotoKey    string    `json:"photo_key"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// CheckinInput records progress on a habit for today. CountInc defaults to
+// 1 for count habits; measurable habits use Quantity instead.
+type CheckinInput struct {
+	HabitID  uint64  `json:"habit_id"`
+	CountInc int     `json:"count_inc,omitempty"`
+	Quantity float64 `json:"quantity,omitempty"`
+	Note     string  `json:"note,omitempty"`
+	// Mood is 1 to 5.
+	Mood *int `json:"mood,omitempty"`
+}
+
+// CheckinResult describes what a check-in changed.
+type CheckinResult struct {
+	CheckinID      uint64
+	TodayCount     int
+	TodayQuantity  float64
+	ReachedTarget  bool
+	StreakDays     int
+	TotalCheckins  int
+	PointsAwarded  int
+	FreezeEarned   bool
+	UnlockedAwards []UserAchievement
+}
+
+// RelapseResult is the state of a quit habit after a logged relapse.
+type RelapseResult struct {
+	RelapsesToday  int `json:"relapses_today"`
+	PreviousStreak int `json:"previous_streak"`
+	StreakDays     int `json:"streak_days"`
+}
+
+// LeaderboardEntry is one row of a leaderboard.
+type LeaderboardEntry struct {
+	UserID   uint64 `json:"user_id"`
+	Nickname string `json:"nickname"`
+	Points   int64  `json:"points"`
+	Rank     int    `json:"rank"`
+}
+
+// Achievement is an entry of the achievement catalog.
+type Achievement struct {
+	ID             uint64 `json:"id"`
+	Code           string `json:"code"`
+	Name           string `json:"name"`
+	Description    string `json:"description"`
+	ConditionType  string `json:"condition_type"`
+	ConditionValue int    `json:"condition_value"`
+}
+
+// UserAchievement is an achievement the user has unlocked.
+type UserAchievement struct {
+	ID            uint64    `json:"id"`
+	UserID        uint64    `json:"user_id"`
+	AchievementID uint64    `json:"achievement_id"`
+	UnlockedAt    time.Time `json:"unlocked_at"`
+}
+
+// UserStats summarises the activity of the user.
+type UserStats struct {
+	TotalCheckins   int64 `json:"total_checkins"`
+	WeeklyCheckins  int64 `json:"weekly_checkins"`
+	MonthlyCheckins int64 `json:"monthly_checkins"`
+	WeeklyPoints    int64 `json:"weekly_points"`
+	MonthlyPoints   int64 `json:"monthly_points"`
+	LongestStreak   int   `json:"longest_streak"`
+}
+
+// HabitStats is the completion analysis of one habit.
+type HabitStats struct {
+	HabitID        uint64        `json:"habit_id"`
+	ScheduleDays   []int         `json:"schedule_days"`
+	ScheduledDays  int           `json:"scheduled_days"`
+	CompletedDays  int           `json:"completed_days"`
+	CompletionRate float64       `json:"completion_rate"`
+	CurrentStreak  int           `json:"current_streak"`
+	LongestStreak  int           `json:"longest_streak"`
+	TotalCount     int64         `json:"total_count"`
+	TotalQuantity  float64       `json:"total_quantity"`
+	BestWeekday    *int          `json:"best_weekday"`
+	Weekdays       []WeekdayStat `json:"weekdays"`
+	WeeklyTrend    []WeekStat    `json:"weekly_trend"`
+}
+
+// WeekdayStat is the completion of a habit on one weekday, 0 being Sunday.
+type WeekdayStat struct {
+	Weekday   int     `json:"weekday"`
+	Scheduled int     `json:"scheduled"`
+	Completed int     `json:"completed"`
+	Rate      float64 `json:"rate"`
+}
+
+// WeekStat is the completion of a habit in the week starting on WeekStart.
+type WeekStat struct {
+	WeekStart string  `json:"week_start"`
+	Scheduled int     `json:"scheduled"`
+	Completed int     `json:"completed"`
+	Rate      float64 `json:"rate"`
+}
+
+// Heatmap holds one value per day from StartDate to EndDate; nil marks
+// days before the habit started.
+type Heatmap struct {
+	HabitID   *uint64    `json:"habit_id"`
+	StartDate string     `json:"start_date"`
+	EndDate   string     `json:"end_date"`
+	Values    []*float64 `json:"values"`
+}
+
+// Series is the points and check-in count per time bucket.
+type Series struct {
+	Granularity      string   `json:"granularity"`
+	Timezone         string   `json:"timezone"`
+	Buckets          []string `json:"buckets"`
+	Points           []int64  `json:"points"`
+	CumulativePoints []int64  `json:"cumulative_points"`
+	Checkins         []int64  `json:"checkins"`
+}
+
+// SeriesQuery selects the range of UserSeries; zero fields use the server
+// defaults.
+type SeriesQuery struct {
+	Start, End time.Time
+	// Granularity is "day", "week" or "month".
+	Granularity string
+	// Timezone is an IANA name; the user's own zone by default.
+	Timezone string
+}
+
+// DateRange limits a query to the days from Start to End; a zero bound uses
+// the server default.
+type DateRange struct {
+	Start, End time.Time
+}
+
+func formatDate(t time.Time) string {
+	return t.Format("2006-01-02")
+}
@@ -39,9 +39,10 @@ document.addEventListener('DOMContentLoaded', () => {
             const username = registerForm.username.value;
             const password = registerForm.password.value;
             const nickname = registerForm.nickname.value;
+            const timezone = Intl.DateTimeFormat().resolvedOptions().timeZone || '';
 
             try {
-                await Api.post('/auth/register', { username, password, nickname });
+                await Api.post('/auth/register', { username, password, nickname, timezone });
                 alert('Registration successful! Please login.');
                 window.location.href = '/static/login.html';
             } catch (error) {
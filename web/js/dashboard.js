@@ -2,8 +2,49 @@ document.addEventListener('DOMContentLoaded', () => {
     Api.checkAuth();
     loadDashboard();
     loadUserStats();
+    subscribeLiveUpdates();
 });
 
+// 通过 SSE 接收其他设备上的打卡与积分变动。连接票据只能使用一次，
+// 所以断线后不用浏览器的自动重连，而是换新票据并带上 last_event_id 补发漏掉的事件
+let lastEventId = '';
+
+async function subscribeLiveUpdates() {
+    if (!Api.getToken() || !window.EventSource) {
+        return;
+    }
+    let ticket;
+    try {
+        const response = await Api.post('/events/ticket');
+        ticket = response.data.ticket;
+    } catch (error) {
+        console.error('Failed to open live updates:', error);
+        return;
+    }
+    let url = `${API_BASE_URL}/events/stream?ticket=${encodeURIComponent(ticket)}`;
+    if (lastEventId) {
+        url += `&last_event_id=${encodeURIComponent(lastEventId)}`;
+    }
+    const source = new EventSource(url);
+    const refresh = () => {
+        loadDashboard();
+        loadUserStats();
+    };
+    const track = (handler) => (event) => {
+        if (event.lastEventId) {
+            lastEventId = event.lastEventId;
+        }
+        handler();
+    };
+    source.addEventListener('checkin', track(refresh));
+    source.addEventListener('points', track(loadUserStats));
+    source.addEventListener('reset', refresh);
+    source.onerror = () => {
+        source.close();
+        setTimeout(subscribeLiveUpdates, 3000);
+    };
+}
+
 async function loadDashboard() {
     try {
         // Fetch all habits. Ideally, there would be an endpoint for "today's habits"
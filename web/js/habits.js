@@ -93,6 +93,7 @@ async function loadHabitDetail() {
 
         // Load checkins
         loadHabitCheckins(id);
+        loadHabitStats(id);
 
     } catch (error) {
         console.error('Failed to load habit detail:', error);
@@ -126,6 +127,33 @@ async function loadHabitCheckins(id) {
     }
 }
 
+const WEEKDAY_NAMES = ['周日', '周一', '周二', '周三', '周四', '周五', '周六'];
+
+async function loadHabitStats(id) {
+    try {
+        const response = await Api.get(`/habits/${id}/stats`);
+        const stats = response.data;
+
+        document.getElementById('stat-rate').textContent = `${Math.round(stats.completion_rate * 100)}% (${stats.completed_days}/${stats.scheduled_days})`;
+        document.getElementById('stat-current').textContent = `${stats.current_streak} 天`;
+        document.getElementById('stat-longest').textContent = `${stats.longest_streak} 天`;
+        document.getElementById('stat-total').textContent = stats.total_count;
+        document.getElementById('stat-weekday').textContent = stats.best_weekday === null ? '-' : WEEKDAY_NAMES[stats.best_weekday];
+
+        const trend = document.getElementById('stat-trend');
+        trend.innerHTML = '';
+        (stats.weekly_trend || []).forEach(w => {
+            const bar = document.createElement('div');
+            bar.className = 'bg-primary flex-fill';
+            bar.style.height = `${Math.max(w.rate * 100, 2)}%`;
+            bar.title = `${w.week_start}: ${w.completed}/${w.scheduled}`;
+            trend.appendChild(bar);
+        });
+    } catch (error) {
+        console.error('Failed to load habit stats:', error);
+    }
+}
+
 async function openEditModal(habitId) {
     try {
         const response = await Api.get(`/habits/${habitId}`);